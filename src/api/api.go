@@ -2,20 +2,28 @@ package api
 
 import (
 	"github.com/dattito/purrmannplus-backend/api/providers"
-	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/app/commands"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 )
 
-var api providers.Provider
+// Serves the api of an app
+type Api struct {
+	provider providers.Provider
+	port     int
+}
+
+// Initialize the api object for the given app
+func New(a *commands.App) (*Api, error) {
+	api := &Api{
+		provider: providers.GetProvider(a),
+		port:     a.Config.LISTENING_PORT,
+	}
 
-// Initialize the api object
-func Init() error {
-	api = providers.GetProvider()
-	return api.Init()
+	return api, api.provider.Init()
 }
 
 // Start the api to listen on the configured port
-func StartListening() error {
-	logging.Infof("Starting listening on port %d", config.LISTENING_PORT)
-	return api.StartListening()
+func (a *Api) StartListening() error {
+	logging.Infof("Starting listening on port %d", a.port)
+	return a.provider.StartListening()
 }
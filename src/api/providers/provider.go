@@ -1,12 +1,15 @@
 package providers
 
-import "github.com/dattito/purrmannplus-backend/api/providers/rest"
+import (
+	"github.com/dattito/purrmannplus-backend/api/providers/rest"
+	"github.com/dattito/purrmannplus-backend/app/commands"
+)
 
 type Provider interface {
 	Init() error
 	StartListening() error
 }
 
-func GetProvider() Provider {
-	return &rest.RestProvider{}
+func GetProvider(a *commands.App) Provider {
+	return rest.NewRestProvider(a)
 }
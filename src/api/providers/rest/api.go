@@ -1,23 +1,30 @@
 package rest
 
 import (
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/controllers"
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/routes"
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/session"
+	"github.com/dattito/purrmannplus-backend/app/commands"
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
 	"github.com/dattito/purrmannplus-backend/config"
+	jwt_utils "github.com/dattito/purrmannplus-backend/utils/jwt"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	jwtware "github.com/gofiber/jwt/v3"
 	"github.com/gofiber/template/amber"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 // Get the JWT configuration for the api
-func getJWTConfig() jwtware.Config {
+func getJWTConfig(secret string) jwtware.Config {
 	return jwtware.Config{
-		SigningKey: []byte(config.JWT_SECRET),
+		SigningKey: []byte(secret),
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			IsMissingOrMalformedJWT := err.Error() == "Missing or malformed JWT"
 
@@ -36,65 +43,152 @@ func getJWTConfig() jwtware.Config {
 	}
 }
 
-// Protected is a middleware that checks if the user is logged in
-func Protected() fiber.Handler {
-	return jwtware.New(getJWTConfig())
+// Protected is a middleware that checks if the user is logged in with a token signed by the secret of the app.
+// The account of the token is loaded on every request, so deleted or disabled accounts lose their access immediately.
+func Protected(a *commands.App) fiber.Handler {
+	cfg := getJWTConfig(a.Config.JWT_SECRET)
+	cfg.SuccessHandler = func(c *fiber.Ctx) error {
+		user := c.Locals("user").(*jwt.Token)
+		claims := user.Claims.(jwt.MapClaims)
+		accountId, _ := claims["account_id"].(string)
+
+		account, user_err, db_err := a.GetLoggedInAccount(accountId)
+		if db_err != nil {
+			logging.Errorf("Error while getting the logged in account: %v", db_err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Something went wrong",
+			})
+		}
+
+		if user_err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": user_err.Error(),
+			})
+		}
+
+		c.Locals("account", account)
+		return c.Next()
+	}
+
+	return jwtware.New(cfg)
+}
+
+// Admin is a middleware that checks if the logged in user is an admin, has to be used after Protected()
+func Admin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		account := c.Locals("account").(app_models.Account)
+
+		if account.Role != app_models.RoleAdmin {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin rights required",
+			})
+		}
+
+		return c.Next()
+	}
 }
 
 type RestProvider struct {
-	app *fiber.App
+	app      *fiber.App
+	config   *config.Config
+	handlers *controllers.Handlers
+}
+
+// Returns a RestProvider which answers the requests using the given app
+func NewRestProvider(a *commands.App) *RestProvider {
+	cfg := a.Config
+	tokens := jwt_utils.NewIssuer(cfg.JWT_SECRET, cfg.JWT_SHORTLIVING_SECRET, time.Duration(cfg.AUTHORIZATION_EXPIRATION_TIME)*time.Second)
+
+	return &RestProvider{
+		config:   cfg,
+		handlers: controllers.New(a, session.New(cfg), tokens),
+	}
 }
 
 // Initialize the fiber app and sets the routes and middlewares
 func (r *RestProvider) Init() error {
 	r.app = fiber.New(fiber.Config{
-		Views: amber.New(config.PATH_TO_API_VIEWS, ".amber"),
+		Views: amber.New(r.config.PATH_TO_API_VIEWS, ".amber"),
+		// The json encoder bundled with fiber v2.22 crashes while encoding maps with go 1.27
+		JSONEncoder: json.Marshal,
 	})
 
-	r.app.Static("/static", config.PATH_TO_API_STATIC)
+	r.app.Static("/static", r.config.PATH_TO_API_STATIC)
 
-	if config.CORS_ALLOWED_ORIGINS != "" {
+	if r.config.CORS_ALLOWED_ORIGINS != "" {
 		r.app.Use(cors.New(cors.Config{
-			AllowOrigins: config.CORS_ALLOWED_ORIGINS,
+			AllowOrigins: r.config.CORS_ALLOWED_ORIGINS,
 			AllowHeaders: "Origin, Content-Type, Accept",
 		}))
 	}
 
 	r.app.Use(compress.New())
 
+	h := r.handlers
+	protected := Protected(r.handlers.App)
+
 	r.app.Get(routes.HealthRoute, controllers.GetHealth)
 	r.app.Get(routes.AboutRoute, controllers.About)
 
 	v1 := r.app.Group("/v1")
 
-	v1.Post(routes.AccountLoginRoute, controllers.AccountLogin)
+	v1.Post(routes.AccountLoginRoute, h.AccountLogin)
 	v1.Get(routes.AccountLogoutRoute, controllers.AccountLogout)
-	v1.Get(routes.IsLoggedInRoute, Protected(), controllers.IsLoggedIn)
-
-	v1.Post(routes.AddAccountRoute, controllers.AddAccount)
-	v1.Delete(routes.DeleteAccountRoute, Protected(), controllers.DeleteAccount)
-	//v1.Get(GetAccountsRoute, controllers.GetAccounts)
-	v1.Post(routes.SendPhoneNumberConfirmationLinkRoute, Protected(), controllers.SendPhoneNumberConfirmationLink)
-	v1.Get(routes.AddPhoneNumberRoute, controllers.AddPhoneNumber)
-
-	v1.Post(routes.AddAccountToSubstitutionUpdaterRoute, Protected(), controllers.AddAccountToSubstitutionUpdater)
-	v1.Delete(routes.RemoveAccountFromSubstitutionUpdaterRoute, Protected(), controllers.RemoveAccountFromSubstitutionUpdater)
-
-	v1.Post(routes.AddAccountToMoodleAssignmentUpdaterRoute, Protected(), controllers.AddAccountToMoodleAssignmentUpdater)
-	v1.Delete(routes.RemoveAccountFromMoodleAssignmentUpdaterRoute, Protected(), controllers.RemoveAccountFromMoodleAssignmentUpdater)
-
-	r.app.All(routes.RegistrationSpeedFormRoute, controllers.RegistrationSpeedForm)
-	r.app.All(routes.RegistrationSpeedFormSubstitutionCredentialsRoute, controllers.SubstitutionCredentialsSpeedForm)
-	r.app.All(routes.RegistrationSpeedFormValidationRoute, controllers.ValidateRegistrationSpeedForm)
+	v1.Get(routes.IsLoggedInRoute, protected, controllers.IsLoggedIn)
+
+	v1.Post(routes.AddAccountRoute, h.AddAccount)
+	v1.Delete(routes.DeleteAccountRoute, protected, h.DeleteAccount)
+	v1.Put(routes.UpdateAccountPasswordRoute, protected, h.UpdateAccountPassword)
+	v1.Post(routes.SendPhoneNumberConfirmationLinkRoute, protected, h.SendPhoneNumberConfirmationLink)
+	v1.Get(routes.AddPhoneNumberRoute, h.AddPhoneNumber)
+
+	v1.Get(routes.GetNotificationSettingsRoute, protected, h.GetNotificationSettings)
+	v1.Put(routes.SetNotificationSettingRoute, protected, h.SetNotificationSetting)
+	v1.Delete(routes.RemoveNotificationSettingRoute, protected, h.RemoveNotificationSetting)
+	v1.Get(routes.GetNotificationPreferenceRoute, protected, h.GetNotificationPreference)
+	v1.Put(routes.SetNotificationPreferenceRoute, protected, h.SetNotificationPreference)
+
+	v1.Post(routes.AddAccountToSubstitutionUpdaterRoute, protected, h.AddAccountToSubstitutionUpdater)
+	v1.Delete(routes.RemoveAccountFromSubstitutionUpdaterRoute, protected, h.RemoveAccountFromSubstitutionUpdater)
+	v1.Get(routes.GetSubstitutionsRoute, protected, h.GetSubstitutions)
+
+	v1.Post(routes.AddAccountToMoodleAssignmentUpdaterRoute, protected, h.AddAccountToMoodleAssignmentUpdater)
+	v1.Delete(routes.RemoveAccountFromMoodleAssignmentUpdaterRoute, protected, h.RemoveAccountFromMoodleAssignmentUpdater)
+	v1.Get(routes.GetMoodleAssignmentsRoute, protected, h.GetMoodleAssignments)
+	v1.Get(routes.GetMoodleCourseSettingsRoute, protected, h.GetMoodleCourseSettings)
+	v1.Put(routes.SetMoodleCourseSettingRoute, protected, h.SetMoodleCourseSetting)
+
+	v1.Post(routes.AddAccountToMoodleForumUpdaterRoute, protected, h.AddAccountToMoodleForumUpdater)
+	v1.Delete(routes.RemoveAccountFromMoodleForumUpdaterRoute, protected, h.RemoveAccountFromMoodleForumUpdater)
+
+	v1.Post(routes.AddAccountToMoodleGradeUpdaterRoute, protected, h.AddAccountToMoodleGradeUpdater)
+	v1.Delete(routes.RemoveAccountFromMoodleGradeUpdaterRoute, protected, h.RemoveAccountFromMoodleGradeUpdater)
+
+	v1.Get(routes.GetCalendarRoute, protected, h.GetCalendar)
+	v1.Get(routes.GetCalendarFeedRoute, h.GetCalendarFeed)
+	v1.Get(routes.GetSubstitutionCalendarFeedRoute, h.GetSubstitutionCalendarFeed)
+	v1.Post(routes.RotateCalendarTokenRoute, protected, h.RotateCalendarToken)
+	v1.Delete(routes.RevokeCalendarTokenRoute, protected, h.RevokeCalendarToken)
+
+	admin := v1.Group(routes.AdminRoute, protected, Admin())
+	admin.Get(routes.GetJobRunsRoute, h.GetJobRuns)
+	admin.Get(routes.GetAccountsRoute, h.GetAccounts)
+	admin.Get(routes.GetAccountUpdaterStateRoute, h.GetAccountUpdaterState)
+	admin.Post(routes.ForceAccountUpdateRoute, h.ForceAccountUpdate)
+	admin.Post(routes.DisableAccountRoute, h.DisableAccount)
+	admin.Post(routes.EnableAccountRoute, h.EnableAccount)
+	admin.Get(routes.GetSendFailuresRoute, h.GetSendFailures)
+
+	r.app.All(routes.RegistrationSpeedFormRoute, h.RegistrationSpeedForm)
+	r.app.All(routes.RegistrationSpeedFormSubstitutionCredentialsRoute, h.SubstitutionCredentialsSpeedForm)
+	r.app.All(routes.RegistrationSpeedFormValidationRoute, h.ValidateRegistrationSpeedForm)
 	r.app.Get(routes.RegistrationSpeedFormFinishRoute, controllers.FinishRegistrationSpeedForm)
 	r.app.Get(routes.RegistrationSpeedFormInfoRoute, controllers.InfoRegsitrationSpeedForm)
 
-	session.Init()
-
 	return nil
 }
 
 // Start the fiber app and listen on the specified port
 func (r *RestProvider) StartListening() error {
-	return r.app.Listen(fmt.Sprintf(":%d", config.LISTENING_PORT))
+	return r.app.Listen(fmt.Sprintf(":%d", r.config.LISTENING_PORT))
 }
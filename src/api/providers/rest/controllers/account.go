@@ -2,14 +2,13 @@ package controllers
 
 import (
 	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
-	"github.com/dattito/purrmannplus-backend/app/commands"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 // Creates a new account and returns the account id
-func AddAccount(c *fiber.Ctx) error {
+func (h *Handlers) AddAccount(c *fiber.Ctx) error {
 	accApi := new(api_models.PostAccountRequest)
 	if err := c.BodyParser(accApi); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
@@ -17,7 +16,7 @@ func AddAccount(c *fiber.Ctx) error {
 		})
 	}
 
-	acc, user_err, db_err := commands.CreateAccount(accApi.Username, accApi.Password)
+	acc, user_err, db_err := h.App.CreateAccount(accApi.Username, accApi.Password)
 
 	if user_err != nil {
 		return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
@@ -36,9 +35,9 @@ func AddAccount(c *fiber.Ctx) error {
 	return c.JSON(api_models.AccountToPostAccountResponse(&acc))
 }
 
-// Retruns the account_id and the credentials of all accounts
-func GetAccounts(c *fiber.Ctx) error {
-	accs, err := commands.GetAllAccounts()
+// Returns the id, username, role and status of all accounts
+func (h *Handlers) GetAccounts(c *fiber.Ctx) error {
+	accs, err := h.App.GetAllAccounts()
 	if err != nil {
 		logging.Errorf("Error while getting accounts: %v", err.Error())
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -50,12 +49,12 @@ func GetAccounts(c *fiber.Ctx) error {
 }
 
 // Delets an account
-func DeleteAccount(c *fiber.Ctx) error {
+func (h *Handlers) DeleteAccount(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
 
-	if err := commands.DeleteAccount(accountId); err != nil {
+	if err := h.App.DeleteAccount(accountId); err != nil {
 		logging.Errorf("Error while deleting account: %v", err.Error())
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
 			"error": err.Error(),
@@ -64,3 +63,34 @@ func DeleteAccount(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// Updates the stored moodle password of an account after checking it against moodle
+func (h *Handlers) UpdateAccountPassword(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	req := new(api_models.PutAccountPasswordRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	user_err, db_err := h.App.UpdateAccountPassword(accountId, req.Password)
+
+	if db_err != nil {
+		logging.Errorf("Error while updating account password: %v", db_err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -5,18 +5,14 @@ import (
 
 	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/routes"
-	"github.com/dattito/purrmannplus-backend/app/commands"
 	"github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/config"
-	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
-	utils_jwt "github.com/dattito/purrmannplus-backend/utils/jwt"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 // Sends a message with a link to the user to confirm his phone number
-func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
+func (h *Handlers) SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 	pr := new(api_models.PostSendPhoneNumberConfirmationLinkRequest)
 	if err := c.BodyParser(pr); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
@@ -28,7 +24,7 @@ func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
 
-	ok, err := commands.ValidAccountId(accountId)
+	ok, err := h.App.ValidAccountId(accountId)
 	if err != nil {
 		logging.Errorf("Error while validating account id: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -49,7 +45,7 @@ func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 		})
 	}
 
-	has_phone_number, err := commands.HasPhoneNumber(accountId)
+	has_phone_number, err := h.App.HasPhoneNumber(accountId)
 	if err != nil {
 		logging.Errorf("Error while checking if account has a phone-number: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -63,7 +59,7 @@ func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 		})
 	}
 
-	token, err := utils_jwt.NewAccountIdPhoneNumberToken(account_info.Account.Id, account_info.PhoneNumber)
+	token, err := h.Tokens.NewAccountIdPhoneNumberToken(account_info.Account.Id, account_info.PhoneNumber)
 	if err != nil {
 		logging.Errorf("Error while creating token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -74,9 +70,9 @@ func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 	text := fmt.Sprintf("Willkommen bei PurrmannPlus. Um deine Telefonnummer zu bestätigen, drücke "+
 		"auf den nachfolgenden Link. Er ist 10 Minuten lang gültig. Du hast den Link nicht angefordert? Dann kannst du ihn ignorieren. "+
 		"%s/v1%s?token=%s",
-		config.API_URL, routes.AddPhoneNumberRoute, token)
+		h.App.Config.API_URL, routes.AddPhoneNumberRoute, token)
 
-	err = signal_message_sender.SignalMessageSender.Send(text, account_info.PhoneNumber)
+	err = h.App.Signal.Send(text, account_info.PhoneNumber)
 
 	if err != nil {
 		logging.Errorf("Error while sending signal message: %v", err)
@@ -89,7 +85,7 @@ func SendPhoneNumberConfirmationLink(c *fiber.Ctx) error {
 }
 
 // Validates the phone number of the user and adds it to the database
-func AddPhoneNumber(c *fiber.Ctx) error {
+func (h *Handlers) AddPhoneNumber(c *fiber.Ctx) error {
 	p := new(api_models.PostAddPhoneNumberRequest)
 	if err := c.QueryParser(p); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
@@ -103,7 +99,7 @@ func AddPhoneNumber(c *fiber.Ctx) error {
 		})
 	}
 
-	accountId, phoneNumber, err := utils_jwt.ParseAccountIdPhoneNumberToken(p.Token)
+	accountId, phoneNumber, err := h.Tokens.ParseAccountIdPhoneNumberToken(p.Token)
 	if err != nil {
 		logging.Errorf("Error while parsing token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -111,7 +107,7 @@ func AddPhoneNumber(c *fiber.Ctx) error {
 		})
 	}
 
-	ok, err := commands.ValidAccountId(accountId)
+	ok, err := h.App.ValidAccountId(accountId)
 	if err != nil {
 		logging.Errorf("Error while validating account id: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -125,7 +121,7 @@ func AddPhoneNumber(c *fiber.Ctx) error {
 		})
 	}
 
-	_, user_err, internal_error := commands.AddAccountInfo(accountId, phoneNumber)
+	_, user_err, internal_error := h.App.AddAccountInfo(accountId, phoneNumber)
 	if internal_error != nil {
 		logging.Errorf("Error while adding account info: %v", internal_error)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Returns the latest runs of the scheduled jobs
+func (h *Handlers) GetJobRuns(c *fiber.Ctx) error {
+	req := new(api_models.GetJobRunsRequest)
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if req.Limit <= 0 || req.Limit > 500 {
+		req.Limit = 50
+	}
+
+	jobRuns, err := h.App.GetJobRuns(req.Job, req.Limit)
+	if err != nil {
+		logging.Errorf("Error while getting job runs: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(api_models.JobRunsToGetJobRunResponses(jobRuns))
+}
+
+// Returns the state of the updaters of an account
+func (h *Handlers) GetAccountUpdaterState(c *fiber.Ctx) error {
+	state, user_err, db_err := h.App.GetUpdaterState(c.Params("id"))
+
+	if db_err != nil {
+		logging.Errorf("Error while getting updater state: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.UpdaterStateToGetUpdaterStateResponse(state))
+}
+
+// Runs the updaters of an account immediately
+func (h *Handlers) ForceAccountUpdate(c *fiber.Ctx) error {
+	user_err, db_err := h.App.ForceAccountUpdate(c.Params("id"))
+
+	if db_err != nil {
+		logging.Errorf("Error while forcing account update: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *Handlers) setAccountDisabled(c *fiber.Ctx, disabled bool) error {
+	user_err, db_err := h.App.SetAccountDisabled(c.Params("id"), disabled)
+
+	if db_err != nil {
+		logging.Errorf("Error while setting account disabled: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Disables an account, it can't log in anymore and isn't updated
+func (h *Handlers) DisableAccount(c *fiber.Ctx) error {
+	return h.setAccountDisabled(c, true)
+}
+
+// Enables a disabled account
+func (h *Handlers) EnableAccount(c *fiber.Ctx) error {
+	return h.setAccountDisabled(c, false)
+}
+
+// Returns the latest notifications which couldn't be sent
+func (h *Handlers) GetSendFailures(c *fiber.Ctx) error {
+	req := new(api_models.GetSendFailuresRequest)
+	if err := c.QueryParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if req.Limit <= 0 || req.Limit > 500 {
+		req.Limit = 50
+	}
+
+	sendFailures, err := h.App.GetSendFailures(req.Limit)
+	if err != nil {
+		logging.Errorf("Error while getting send failures: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(api_models.SendFailuresToGetSendFailureResponses(sendFailures))
+}
@@ -5,15 +5,13 @@ import (
 
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/models"
 	"github.com/dattito/purrmannplus-backend/app/commands"
-	"github.com/dattito/purrmannplus-backend/config"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
-	"github.com/dattito/purrmannplus-backend/utils/jwt"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 )
 
 // AccountLogin logs in the user and returns a JWT token or sets a cookie
-func AccountLogin(c *fiber.Ctx) error {
+func (h *Handlers) AccountLogin(c *fiber.Ctx) error {
 	a := new(models.PostLoginRequest)
 	if err := c.BodyParser(a); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
@@ -21,20 +19,30 @@ func AccountLogin(c *fiber.Ctx) error {
 		})
 	}
 
-	dbAcc, err := commands.GetAccountByCredentials(a.Username, a.Password)
+	dbAcc, err := h.App.Login(a.Username, a.Password, c.IP())
 	if err != nil {
 		if errors.Is(err, &db_errors.ErrRecordNotFound) {
 			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
 				"error": "wrong credentials",
 			})
 		}
-		logging.Errorf("Error while getting account by credentials: %v", err)
+		if errors.Is(err, commands.ErrAccountDisabled) {
+			return c.Status(fiber.StatusForbidden).JSON(&fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, commands.ErrTooManyLoginAttempts) {
+			return c.Status(fiber.StatusTooManyRequests).JSON(&fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		logging.Errorf("Error while logging in: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
 			"error": "Something went wrong",
 		})
 	}
 
-	token, expires, err := jwt.NewAccountIdToken(dbAcc.Id)
+	token, expires, err := h.Tokens.NewAccountIdToken(dbAcc.Id, dbAcc.Role)
 	if err != nil {
 		logging.Errorf("Error while creating token: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -52,12 +60,12 @@ func AccountLogin(c *fiber.Ctx) error {
 		}
 		cookie.HTTPOnly = true
 
-		if config.AUTHORIZATION_COOKIE_DOMAIN != "" {
-			cookie.Domain = config.AUTHORIZATION_COOKIE_DOMAIN
+		if h.App.Config.AUTHORIZATION_COOKIE_DOMAIN != "" {
+			cookie.Domain = h.App.Config.AUTHORIZATION_COOKIE_DOMAIN
 		}
 
-		cookie.Secure = config.AUTHORIZATION_COOKIE_SECURE
-		cookie.SameSite = config.AUTHORIZATION_COOKIE_SAMESITE
+		cookie.Secure = h.App.Config.AUTHORIZATION_COOKIE_SECURE
+		cookie.SameSite = h.App.Config.AUTHORIZATION_COOKIE_SAMESITE
 
 		c.Cookie(cookie)
 
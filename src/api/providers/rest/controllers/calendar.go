@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
+	"github.com/dattito/purrmannplus-backend/api/providers/rest/routes"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Returns the url of the calendar feed of the account, the feed is created on the first call
+func (h *Handlers) GetCalendar(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	token, err := h.App.GetOrCreateCalendarToken(accountId)
+	if err != nil {
+		logging.Errorf("Error while getting calendar token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(h.calendarResponse(token))
+}
+
+// Returns the urls of the calendar feeds with the given token
+func (h *Handlers) calendarResponse(token string) api_models.GetCalendarResponse {
+	return api_models.GetCalendarResponse{
+		Url:              fmt.Sprintf("%s/v1%s", h.App.Config.API_URL, strings.Replace(routes.GetCalendarFeedRoute, ":token", token, 1)),
+		SubstitutionsUrl: fmt.Sprintf("%s/v1%s", h.App.Config.API_URL, strings.Replace(routes.GetSubstitutionCalendarFeedRoute, ":token", token, 1)),
+	}
+}
+
+// Replaces the token of the calendar feeds, the old urls stop working
+func (h *Handlers) RotateCalendarToken(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	token, err := h.App.RotateCalendarToken(accountId)
+	if err != nil {
+		logging.Errorf("Error while rotating calendar token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(h.calendarResponse(token))
+}
+
+// Removes the calendar feeds of the account
+func (h *Handlers) RevokeCalendarToken(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	if err := h.App.RevokeCalendarToken(accountId); err != nil {
+		logging.Errorf("Error while revoking calendar token: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Returns the calendar feed in the iCalendar format, the token in the url authenticates the request
+func (h *Handlers) GetCalendarFeed(c *fiber.Ctx) error {
+	feed, user_err, db_err := h.App.GetCalendarFeed(c.Params("token"))
+
+	if db_err != nil {
+		logging.Errorf("Error while getting calendar feed: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.SendString(feed)
+}
+
+// Returns the calendar feed of the substitutions in the iCalendar format, the token in the url authenticates the request
+func (h *Handlers) GetSubstitutionCalendarFeed(c *fiber.Ctx) error {
+	feed, user_err, db_err := h.App.GetSubstitutionCalendarFeed(c.Params("token"))
+
+	if db_err != nil {
+		logging.Errorf("Error while getting substitution calendar feed: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.SendString(feed)
+}
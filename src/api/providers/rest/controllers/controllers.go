@@ -1,9 +1,28 @@
 package controllers
 
 import (
+	"github.com/dattito/purrmannplus-backend/app/commands"
+	utils_jwt "github.com/dattito/purrmannplus-backend/utils/jwt"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/session"
 )
 
+// Holds everything the controllers depend on
+type Handlers struct {
+	App      *commands.App
+	Sessions *session.Store // Sessions of the registration speed form
+	Tokens   *utils_jwt.Issuer
+}
+
+// Returns the Handlers which answer the requests using the given app
+func New(app *commands.App, sessions *session.Store, tokens *utils_jwt.Issuer) *Handlers {
+	return &Handlers{
+		App:      app,
+		Sessions: sessions,
+		Tokens:   tokens,
+	}
+}
+
 // Sends an empty response to check if the server is up and running
 func GetHealth(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusNoContent)
@@ -1,18 +1,18 @@
 package controllers
 
 import (
-	"github.com/dattito/purrmannplus-backend/app/commands"
+	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-func AddAccountToMoodleAssignmentUpdater(c *fiber.Ctx) error {
+func (h *Handlers) AddAccountToMoodleAssignmentUpdater(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
 
-	ok, err := commands.ValidAccountId(accountId)
+	ok, err := h.App.ValidAccountId(accountId)
 	if err != nil {
 		logging.Errorf("Error validating account id: %s", err.Error())
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -26,7 +26,7 @@ func AddAccountToMoodleAssignmentUpdater(c *fiber.Ctx) error {
 		})
 	}
 
-	user_err, db_err := commands.AddAccountToMoodleAssignmentUpdater(accountId)
+	user_err, db_err := h.App.AddAccountToMoodleAssignmentUpdater(accountId)
 
 	if db_err != nil {
 		logging.Errorf("Error while adding account to moodle assignment updater: %s", db_err.Error())
@@ -44,12 +44,12 @@ func AddAccountToMoodleAssignmentUpdater(c *fiber.Ctx) error {
 	return c.SendStatus(fiber.StatusCreated)
 }
 
-func RemoveAccountFromMoodleAssignmentUpdater(c *fiber.Ctx) error {
+func (h *Handlers) RemoveAccountFromMoodleAssignmentUpdater(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
 
-	err := commands.RemoveAccountFromMoodleAssignmentUpdater(accountId)
+	err := h.App.RemoveAccountFromMoodleAssignmentUpdater(accountId)
 
 	if err != nil {
 		logging.Errorf("Error while removing account from moodle assignment updater: %s", err.Error())
@@ -60,3 +60,86 @@ func RemoveAccountFromMoodleAssignmentUpdater(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// Returns the stored moodle assignments of the account
+func (h *Handlers) GetMoodleAssignments(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	m, user_err, db_err := h.App.GetMoodleAssignments(accountId)
+	if db_err != nil {
+		logging.Errorf("Error while getting moodle assignments: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.MoodleAssignmentsToGetMoodleAssignmentsResponse(m))
+}
+
+// Returns the courses of the account and whether reminders are sent for them
+func (h *Handlers) GetMoodleCourseSettings(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	settings, user_err, db_err := h.App.GetMoodleCourseSettings(accountId)
+	if db_err != nil {
+		logging.Errorf("Error while getting moodle course settings: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.MoodleCourseSettingsToGetMoodleCourseSettingResponses(settings))
+}
+
+// Enables or disables the reminders for a course of the account
+func (h *Handlers) SetMoodleCourseSetting(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	courseId, err := c.ParamsInt("course_id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": "invalid course id",
+		})
+	}
+
+	req := new(api_models.PutMoodleCourseSettingRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	s, user_err, db_err := h.App.SetMoodleCourseSetting(accountId, courseId, req.RemindersEnabled)
+	if db_err != nil {
+		logging.Errorf("Error while setting moodle course setting: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.MoodleCourseSettingToGetMoodleCourseSettingResponse(s))
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Adds an account to the moodle grade updater, which sends new and changed grades
+func (h *Handlers) AddAccountToMoodleGradeUpdater(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	ok, err := h.App.ValidAccountId(accountId)
+	if err != nil {
+		logging.Errorf("Error validating account id: %s", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": "account not found",
+		})
+	}
+
+	user_err, db_err := h.App.AddAccountToMoodleGradeUpdater(accountId)
+
+	if db_err != nil {
+		logging.Errorf("Error while adding account to moodle grade updater: %s", db_err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.SendStatus(fiber.StatusCreated)
+}
+
+// Removes an account from the moodle grade updater
+func (h *Handlers) RemoveAccountFromMoodleGradeUpdater(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	if err := h.App.RemoveAccountFromMoodleGradeUpdater(accountId); err != nil {
+		logging.Errorf("Error while removing account from moodle grade updater: %s", err.Error())
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
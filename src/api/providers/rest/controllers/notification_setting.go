@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	api_models "github.com/dattito/purrmannplus-backend/api/providers/rest/models"
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Returns the notification channels of the account
+func (h *Handlers) GetNotificationSettings(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	ns, err := h.App.GetNotificationSettings(accountId)
+	if err != nil {
+		logging.Errorf("Error while getting notification settings: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(api_models.NotificationSettingsToGetNotificationSettingResponses(ns))
+}
+
+// Adds or updates a notification channel of the account
+func (h *Handlers) SetNotificationSetting(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	req := new(api_models.PutNotificationSettingRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	n, user_err, db_err := h.App.SetNotificationSetting(accountId, req.Channel, req.Recipient)
+
+	if db_err != nil {
+		logging.Errorf("Error while setting notification setting: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.NotificationSettingToGetNotificationSettingResponse(n))
+}
+
+// Removes a notification channel of the account
+func (h *Handlers) RemoveNotificationSetting(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	if err := h.App.RemoveNotificationSetting(accountId, c.Params("channel")); err != nil {
+		logging.Errorf("Error while removing notification setting: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Returns whether the notifications of the account are paused
+func (h *Handlers) GetNotificationPreference(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	p, err := h.App.GetNotificationPreference(accountId)
+	if err != nil {
+		logging.Errorf("Error while getting notification preference: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	return c.JSON(api_models.NotificationPreferenceToGetNotificationPreferenceResponse(p))
+}
+
+// Sets when the account receives notifications: pause, opt-out, quiet hours and digest mode
+func (h *Handlers) SetNotificationPreference(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	req := new(api_models.PutNotificationPreferenceRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	preference := app_models.NotificationPreference{
+		AccountId:       accountId,
+		Paused:          req.Paused,
+		OptedOut:        req.OptedOut,
+		QuietHoursStart: req.QuietHoursStart,
+		QuietHoursEnd:   req.QuietHoursEnd,
+		Digest:          req.Digest,
+	}
+	if req.PausedUntil != nil {
+		preference.PausedUntil = *req.PausedUntil
+	}
+
+	p, user_err, db_err := h.App.SetNotificationPreference(preference)
+
+	if db_err != nil {
+		logging.Errorf("Error while setting notification preference: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(api_models.NotificationPreferenceToGetNotificationPreferenceResponse(p))
+}
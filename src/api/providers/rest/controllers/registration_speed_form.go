@@ -7,20 +7,15 @@ import (
 
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/models"
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/routes"
-	"github.com/dattito/purrmannplus-backend/api/providers/rest/session"
-	"github.com/dattito/purrmannplus-backend/app/commands"
-	"github.com/dattito/purrmannplus-backend/config"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
-	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
-	"github.com/dattito/purrmannplus-backend/services/substitutions"
 	"github.com/dattito/purrmannplus-backend/utils"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/nyaruka/phonenumbers"
 )
 
-func SaveCustomSubstitutionCredentials(c *fiber.Ctx, customSubstitutionAuthId, customSubstitutionAuthPw string) error {
-	session, err := session.SessionStore.Get(c)
+func (h *Handlers) SaveCustomSubstitutionCredentials(c *fiber.Ctx, customSubstitutionAuthId, customSubstitutionAuthPw string) error {
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		return err
 	}
@@ -31,8 +26,8 @@ func SaveCustomSubstitutionCredentials(c *fiber.Ctx, customSubstitutionAuthId, c
 	return session.Save()
 }
 
-func SaveNeedsCustomSubstitutionCredentials(c *fiber.Ctx) error {
-	session, err := session.SessionStore.Get(c)
+func (h *Handlers) SaveNeedsCustomSubstitutionCredentials(c *fiber.Ctx) error {
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		return err
 	}
@@ -42,8 +37,8 @@ func SaveNeedsCustomSubstitutionCredentials(c *fiber.Ctx) error {
 	return session.Save()
 }
 
-func SaveRequestInSession(c *fiber.Ctx, username, password, phoneNumber, code string) error {
-	session, err := session.SessionStore.Get(c)
+func (h *Handlers) SaveRequestInSession(c *fiber.Ctx, username, password, phoneNumber, code string) error {
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		return err
 	}
@@ -56,8 +51,8 @@ func SaveRequestInSession(c *fiber.Ctx, username, password, phoneNumber, code st
 	return session.Save()
 }
 
-func sendConfirmationCode(c *fiber.Ctx) error {
-	session, err := session.SessionStore.Get(c)
+func (h *Handlers) sendConfirmationCode(c *fiber.Ctx) error {
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		return err
 	}
@@ -69,27 +64,27 @@ func sendConfirmationCode(c *fiber.Ctx) error {
 		return errors.New("phone number or code not found in session")
 	}
 
-	return signal_message_sender.SignalMessageSender.Send(
+	return h.App.Signal.Send(
 		fmt.Sprintf("Willkommen bei PurrmannPlus! Dein Bestätigungscode lautet: %s", code),
 		phoneNumber.(string),
 	)
 }
 
-func RegistrationSpeedForm(c *fiber.Ctx) error {
+func (h *Handlers) RegistrationSpeedForm(c *fiber.Ctx) error {
 	if c.Method() == fiber.MethodGet {
 		return c.Render("registration_speed_form", fiber.Map{
 			"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 			"FormPostRoute":    routes.RegistrationSpeedFormRoute,
-			"ContactEmail":     config.CONTACT_EMAIL,
-			"ContactInstagram": config.CONTACT_INSTAGRAM,
+			"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+			"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 		}, "layouts/main")
 	} else if c.Method() == fiber.MethodPost {
 		internalServerErrorResponse := c.Status(fiber.StatusInternalServerError).Render("registration_speed_form", fiber.Map{
 			"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 			"FormPostRoute":    routes.RegistrationSpeedFormRoute,
 			"ErrorMessage":     "Etwas ist schiefgelaufen...",
-			"ContactEmail":     config.CONTACT_EMAIL,
-			"ContactInstagram": config.CONTACT_INSTAGRAM,
+			"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+			"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 		}, "layouts/main")
 
 		var pr models.PostRegistrationSpeedFormRequest
@@ -105,12 +100,12 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 				"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 				"FormPostRoute":    routes.RegistrationSpeedFormRoute,
 				"ErrorMessage":     "Bitte benutzen hier die Anmeldedaten von MOODLE. Die Anmeldedaten für den VERTRETUNGSPLAN kannst du ggf. im nächsten Schritt eingeben, sofern diese unterschiedlich sind.",
-				"ContactEmail":     config.CONTACT_EMAIL,
-				"ContactInstagram": config.CONTACT_INSTAGRAM,
+				"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+				"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 			}, "layouts/main")
 		}
 
-		correct, err := commands.CheckCredentials(pr.Username, pr.Password)
+		correct, err := h.App.CheckCredentials(pr.Username, pr.Password)
 		if err != nil {
 			logging.Errorf("Error checking credentials: %v", err)
 			return internalServerErrorResponse
@@ -121,15 +116,15 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 				"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 				"FormPostRoute":    routes.RegistrationSpeedFormRoute,
 				"ErrorMessage":     "Falsche Anmeldedaten",
-				"ContactEmail":     config.CONTACT_EMAIL,
-				"ContactInstagram": config.CONTACT_INSTAGRAM,
+				"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+				"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 			}, "layouts/main")
 		}
 
 		// Check if accounts already exist
-		if _, err := commands.GetAccountByCredentials(pr.Username, pr.Password); err != nil {
+		if _, err := h.App.GetAccountByUsername(pr.Username); err != nil {
 			if !errors.Is(err, &db_errors.ErrRecordNotFound) {
-				logging.Errorf("Error getting account by credentials: %v", err)
+				logging.Errorf("Error getting account by username: %v", err)
 				return internalServerErrorResponse
 			}
 		} else {
@@ -137,8 +132,8 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 				"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 				"FormPostRoute":    routes.RegistrationSpeedFormRoute,
 				"ErrorMessage":     "Das Konto exestiert bereits",
-				"ContactEmail":     config.CONTACT_EMAIL,
-				"ContactInstagram": config.CONTACT_INSTAGRAM,
+				"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+				"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 			}, "layouts/main")
 		}
 
@@ -149,8 +144,8 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 					"InfoRoute":        routes.RegistrationSpeedFormInfoRoute,
 					"FormPostRoute":    routes.RegistrationSpeedFormRoute,
 					"ErrorMessage":     "Bitte gebe eine gültige Telefonnummer an",
-					"ContactEmail":     config.CONTACT_EMAIL,
-					"ContactInstagram": config.CONTACT_INSTAGRAM,
+					"ContactEmail":     h.App.Config.CONTACT_EMAIL,
+					"ContactInstagram": h.App.Config.CONTACT_INSTAGRAM,
 				}, "layouts/main")
 			}
 			logging.Errorf("Error formatting number: %v", err)
@@ -159,20 +154,20 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 
 		code := utils.GenerateValidationCode(6)
 
-		err = SaveRequestInSession(c, pr.Username, pr.Password, validNumber, code)
+		err = h.SaveRequestInSession(c, pr.Username, pr.Password, validNumber, code)
 		if err != nil {
 			logging.Errorf("Error saving request in session: %v", err)
 			return internalServerErrorResponse
 		}
 
-		ok, err := substitutions.CheckCredentials(pr.Username, pr.Password)
+		ok, err := h.App.CheckSubstitutionCredentials(pr.Username, pr.Password)
 		if err != nil {
 			logging.Errorf("Error checking credentials: %v", err)
 			return internalServerErrorResponse
 		}
 
 		if !ok {
-			if err := SaveNeedsCustomSubstitutionCredentials(c); err != nil {
+			if err := h.SaveNeedsCustomSubstitutionCredentials(c); err != nil {
 				logging.Errorf("Error saving needs custom substitution credentials: %v", err)
 				return internalServerErrorResponse
 			}
@@ -180,9 +175,9 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 			return c.Redirect(routes.RegistrationSpeedFormSubstitutionCredentialsRoute)
 		}
 
-		if err := sendConfirmationCode(c); err != nil {
+		if err := h.sendConfirmationCode(c); err != nil {
 			logging.Errorf("Error sending confirmation code: %v", err)
-			session, err := session.SessionStore.Get(c)
+			session, err := h.Sessions.Get(c)
 			if err != nil {
 				return internalServerErrorResponse
 			}
@@ -196,13 +191,13 @@ func RegistrationSpeedForm(c *fiber.Ctx) error {
 	}
 }
 
-func SubstitutionCredentialsSpeedForm(c *fiber.Ctx) error {
+func (h *Handlers) SubstitutionCredentialsSpeedForm(c *fiber.Ctx) error {
 	internalServerErrorResponse := c.Status(fiber.StatusInternalServerError).Render("registration_speed_form_substitution_credentials", fiber.Map{
 		"FormPostRoute": routes.RegistrationSpeedFormSubstitutionCredentialsRoute,
 		"ErrorMessage":  "Etwas ist schiefgelaufen...",
 	}, "layouts/main")
 
-	session, err := session.SessionStore.Get(c)
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		session.Destroy()
 		logging.Errorf("Error getting session: %v", err)
@@ -226,7 +221,7 @@ func SubstitutionCredentialsSpeedForm(c *fiber.Ctx) error {
 		}
 
 		pr.AuthId = strings.ToLower(pr.AuthId)
-		ok, err := substitutions.CheckCredentials(pr.AuthId, pr.AuthPw)
+		ok, err := h.App.CheckSubstitutionCredentials(pr.AuthId, pr.AuthPw)
 		if err != nil {
 			session.Destroy()
 			logging.Errorf("Error checking substitution credentials: %v", err)
@@ -238,13 +233,13 @@ func SubstitutionCredentialsSpeedForm(c *fiber.Ctx) error {
 				"ErrorMessage":  "Falsche Anmeldedaten",
 			}, "layouts/main")
 		}
-		if err := SaveCustomSubstitutionCredentials(c, pr.AuthId, pr.AuthPw); err != nil {
+		if err := h.SaveCustomSubstitutionCredentials(c, pr.AuthId, pr.AuthPw); err != nil {
 			logging.Errorf("Error saving custom substitution credentials: %v", err)
 			session.Destroy()
 			return internalServerErrorResponse
 		}
 
-		if err := sendConfirmationCode(c); err != nil {
+		if err := h.sendConfirmationCode(c); err != nil {
 			logging.Errorf("Error sending confirmation code: %v", err)
 			session.Destroy()
 			return internalServerErrorResponse
@@ -255,13 +250,13 @@ func SubstitutionCredentialsSpeedForm(c *fiber.Ctx) error {
 	return fiber.ErrMethodNotAllowed
 }
 
-func ValidateRegistrationSpeedForm(c *fiber.Ctx) error {
+func (h *Handlers) ValidateRegistrationSpeedForm(c *fiber.Ctx) error {
 	internalServerErrorResponse := c.Status(fiber.StatusInternalServerError).Render("registration_speed_form_pn_validate", fiber.Map{
 		"FormPostRoute": routes.RegistrationSpeedFormValidationRoute,
 		"ErrorMessage":  "Etwas ist schiefgelaufen...",
 	}, "layouts/main")
 
-	session, err := session.SessionStore.Get(c)
+	session, err := h.Sessions.Get(c)
 	if err != nil {
 		return internalServerErrorResponse
 	}
@@ -305,7 +300,7 @@ func ValidateRegistrationSpeedForm(c *fiber.Ctx) error {
 			}, "layouts/main")
 		}
 
-		acc, userErr, internalErr := commands.CreateAccount(session.Get("username").(string), session.Get("password").(string))
+		acc, userErr, internalErr := h.App.CreateAccount(session.Get("username").(string), session.Get("password").(string))
 		if internalErr != nil {
 			session.Destroy()
 			return internalServerErrorResponse
@@ -315,7 +310,7 @@ func ValidateRegistrationSpeedForm(c *fiber.Ctx) error {
 			return internalServerErrorResponse
 		}
 
-		_, userErr, internalErr = commands.AddAccountInfo(acc.Id, session.Get("phone_number").(string))
+		_, userErr, internalErr = h.App.AddAccountInfo(acc.Id, session.Get("phone_number").(string))
 		if internalErr != nil {
 			session.Destroy()
 			return internalServerErrorResponse
@@ -324,26 +319,26 @@ func ValidateRegistrationSpeedForm(c *fiber.Ctx) error {
 			return internalServerErrorResponse
 		}
 
-		if _, err := commands.AddAccountToMoodleAssignmentUpdater(acc.Id); err != nil {
+		if _, err := h.App.AddAccountToMoodleAssignmentUpdater(acc.Id); err != nil {
 			session.Destroy()
 			return internalServerErrorResponse
 		}
 
 		if needsCustomSubstitutionCredentials != nil && needsCustomSubstitutionCredentials == true {
-			if _, err := commands.AddAccountToSubstitutionUpdaterWithCustomCredentials(acc.Id, session.Get("custom_substitution_auth_id").(string), session.Get("custom_substitution_auth_pw").(string)); err != nil {
+			if _, err := h.App.AddAccountToSubstitutionUpdaterWithCustomCredentials(acc.Id, session.Get("custom_substitution_auth_id").(string), session.Get("custom_substitution_auth_pw").(string)); err != nil {
 				session.Destroy()
 				logging.Errorf("Error adding account to substitution updater with custom credentials: %v", err)
 				return internalServerErrorResponse
 			}
 		} else {
-			if _, err := commands.AddAccountToSubstitutionUpdater(acc.Id); err != nil {
+			if _, err := h.App.AddAccountToSubstitutionUpdater(acc.Id); err != nil {
 				session.Destroy()
 				logging.Errorf("Error adding account to substitution updater: %v", err)
 				return internalServerErrorResponse
 			}
 		}
 
-		if err := signal_message_sender.SignalMessageSender.Send(
+		if err := h.App.Signal.Send(
 			fmt.Sprintf("Dein Account '%s' wurde mit dieser Telefonnummer verbunden. Ab jetzt erhälst du über diesen Chat neue Infos über Vertretungen und Moodle-Aufgaben!",
 				acc.Username),
 			session.Get("phone_number").(string),
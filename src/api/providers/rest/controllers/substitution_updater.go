@@ -2,18 +2,17 @@ package controllers
 
 import (
 	"github.com/dattito/purrmannplus-backend/api/providers/rest/models"
-	"github.com/dattito/purrmannplus-backend/app/commands"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 )
 
 // Adds an account to the substitution updater
-func AddAccountToSubstitutionUpdater(c *fiber.Ctx) error {
+func (h *Handlers) AddAccountToSubstitutionUpdater(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
-	ok, err := commands.ValidAccountId(accountId)
+	ok, err := h.App.ValidAccountId(accountId)
 	if err != nil {
 		logging.Errorf("Error validating account id: %s", err.Error())
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -30,7 +29,7 @@ func AddAccountToSubstitutionUpdater(c *fiber.Ctx) error {
 	c.BodyParser(&m)
 
 	if m.Username != "" && m.Password != "" {
-		user_err, db_err := commands.AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, m.Username, m.Password)
+		user_err, db_err := h.App.AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, m.Username, m.Password)
 		if user_err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(&fiber.Map{
 				"error": user_err.Error(),
@@ -43,7 +42,7 @@ func AddAccountToSubstitutionUpdater(c *fiber.Ctx) error {
 			})
 		}
 	} else {
-		user_err, db_err := commands.AddAccountToSubstitutionUpdater(accountId)
+		user_err, db_err := h.App.AddAccountToSubstitutionUpdater(accountId)
 		if db_err != nil {
 			logging.Errorf("Error while adding account to substitution updater: %v", db_err)
 			return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -60,12 +59,12 @@ func AddAccountToSubstitutionUpdater(c *fiber.Ctx) error {
 }
 
 // Removes an account from the substitution updater
-func RemoveAccountFromSubstitutionUpdater(c *fiber.Ctx) error {
+func (h *Handlers) RemoveAccountFromSubstitutionUpdater(c *fiber.Ctx) error {
 	user := c.Locals("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 	accountId := claims["account_id"].(string)
 
-	err := commands.RemoveAccountFromSubstitutionUpdater(accountId)
+	err := h.App.RemoveAccountFromSubstitutionUpdater(accountId)
 	if err != nil {
 		logging.Errorf("Error while removing account from substitution updater: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
@@ -75,3 +74,26 @@ func RemoveAccountFromSubstitutionUpdater(c *fiber.Ctx) error {
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
+
+// Returns the stored substitutions of the account
+func (h *Handlers) GetSubstitutions(c *fiber.Ctx) error {
+	user := c.Locals("user").(*jwt.Token)
+	claims := user.Claims.(jwt.MapClaims)
+	accountId := claims["account_id"].(string)
+
+	s, user_err, db_err := h.App.GetSubstitutions(accountId)
+	if db_err != nil {
+		logging.Errorf("Error while getting substitutions: %v", db_err)
+		return c.Status(fiber.StatusInternalServerError).JSON(&fiber.Map{
+			"error": "Something went wrong",
+		})
+	}
+
+	if user_err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(&fiber.Map{
+			"error": user_err.Error(),
+		})
+	}
+
+	return c.JSON(models.SubstitutionsToGetSubstitutionsResponse(s))
+}
@@ -0,0 +1,388 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/commands"
+	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/database/providers/memory"
+	"github.com/dattito/purrmannplus-backend/services/moodle/moodletest"
+	"github.com/dattito/purrmannplus-backend/services/notifier"
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender/signaltest"
+	"github.com/dattito/purrmannplus-backend/services/substitutions/substitutionstest"
+	"github.com/dattito/purrmannplus-backend/utils"
+)
+
+const (
+	testUsername    = "max.mustermann"
+	testPassword    = "geheim123"
+	testPhoneNumber = "+4915112345678"
+	testSenderPhone = "+4915100000000"
+)
+
+// The backend wired to fake pmwiki, moodle and signal servers
+type testEnv struct {
+	pmwiki *substitutionstest.Server
+	moodle *moodletest.Server
+	signal *signaltest.Server
+	app    *commands.App
+	rest   *RestProvider
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	pmwiki := substitutionstest.NewServer()
+	t.Cleanup(pmwiki.Close)
+
+	moodle := moodletest.NewServer()
+	t.Cleanup(moodle.Close)
+
+	signal, err := signaltest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(signal.Close)
+
+	cfg := &config.Config{
+		API_URL:                       "http://purrmannplus.test",
+		PATH_TO_API_VIEWS:             "views",
+		PATH_TO_API_STATIC:            "static",
+		JWT_SECRET:                    "secret",
+		JWT_SHORTLIVING_SECRET:        "shortliving-secret",
+		AUTHORIZATION_EXPIRATION_TIME: 3600,
+		MOODLE_URL:                    moodle.URL,
+		SUBSTITUTION_URL:              pmwiki.URL,
+		UPDATER_CONCURRENCY:           2,
+		UPDATER_JOB_TIMEOUT:           10,
+		SIGNAL_RECEIVE_TIMEOUT:        1,
+	}
+
+	sender, err := signal_message_sender.New(testSenderPhone, signal.Addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	app, err := commands.New(cfg, memory.NewMemoryProvider(), sender, notifier.New(cfg, sender, http.DefaultClient), nil, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rest := NewRestProvider(app)
+	if err := rest.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	return &testEnv{pmwiki: pmwiki, moodle: moodle, signal: signal, app: app, rest: rest}
+}
+
+// Sends a request to the api and decodes the json response into v, if v isn't nil
+func (e *testEnv) do(t *testing.T, method, path, token string, body, v interface{}) int {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := e.rest.app.Test(req, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+
+	raw, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.StatusCode >= 400 {
+		t.Logf("%s %s: %d %s", method, path, res.StatusCode, raw)
+	}
+
+	if v != nil && len(raw) > 0 {
+		if err := json.Unmarshal(raw, v); err != nil {
+			t.Fatalf("%s %s: invalid json %q: %s", method, path, raw, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+// Returns the signal messages sent to the phone number, regardless of its format
+func (e *testEnv) sentTo(phoneNumber string) []string {
+	want, _ := utils.FormatPhoneNumber(phoneNumber)
+
+	var texts []string
+	for _, m := range e.signal.Sent() {
+		for _, recipient := range m.Recipients {
+			if got, _ := utils.FormatPhoneNumber(recipient); got == want {
+				texts = append(texts, m.Text)
+			}
+		}
+	}
+	return texts
+}
+
+// Returns the header of the next school day after today, e.g. "Mo 13.12."
+func nextSchoolDayHeader(now time.Time) string {
+	weekdays := [7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"}
+
+	day := now.AddDate(0, 0, 1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return fmt.Sprintf("%s %s", weekdays[day.Weekday()], day.Format("02.01."))
+}
+
+var confirmationTokenRegex = regexp.MustCompile(`token=(\S+)`)
+
+func TestRegistrationToSubstitutionNotification(t *testing.T) {
+	e := newTestEnv(t)
+
+	e.moodle.AddUser(testUsername, testPassword)
+	e.pmwiki.AddStudent(testUsername, testPassword)
+
+	day := nextSchoolDayHeader(time.Now())
+	e.pmwiki.SetSubstitutions(testUsername, substitutionstest.Day{
+		Header: day,
+		Rows:   [][]string{{"1", "10a", "Mathe", "MÜL", "SCH", "104", ""}},
+	})
+
+	// Registration
+	if status := e.do(t, http.MethodPost, "/v1/accounts", "", map[string]string{"username": testUsername, "password": "falsch"}, nil); status != http.StatusUnauthorized {
+		t.Fatalf("creating an account with wrong moodle credentials returned %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	var account struct{ Id string }
+	if status := e.do(t, http.MethodPost, "/v1/accounts", "", map[string]string{"username": testUsername, "password": testPassword}, &account); status != http.StatusOK || account.Id == "" {
+		t.Fatalf("creating the account returned %d, id %q", status, account.Id)
+	}
+
+	var login struct{ Token string }
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": testPassword}, &login); status != http.StatusCreated || login.Token == "" {
+		t.Fatalf("login returned %d, token %q", status, login.Token)
+	}
+
+	if status := e.do(t, http.MethodPost, "/v1/accounts/phone_number", login.Token, map[string]string{"phone_number": testPhoneNumber}, nil); status != http.StatusCreated {
+		t.Fatalf("requesting the confirmation link returned %d", status)
+	}
+
+	messages := e.sentTo(testPhoneNumber)
+	if len(messages) != 1 {
+		t.Fatalf("got %d signal messages after requesting the confirmation link, want 1", len(messages))
+	}
+
+	match := confirmationTokenRegex.FindStringSubmatch(messages[0])
+	if match == nil {
+		t.Fatalf("confirmation message %q doesn't contain a token", messages[0])
+	}
+
+	if status := e.do(t, http.MethodGet, "/v1/accounts/phone_number/validate?token="+match[1], "", nil, nil); status != http.StatusCreated {
+		t.Fatalf("confirming the phone number returned %d", status)
+	}
+
+	// First scrape, which only stores the current plan
+	if status := e.do(t, http.MethodPost, "/v1/substitution_updater", login.Token, nil, nil); status != http.StatusCreated {
+		t.Fatalf("adding the account to the substitution updater returned %d", status)
+	}
+
+	var plan struct {
+		Entries []struct{ Subject, Room string }
+	}
+	if status := e.do(t, http.MethodGet, "/v1/substitution_updater", login.Token, nil, &plan); status != http.StatusOK {
+		t.Fatalf("getting the substitutions returned %d", status)
+	}
+
+	if len(plan.Entries) != 1 || plan.Entries[0].Subject != "Mathe" || plan.Entries[0].Room != "104" {
+		t.Fatalf("stored substitutions after the first scrape = %+v, want the substitution of the pmwiki", plan.Entries)
+	}
+
+	if len(e.sentTo(testPhoneNumber)) != 1 {
+		t.Fatalf("the first scrape sent a notification: %v", e.sentTo(testPhoneNumber))
+	}
+
+	// Nothing changed, so nothing is sent
+	if summary, err := e.app.UpdateAllSubstitutions(); err != nil || summary.MessagesSent != 0 {
+		t.Fatalf("UpdateAllSubstitutions() without changes = %+v, %v", summary, err)
+	}
+
+	// Change of the plan
+	e.pmwiki.SetSubstitutions(testUsername, substitutionstest.Day{
+		Header: day,
+		Rows: [][]string{
+			{"1", "10a", "Mathe", "MÜL", "SCH", "201", ""},
+			{"3", "10a", "Englisch", "BAU", "", "", "entfällt"},
+		},
+	})
+
+	summary, err := e.app.UpdateAllSubstitutions()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.MessagesSent != 1 || summary.Failed != 0 {
+		t.Fatalf("UpdateAllSubstitutions() after a change = %+v, want one sent message", summary)
+	}
+
+	messages = e.sentTo(testPhoneNumber)
+	if len(messages) != 2 {
+		t.Fatalf("got %d signal messages, want the confirmation and the change", len(messages))
+	}
+
+	for _, want := range []string{day, "Raum 201", "Englisch", "entfällt"} {
+		if !strings.Contains(messages[1], want) {
+			t.Errorf("change message %q doesn't contain %q", messages[1], want)
+		}
+	}
+
+	// The signal bot answers with the current plan
+	if err := e.signal.AddIncomingMessage(testPhoneNumber, "plan"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := e.app.ReceiveSignalCommands(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	messages = e.sentTo(testPhoneNumber)
+	if len(messages) != 3 || !strings.Contains(messages[2], "Englisch") {
+		t.Errorf("answer to the plan command = %q, want the current plan", messages[len(messages)-1])
+	}
+}
+
+// Creates an account through the api and logs in, returns the id and the token of the account
+func (e *testEnv) login(t *testing.T) (string, string) {
+	t.Helper()
+
+	e.moodle.AddUser(testUsername, testPassword)
+
+	var account struct{ Id string }
+	if status := e.do(t, http.MethodPost, "/v1/accounts", "", map[string]string{"username": testUsername, "password": testPassword}, &account); status != http.StatusOK {
+		t.Fatalf("creating the account returned %d", status)
+	}
+
+	var login struct{ Token string }
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": testPassword}, &login); status != http.StatusCreated {
+		t.Fatalf("login returned %d", status)
+	}
+
+	return account.Id, login.Token
+}
+
+func TestDisabledAccountLosesAccess(t *testing.T) {
+	e := newTestEnv(t)
+	accountId, token := e.login(t)
+
+	if status := e.do(t, http.MethodGet, "/v1/login_check", token, nil, nil); status != http.StatusOK {
+		t.Fatalf("login check before disabling the account returned %d", status)
+	}
+
+	if user_err, db_err := e.app.SetAccountDisabled(accountId, true); user_err != nil || db_err != nil {
+		t.Fatal(user_err, db_err)
+	}
+
+	if status := e.do(t, http.MethodGet, "/v1/login_check", token, nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("login check with the token of a disabled account returned %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+func TestRemovedAdminLosesAccess(t *testing.T) {
+	e := newTestEnv(t)
+	e.app.Config.ADMIN_USERNAMES = testUsername
+	_, token := e.login(t)
+
+	if status := e.do(t, http.MethodGet, "/v1/admin/job_runs", token, nil, nil); status != http.StatusOK {
+		t.Fatalf("admin api returned %d for an admin", status)
+	}
+
+	e.app.Config.ADMIN_USERNAMES = ""
+
+	if status := e.do(t, http.MethodGet, "/v1/admin/job_runs", token, nil, nil); status != http.StatusForbidden {
+		t.Errorf("admin api returned %d after the username was removed from ADMIN_USERNAMES, want %d", status, http.StatusForbidden)
+	}
+}
+
+func TestLoginWithChangedMoodlePassword(t *testing.T) {
+	e := newTestEnv(t)
+	accountId, _ := e.login(t)
+
+	e.moodle.AddUser(testUsername, "neuesPasswort")
+
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": "neuesPasswort"}, nil); status != http.StatusCreated {
+		t.Fatalf("login with the changed moodle password returned %d", status)
+	}
+
+	a, err := e.app.GetAccount(accountId)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if a.Password != "neuesPasswort" {
+		t.Errorf("stored moodle password = %q, want the changed one", a.Password)
+	}
+}
+
+func TestUpdatedPasswordReplacesTheLoginPassword(t *testing.T) {
+	e := newTestEnv(t)
+	_, token := e.login(t)
+
+	e.moodle.AddUser(testUsername, "neuesPasswort")
+
+	if status := e.do(t, http.MethodPut, "/v1/accounts/password", token, map[string]string{"password": "neuesPasswort"}, nil); status != http.StatusNoContent {
+		t.Fatalf("updating the password returned %d", status)
+	}
+
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": testPassword}, nil); status != http.StatusUnauthorized {
+		t.Errorf("login with the old password returned %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": "neuesPasswort"}, nil); status != http.StatusCreated {
+		t.Errorf("login with the new password returned %d, want %d", status, http.StatusCreated)
+	}
+}
+
+func TestLoginLimitsMoodleChecks(t *testing.T) {
+	e := newTestEnv(t)
+	e.login(t)
+
+	wrong := map[string]string{"username": testUsername, "password": "falsch"}
+	for i := 0; i < 5; i++ {
+		if status := e.do(t, http.MethodPost, "/v1/login", "", wrong, nil); status != http.StatusUnauthorized {
+			t.Fatalf("login %d with a wrong password returned %d, want %d", i+1, status, http.StatusUnauthorized)
+		}
+	}
+
+	if status := e.do(t, http.MethodPost, "/v1/login", "", wrong, nil); status != http.StatusTooManyRequests {
+		t.Errorf("login after too many wrong passwords returned %d, want %d", status, http.StatusTooManyRequests)
+	}
+
+	// The stored hash is still checked without moodle
+	if status := e.do(t, http.MethodPost, "/v1/login", "", map[string]string{"username": testUsername, "password": testPassword}, nil); status != http.StatusCreated {
+		t.Errorf("login with the right password after too many wrong ones returned %d, want %d", status, http.StatusCreated)
+	}
+}
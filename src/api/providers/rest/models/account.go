@@ -21,22 +21,28 @@ func AccountToPostAccountResponse(account *app_models.Account) *PostAccountRespo
 	}
 }
 
+type PutAccountPasswordRequest struct {
+	Password string `json:"password" form:"password"`
+}
+
 type GetAccountResponse struct {
 	Id       string `json:"id"`
-	Username string `json:"username" form:"username"`
-	Password string `json:"password" form:"password"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
 }
 
 func AccountToGetAccountResponse(account *app_models.Account) *GetAccountResponse {
 	return &GetAccountResponse{
 		Id:       account.Id,
 		Username: account.Username,
-		Password: account.Password,
+		Role:     account.Role,
+		Disabled: account.Disabled,
 	}
 }
 
 func AccountsToGetAccountResponses(accounts []app_models.Account) []*GetAccountResponse {
-	var getAccountResponses []*GetAccountResponse
+	getAccountResponses := []*GetAccountResponse{}
 	for _, account := range accounts {
 		getAccountResponses = append(getAccountResponses, AccountToGetAccountResponse(&account))
 	}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type GetJobRunsRequest struct {
+	Job   string `query:"job"`
+	Limit int    `query:"limit"`
+}
+
+type GetJobRunResponse struct {
+	Id                string    `json:"id"`
+	Job               string    `json:"job"`
+	StartedAt         time.Time `json:"started_at"`
+	FinishedAt        time.Time `json:"finished_at"`
+	AccountsProcessed int       `json:"accounts_processed"`
+	Errors            int       `json:"errors"`
+	MessagesSent      int       `json:"messages_sent"`
+	Error             string    `json:"error,omitempty"`
+}
+
+func JobRunsToGetJobRunResponses(jobRuns []app_models.JobRun) []GetJobRunResponse {
+	responses := []GetJobRunResponse{}
+	for _, j := range jobRuns {
+		responses = append(responses, GetJobRunResponse{
+			Id:                j.Id,
+			Job:               j.Job,
+			StartedAt:         j.StartedAt,
+			FinishedAt:        j.FinishedAt,
+			AccountsProcessed: j.AccountsProcessed,
+			Errors:            j.Errors,
+			MessagesSent:      j.MessagesSent,
+			Error:             j.Error,
+		})
+	}
+	return responses
+}
+
+type GetUpdaterStateResponse struct {
+	AccountId                  string    `json:"account_id"`
+	Disabled                   bool      `json:"disabled"`
+	SubstitutionUpdater        bool      `json:"substitution_updater"`
+	SubstitutionCount          int       `json:"substitution_count"`
+	SubstitutionsUpdatedAt     time.Time `json:"substitutions_updated_at"`
+	MoodleAssignmentUpdater    bool      `json:"moodle_assignment_updater"`
+	MoodleAssignmentCount      int       `json:"moodle_assignment_count"`
+	MoodleAssignmentsUpdatedAt time.Time `json:"moodle_assignments_updated_at"`
+	MoodleForumUpdater         bool      `json:"moodle_forum_updater"`
+	MoodleGradeUpdater         bool      `json:"moodle_grade_updater"`
+	NotificationChannels       []string  `json:"notification_channels"`
+}
+
+func UpdaterStateToGetUpdaterStateResponse(s app_models.UpdaterState) GetUpdaterStateResponse {
+	return GetUpdaterStateResponse{
+		AccountId:                  s.AccountId,
+		Disabled:                   s.Disabled,
+		SubstitutionUpdater:        s.SubstitutionUpdater,
+		SubstitutionCount:          s.SubstitutionCount,
+		SubstitutionsUpdatedAt:     s.SubstitutionsUpdatedAt,
+		MoodleAssignmentUpdater:    s.MoodleAssignmentUpdater,
+		MoodleAssignmentCount:      s.MoodleAssignmentCount,
+		MoodleAssignmentsUpdatedAt: s.MoodleAssignmentsUpdatedAt,
+		MoodleForumUpdater:         s.MoodleForumUpdater,
+		MoodleGradeUpdater:         s.MoodleGradeUpdater,
+		NotificationChannels:       s.NotificationChannels,
+	}
+}
+
+type GetSendFailuresRequest struct {
+	Limit int `query:"limit"`
+}
+
+type GetSendFailureResponse struct {
+	Id        string    `json:"id"`
+	AccountId string    `json:"account_id"`
+	Channel   string    `json:"channel"`
+	Error     string    `json:"error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func SendFailuresToGetSendFailureResponses(sendFailures []app_models.SendFailure) []GetSendFailureResponse {
+	responses := []GetSendFailureResponse{}
+	for _, f := range sendFailures {
+		responses = append(responses, GetSendFailureResponse{
+			Id:        f.Id,
+			AccountId: f.AccountId,
+			Channel:   f.Channel,
+			Error:     f.Error,
+			CreatedAt: f.CreatedAt,
+		})
+	}
+	return responses
+}
@@ -0,0 +1,6 @@
+package models
+
+type GetCalendarResponse struct {
+	Url              string `json:"url"`
+	SubstitutionsUrl string `json:"substitutions_url"`
+}
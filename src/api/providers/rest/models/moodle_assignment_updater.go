@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type GetMoodleAssignmentResponse struct {
+	Id         int        `json:"id"`
+	CourseId   int        `json:"course_id"`
+	CourseName string     `json:"course_name"`
+	Name       string     `json:"name"`
+	Intro      string     `json:"intro"`
+	DueDate    *time.Time `json:"due_date"`
+	CutoffDate *time.Time `json:"cutoff_date"`
+	// One of "new", "draft", "submitted", "graded" or empty if unknown
+	SubmissionStatus string `json:"submission_status"`
+}
+
+type GetMoodleAssignmentsResponse struct {
+	Assignments []GetMoodleAssignmentResponse `json:"assignments"`
+	UpdatedAt   time.Time                     `json:"updated_at"`
+}
+
+// Returns nil for the zero time, so that it's encoded as null
+func timeOrNil(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func MoodleAssignmentsToGetMoodleAssignmentsResponse(m app_models.MoodleAssignments) GetMoodleAssignmentsResponse {
+	assignments := []GetMoodleAssignmentResponse{}
+	for _, a := range m.Assignments {
+		assignments = append(assignments, GetMoodleAssignmentResponse{
+			Id:         a.Id,
+			CourseId:   a.CourseId,
+			CourseName: a.CourseName,
+			Name:       a.Name,
+			Intro:      a.Intro,
+			DueDate:    timeOrNil(a.DueDate),
+			CutoffDate: timeOrNil(a.CutoffDate),
+
+			SubmissionStatus: a.SubmissionStatus,
+		})
+	}
+
+	return GetMoodleAssignmentsResponse{
+		Assignments: assignments,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+type PutMoodleCourseSettingRequest struct {
+	RemindersEnabled bool `json:"reminders_enabled"`
+}
+
+type GetMoodleCourseSettingResponse struct {
+	CourseId         int    `json:"course_id"`
+	CourseName       string `json:"course_name,omitempty"`
+	RemindersEnabled bool   `json:"reminders_enabled"`
+}
+
+func MoodleCourseSettingToGetMoodleCourseSettingResponse(s app_models.MoodleCourseSetting) GetMoodleCourseSettingResponse {
+	return GetMoodleCourseSettingResponse{
+		CourseId:         s.CourseId,
+		CourseName:       s.CourseName,
+		RemindersEnabled: s.RemindersEnabled,
+	}
+}
+
+func MoodleCourseSettingsToGetMoodleCourseSettingResponses(settings []app_models.MoodleCourseSetting) []GetMoodleCourseSettingResponse {
+	responses := []GetMoodleCourseSettingResponse{}
+	for _, s := range settings {
+		responses = append(responses, MoodleCourseSettingToGetMoodleCourseSettingResponse(s))
+	}
+	return responses
+}
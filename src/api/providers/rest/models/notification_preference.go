@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type PutNotificationPreferenceRequest struct {
+	Paused          bool       `json:"paused"`
+	PausedUntil     *time.Time `json:"paused_until"`
+	OptedOut        bool       `json:"opted_out"`
+	QuietHoursStart string     `json:"quiet_hours_start"`
+	QuietHoursEnd   string     `json:"quiet_hours_end"`
+	Digest          bool       `json:"digest"`
+}
+
+type GetNotificationPreferenceResponse struct {
+	Paused          bool       `json:"paused"`
+	PausedUntil     *time.Time `json:"paused_until"`
+	OptedOut        bool       `json:"opted_out"`
+	QuietHoursStart string     `json:"quiet_hours_start"`
+	QuietHoursEnd   string     `json:"quiet_hours_end"`
+	Digest          bool       `json:"digest"`
+}
+
+func NotificationPreferenceToGetNotificationPreferenceResponse(n app_models.NotificationPreference) GetNotificationPreferenceResponse {
+	r := GetNotificationPreferenceResponse{
+		Paused:          n.Paused,
+		OptedOut:        n.OptedOut,
+		QuietHoursStart: n.QuietHoursStart,
+		QuietHoursEnd:   n.QuietHoursEnd,
+		Digest:          n.Digest,
+	}
+
+	if !n.PausedUntil.IsZero() {
+		r.PausedUntil = &n.PausedUntil
+	}
+
+	return r
+}
@@ -0,0 +1,28 @@
+package models
+
+import app_models "github.com/dattito/purrmannplus-backend/app/models"
+
+type PutNotificationSettingRequest struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+}
+
+type GetNotificationSettingResponse struct {
+	Channel   string `json:"channel"`
+	Recipient string `json:"recipient"`
+}
+
+func NotificationSettingToGetNotificationSettingResponse(n app_models.NotificationSetting) GetNotificationSettingResponse {
+	return GetNotificationSettingResponse{
+		Channel:   n.Channel,
+		Recipient: n.Recipient,
+	}
+}
+
+func NotificationSettingsToGetNotificationSettingResponses(ns []app_models.NotificationSetting) []GetNotificationSettingResponse {
+	responses := []GetNotificationSettingResponse{}
+	for _, n := range ns {
+		responses = append(responses, NotificationSettingToGetNotificationSettingResponse(n))
+	}
+	return responses
+}
@@ -1,6 +1,29 @@
 package models
 
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
 type PostAddAccountToSubstitutionRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
 }
+
+type GetSubstitutionsResponse struct {
+	Entries   []app_models.Substitution `json:"entries"`
+	UpdatedAt time.Time                 `json:"updated_at"`
+}
+
+func SubstitutionsToGetSubstitutionsResponse(s app_models.Substitutions) GetSubstitutionsResponse {
+	entries := s.Entries
+	if entries == nil {
+		entries = []app_models.Substitution{}
+	}
+
+	return GetSubstitutionsResponse{
+		Entries:   entries,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
@@ -9,14 +9,45 @@ const (
 	AddAccountRoute                      = "/accounts"
 	GetAccountsRoute                     = "/accounts"
 	DeleteAccountRoute                   = "/accounts"
+	UpdateAccountPasswordRoute           = "/accounts/password"
 	SendPhoneNumberConfirmationLinkRoute = "/accounts/phone_number"
 	AddPhoneNumberRoute                  = "/accounts/phone_number/validate"
 
+	GetNotificationSettingsRoute   = "/notification_settings"
+	SetNotificationSettingRoute    = "/notification_settings"
+	RemoveNotificationSettingRoute = "/notification_settings/:channel"
+	GetNotificationPreferenceRoute = "/notification_preference"
+	SetNotificationPreferenceRoute = "/notification_preference"
+
 	AddAccountToSubstitutionUpdaterRoute      = "/substitution_updater"
 	RemoveAccountFromSubstitutionUpdaterRoute = "/substitution_updater"
+	GetSubstitutionsRoute                     = "/substitution_updater"
 
 	AddAccountToMoodleAssignmentUpdaterRoute      = "/moodle_assignment_updater"
 	RemoveAccountFromMoodleAssignmentUpdaterRoute = "/moodle_assignment_updater"
+	GetMoodleAssignmentsRoute                     = "/moodle_assignment_updater"
+	GetMoodleCourseSettingsRoute                  = "/moodle_assignment_updater/courses"
+	SetMoodleCourseSettingRoute                   = "/moodle_assignment_updater/courses/:course_id"
+
+	AddAccountToMoodleForumUpdaterRoute      = "/moodle_forum_updater"
+	RemoveAccountFromMoodleForumUpdaterRoute = "/moodle_forum_updater"
+
+	AddAccountToMoodleGradeUpdaterRoute      = "/moodle_grade_updater"
+	RemoveAccountFromMoodleGradeUpdaterRoute = "/moodle_grade_updater"
+
+	GetCalendarRoute                 = "/calendar"
+	GetCalendarFeedRoute             = "/calendar/:token.ics"
+	GetSubstitutionCalendarFeedRoute = "/calendar/:token/substitutions.ics"
+	RotateCalendarTokenRoute         = "/calendar/rotate"
+	RevokeCalendarTokenRoute         = "/calendar"
+
+	AdminRoute                  = "/admin"
+	GetJobRunsRoute             = "/job_runs"
+	GetAccountUpdaterStateRoute = "/accounts/:id/updaters"
+	ForceAccountUpdateRoute     = "/accounts/:id/update"
+	DisableAccountRoute         = "/accounts/:id/disable"
+	EnableAccountRoute          = "/accounts/:id/enable"
+	GetSendFailuresRoute        = "/send_failures"
 
 	RegistrationSpeedFormRoute                        = "/registration_speed_form"
 	RegistrationSpeedFormSubstitutionCredentialsRoute = "/registration_speed_form/substitution-credentials"
@@ -7,14 +7,13 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/session"
 )
 
-var SessionStore *session.Store
-
-func Init() {
-	SessionStore = session.New(session.Config{
-		CookieHTTPOnly: config.AUTHORIZATION_COOKIE_HTTPONLY,
-		CookieSecure:   config.AUTHORIZATION_COOKIE_SECURE,
-		CookieSameSite: config.AUTHORIZATION_COOKIE_SAMESITE,
-		CookieDomain:   config.AUTHORIZATION_COOKIE_DOMAIN,
+// Returns the session store of the registration speed form
+func New(cfg *config.Config) *session.Store {
+	return session.New(session.Config{
+		CookieHTTPOnly: cfg.AUTHORIZATION_COOKIE_HTTPONLY,
+		CookieSecure:   cfg.AUTHORIZATION_COOKIE_SECURE,
+		CookieSameSite: cfg.AUTHORIZATION_COOKIE_SAMESITE,
+		CookieDomain:   cfg.AUTHORIZATION_COOKIE_DOMAIN,
 		Expiration:     10 * time.Minute,
 	})
 }
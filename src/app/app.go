@@ -2,12 +2,30 @@ package app
 
 import (
 	"github.com/dattito/purrmannplus-backend/app/commands"
-	"github.com/dattito/purrmannplus-backend/config"
 )
 
-func Init() {
-	if config.ENABLE_SUBSTITUTIONS_SCHEDULER {
-		commands.EnableSubstitutionUpdater()
-		commands.EnableMoodleAssignmentUpdater()
+// Schedules the updaters and starts answering signal commands, if enabled in the config of the app.
+// Returns an error if an updater can't be scheduled, e.g. because of an invalid cron expression
+func Init(a *commands.App) error {
+	if a.Config.ENABLE_SUBSTITUTIONS_SCHEDULER {
+		for _, enable := range []func() error{
+			a.EnableSubstitutionUpdater,
+			a.EnableMoodleAssignmentUpdater,
+			a.EnableMoodleReminders,
+			a.EnableMoodleForumUpdater,
+			a.EnableMoodleGradeUpdater,
+			a.EnableMoodleCalendarSync,
+			a.EnableNotificationOutbox,
+		} {
+			if err := enable(); err != nil {
+				return err
+			}
+		}
 	}
+
+	if a.Config.ENABLE_SIGNAL_COMMANDS {
+		a.EnableSignalCommands()
+	}
+
+	return nil
 }
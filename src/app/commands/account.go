@@ -2,21 +2,22 @@ package commands
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/database"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
-	"github.com/dattito/purrmannplus-backend/services/moodle"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 )
 
 // Returns the accountId of the new account; error produced by user; error not produced by user
-func CreateAccount(username, password string) (models.Account, error, error) {
+func (app *App) CreateAccount(username, password string) (models.Account, error, error) {
 	if _, err := models.NewValidAccount(username, password); err != nil {
 		return models.Account{}, err, nil
 	}
 
-	correct, err := moodle.CheckCredentials(username, password)
+	correct, err := app.Moodle.CheckCredentials(username, password)
 	if err != nil {
 		return models.Account{}, nil, err
 	}
@@ -25,7 +26,12 @@ func CreateAccount(username, password string) (models.Account, error, error) {
 		return models.Account{}, errors.New("incorrect credentials"), nil
 	}
 
-	a, err := database.DB.AddAccount(username, password)
+	passwordHash, err := encryption.HashPassword(password)
+	if err != nil {
+		return models.Account{}, nil, err
+	}
+
+	a, err := app.DB.AddAccount(username, password, passwordHash)
 	if err == nil {
 		logging.Infof("Created account %s", a.Username)
 	}
@@ -34,14 +40,14 @@ func CreateAccount(username, password string) (models.Account, error, error) {
 }
 
 // Returns the id and the credentials of all accounts
-func GetAllAccounts() ([]models.Account, error) {
+func (app *App) GetAllAccounts() ([]models.Account, error) {
 
-	return database.DB.GetAccounts()
+	return app.DB.GetAccounts()
 }
 
 // Returns the accountId and the credentials of the account
-func GetAccount(accountId string) (models.Account, error) {
-	a, err := database.DB.GetAccount(accountId)
+func (app *App) GetAccount(accountId string) (models.Account, error) {
+	a, err := app.DB.GetAccount(accountId)
 	if err != nil {
 		return models.Account{}, err
 	}
@@ -50,8 +56,8 @@ func GetAccount(accountId string) (models.Account, error) {
 }
 
 // Returns true if the accountId was found in the database
-func ValidAccountId(accountId string) (bool, error) {
-	_, err := GetAccount(accountId)
+func (app *App) ValidAccountId(accountId string) (bool, error) {
+	_, err := app.GetAccount(accountId)
 	if err != nil {
 		if errors.Is(err, &db_errors.ErrRecordNotFound) {
 			return false, nil
@@ -62,8 +68,49 @@ func ValidAccountId(accountId string) (bool, error) {
 	return true, nil
 }
 
-// Returns the accountId and the credentials of the account matching the credentials
-func GetAccountByCredentials(username, password string) (models.Account, error) {
+var ErrAccountDisabled = errors.New("account is disabled")
+
+// Returns the account a login token was issued for, with the role of the current ADMIN_USERNAMES.
+// The token isn't valid anymore if the account was deleted or disabled; error produced by user; error not produced by user
+func (app *App) GetLoggedInAccount(accountId string) (models.Account, error, error) {
+	a, err := app.DB.GetAccount(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return models.Account{}, errors.New("account does not exist anymore"), nil
+		}
+		return models.Account{}, nil, err
+	}
+
+	if a.Disabled {
+		return models.Account{}, ErrAccountDisabled, nil
+	}
+
+	if err := app.syncAdminRole(&a); err != nil {
+		return models.Account{}, nil, err
+	}
+
+	return a, nil, nil
+}
+
+// Returns the account of the given username
+func (app *App) GetAccountByUsername(username string) (models.Account, error) {
+	return app.DB.GetAccountByUsername(username)
+}
+
+// Failed logins are checked against moodle at most this often within loginFallbackWindow.
+// The limit per ip is higher, because the users behind a proxy share one.
+const (
+	maxLoginFallbacksPerUsername = 5
+	maxLoginFallbacksPerIp       = 30
+	loginFallbackWindow          = 15 * time.Minute
+)
+
+var ErrTooManyLoginAttempts = errors.New("too many login attempts, try again later")
+
+// Returns the account matching the login credentials. The password is checked against the stored hash,
+// if it doesn't match (e.g. because the moodle password was changed), it's checked against moodle and
+// the hash and the stored moodle password are updated. The checks against moodle are limited per username and ip.
+func (app *App) Login(username, password, ip string) (models.Account, error) {
 	if username == "" {
 		return models.Account{}, errors.New("missing authId")
 	}
@@ -72,17 +119,99 @@ func GetAccountByCredentials(username, password string) (models.Account, error)
 		return models.Account{}, errors.New("missing authPw")
 	}
 
-	a, err := database.DB.GetAccountByCredentials(username, password)
+	a, err := app.GetAccountByUsername(username)
 	if err != nil {
 		return models.Account{}, err
 	}
 
-	return a, nil
+	if a.Disabled {
+		return models.Account{}, ErrAccountDisabled
+	}
+
+	if a.PasswordHash != "" && encryption.CheckPasswordHash(password, a.PasswordHash) {
+		return a, app.syncAdminRole(&a)
+	}
+
+	now := time.Now()
+	// The ip is checked first, so that a throttled ip doesn't use up the attempts of the username
+	if !app.loginFallbacksByIp.Allow(now, ip) || !app.loginFallbacksByUsername.Allow(now, strings.ToLower(a.Username)) {
+		logging.Warningf("Too many failed logins for account %s from %s", a.Username, ip)
+		return models.Account{}, ErrTooManyLoginAttempts
+	}
+
+	correct, err := app.Moodle.CheckCredentials(a.Username, password)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	if !correct {
+		return models.Account{}, &db_errors.ErrRecordNotFound
+	}
+
+	passwordHash, err := encryption.HashPassword(password)
+	if err != nil {
+		return models.Account{}, err
+	}
+
+	// The moodle password changed, so the updaters need the new one as well
+	if err := app.DB.SetAccountPassword(a.Id, password); err != nil {
+		return models.Account{}, err
+	}
+	a.Password = password
+
+	if err := app.DB.SetAccountPasswordHash(a.Id, passwordHash); err != nil {
+		return models.Account{}, err
+	}
+	a.PasswordHash = passwordHash
+
+	return a, app.syncAdminRole(&a)
+}
+
+// Updates the stored moodle password and the login password after checking it against moodle; error produced by user; error not produced by user
+func (app *App) UpdateAccountPassword(accountId, password string) (error, error) {
+	if password == "" {
+		return errors.New("password is empty"), nil
+	}
+
+	a, err := app.DB.GetAccount(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return errors.New("account does not exist"), nil
+		}
+		return nil, err
+	}
+
+	correct, err := app.Moodle.CheckCredentials(a.Username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	if !correct {
+		return errors.New("credentials are incorrect for moodle"), nil
+	}
+
+	passwordHash, err := encryption.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := app.DB.SetAccountPassword(accountId, password); err != nil {
+		return nil, err
+	}
+
+	// The login password is the moodle password, so the old one mustn't work any longer
+	if err := app.DB.SetAccountPasswordHash(accountId, passwordHash); err != nil {
+		return nil, err
+	}
+
+	logging.Infof("Updated moodle password of account %s", a.Username)
+
+	return nil, nil
 }
 
 // Deleting an account
-func DeleteAccount(accountId string) error {
-	err := database.DB.DeleteAccount(accountId)
+func (app *App) DeleteAccount(accountId string) error {
+	err := app.DB.DeleteAccount(accountId)
 	if err == nil {
 		logging.Infof("Deleted account %s", accountId)
 	}
@@ -91,6 +220,38 @@ func DeleteAccount(accountId string) error {
 }
 
 // Checks the credentials of an account, should be the same as mooodle.CheckCredentials(username, password)
-func CheckCredentials(username, password string) (bool, error) {
-	return moodle.CheckCredentials(username, password)
+func (app *App) CheckCredentials(username, password string) (bool, error) {
+	return app.Moodle.CheckCredentials(username, password)
+}
+
+// Returns true if the username is listed in ADMIN_USERNAMES
+func (app *App) isAdminUsername(username string) bool {
+	for _, adminUsername := range strings.Split(app.Config.ADMIN_USERNAMES, ",") {
+		if strings.TrimSpace(adminUsername) != "" && strings.EqualFold(strings.TrimSpace(adminUsername), username) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Gives the account the admin role if it's listed in ADMIN_USERNAMES and takes it away if it's not listed anymore
+func (app *App) syncAdminRole(a *models.Account) error {
+	role := models.RoleUser
+	if app.isAdminUsername(a.Username) {
+		role = models.RoleAdmin
+	}
+
+	if a.Role == role {
+		return nil
+	}
+
+	if err := app.DB.SetAccountRole(a.Id, role); err != nil {
+		return err
+	}
+	a.Role = role
+
+	logging.Infof("Account %s got the %s role", a.Username, role)
+
+	return nil
 }
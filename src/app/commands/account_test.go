@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/dattito/purrmannplus-backend/services/moodle/moodletest"
+	"github.com/dattito/purrmannplus-backend/utils/ratelimit"
+)
+
+func TestThrottledIpDoesNotUseUpTheLoginAttemptsOfTheUsername(t *testing.T) {
+	app, _ := setupTest(t)
+	createTestAccount(t, app, "alice")
+
+	srv := moodletest.NewServer()
+	defer srv.Close()
+	srv.AddUser("alice", "password")
+	app.Moodle.Url = srv.URL
+
+	app.loginFallbacksByIp = ratelimit.NewKeyLimiter(1, loginFallbackWindow)
+
+	for i := 0; i < maxLoginFallbacksPerUsername+1; i++ {
+		app.Login("alice", "falsch", "192.0.2.1")
+	}
+
+	if _, err := app.Login("alice", "falsch", "192.0.2.2"); err == ErrTooManyLoginAttempts {
+		t.Error("Login() from another ip = ErrTooManyLoginAttempts, want the username to be checked against moodle")
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+)
+
+// Returns the account or a user error if it does not exist
+func (app *App) getExistingAccount(accountId string) (models.Account, error, error) {
+	a, err := app.DB.GetAccount(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return models.Account{}, errors.New("account does not exist"), nil
+		}
+		return models.Account{}, nil, err
+	}
+
+	return a, nil, nil
+}
+
+// Returns the state of the updaters of an account; error produced by user; error not produced by user
+func (app *App) GetUpdaterState(accountId string) (models.UpdaterState, error, error) {
+	a, user_err, db_err := app.getExistingAccount(accountId)
+	if user_err != nil || db_err != nil {
+		return models.UpdaterState{}, user_err, db_err
+	}
+
+	state := models.UpdaterState{
+		AccountId: a.Id,
+		Disabled:  a.Disabled,
+	}
+
+	s, err := app.DB.GetSubstitutions(accountId)
+	if err == nil {
+		state.SubstitutionUpdater = true
+		state.SubstitutionCount = len(s.Entries)
+		state.SubstitutionsUpdatedAt = s.UpdatedAt
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return models.UpdaterState{}, nil, err
+	}
+
+	m, err := app.DB.GetMoodleAssignments(accountId)
+	if err == nil {
+		state.MoodleAssignmentUpdater = true
+		state.MoodleAssignmentCount = len(m.Assignments)
+		state.MoodleAssignmentsUpdatedAt = m.UpdatedAt
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return models.UpdaterState{}, nil, err
+	}
+
+	if _, err := app.DB.GetMoodleForumInfos(accountId); err == nil {
+		state.MoodleForumUpdater = true
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return models.UpdaterState{}, nil, err
+	}
+
+	if _, err := app.DB.GetMoodleGradeInfos(accountId); err == nil {
+		state.MoodleGradeUpdater = true
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return models.UpdaterState{}, nil, err
+	}
+
+	settings, err := app.DB.GetNotificationSettings(accountId)
+	if err != nil {
+		return models.UpdaterState{}, nil, err
+	}
+
+	state.NotificationChannels = []string{}
+	for _, setting := range settings {
+		state.NotificationChannels = append(state.NotificationChannels, setting.Channel)
+	}
+
+	return state, nil, nil
+}
+
+// Runs the updaters the account is in immediately; error produced by user; error not produced by user
+func (app *App) ForceAccountUpdate(accountId string) (error, error) {
+	state, user_err, db_err := app.GetUpdaterState(accountId)
+	if user_err != nil || db_err != nil {
+		return user_err, db_err
+	}
+
+	if state.Disabled {
+		return errors.New("account is disabled"), nil
+	}
+
+	if !state.SubstitutionUpdater && !state.MoodleAssignmentUpdater && !state.MoodleForumUpdater && !state.MoodleGradeUpdater {
+		return errors.New("account is in no updater"), nil
+	}
+
+	if state.SubstitutionUpdater {
+		if err := app.UpdateSubstitutionsByAccountId(accountId); err != nil {
+			return nil, err
+		}
+	}
+
+	if state.MoodleAssignmentUpdater {
+		if err := app.UpdateMoodleAssignmentsByAccountId(accountId); err != nil {
+			return nil, err
+		}
+	}
+
+	if state.MoodleForumUpdater {
+		if err := app.UpdateMoodleForumsByAccountId(accountId); err != nil {
+			return nil, err
+		}
+	}
+
+	if state.MoodleGradeUpdater {
+		if err := app.UpdateMoodleGradesByAccountId(accountId); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// Disables or enables an account; error produced by user; error not produced by user
+func (app *App) SetAccountDisabled(accountId string, disabled bool) (error, error) {
+	if _, user_err, db_err := app.getExistingAccount(accountId); user_err != nil || db_err != nil {
+		return user_err, db_err
+	}
+
+	return nil, app.DB.SetAccountDisabled(accountId, disabled)
+}
+
+// Returns the latest notifications which couldn't be sent, newest first
+func (app *App) GetSendFailures(limit int) ([]models.SendFailure, error) {
+	return app.DB.GetSendFailures(limit)
+}
@@ -0,0 +1,73 @@
+package commands
+
+import (
+	"context"
+
+	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/database/providers"
+	"github.com/dattito/purrmannplus-backend/services/moodle"
+	"github.com/dattito/purrmannplus-backend/services/notifier"
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
+	"github.com/dattito/purrmannplus-backend/services/substitutions"
+	"github.com/dattito/purrmannplus-backend/utils/httpclient"
+	"github.com/dattito/purrmannplus-backend/utils/ratelimit"
+)
+
+// Sends and receives signal messages, implemented by *signal_message_sender.SignalMessageSender
+type SignalClient interface {
+	Send(message, recipientPhoneNumber string) error
+	Receive(ctx context.Context, timeout int) ([]signal_message_sender.IncomingMessage, error)
+}
+
+// Runs jobs at their cron times, implemented by *scheduler.Scheduler
+type JobScheduler interface {
+	AddJob(name, cron string, exec func()) error
+}
+
+// Holds everything the commands depend on, so that multiple instances can run in one process
+type App struct {
+	Config        *config.Config
+	DB            provider.Provider
+	Signal        SignalClient
+	Notifiers     notifier.Notifiers
+	Scheduler     JobScheduler
+	HTTP          *httpclient.Client
+	Moodle        *moodle.Client
+	Substitutions *substitutions.Client
+
+	// Limit the logins which are checked against moodle
+	loginFallbacksByUsername *ratelimit.KeyLimiter
+	loginFallbacksByIp       *ratelimit.KeyLimiter
+}
+
+// Returns an App with the given dependencies. The requests to moodle and the substitution website
+// are sent with the given Doer, limited to the configured rate limits.
+func New(cfg *config.Config, db provider.Provider, signal SignalClient, notifiers notifier.Notifiers, scheduler JobScheduler, doer httpclient.Doer) (*App, error) {
+	http := httpclient.New(doer)
+
+	if cfg.SUBSTITUTION_URL != "" {
+		if err := http.SetRateLimit(cfg.SUBSTITUTION_URL, cfg.SUBSTITUTION_RATE_LIMIT); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.MOODLE_URL != "" {
+		if err := http.SetRateLimit(cfg.MOODLE_URL, cfg.MOODLE_RATE_LIMIT); err != nil {
+			return nil, err
+		}
+	}
+
+	return &App{
+		Config:        cfg,
+		DB:            db,
+		Signal:        signal,
+		Notifiers:     notifiers,
+		Scheduler:     scheduler,
+		HTTP:          http,
+		Moodle:        moodle.NewClient(cfg.MOODLE_URL, http),
+		Substitutions: substitutions.NewClient(cfg.SUBSTITUTION_URL, http),
+
+		loginFallbacksByUsername: ratelimit.NewKeyLimiter(maxLoginFallbacksPerUsername, loginFallbackWindow),
+		loginFallbacksByIp:       ratelimit.NewKeyLimiter(maxLoginFallbacksPerIp, loginFallbackWindow),
+	}, nil
+}
@@ -0,0 +1,275 @@
+package commands
+
+import (
+	"context"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/services/calendar"
+	"github.com/dattito/purrmannplus-backend/services/substitutions"
+	"github.com/dattito/purrmannplus-backend/utils"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Length of the calendar tokens in bytes
+const calendarTokenLength = 32
+
+// Returns the calendar token of the account, creates one if there is none yet
+func (app *App) GetOrCreateCalendarToken(accountId string) (string, error) {
+	token, err := app.DB.GetCalendarToken(accountId)
+	if err == nil {
+		return token, nil
+	}
+
+	if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return "", err
+	}
+
+	token, err = utils.GenerateToken(calendarTokenLength)
+	if err != nil {
+		return "", err
+	}
+
+	if err := app.DB.SetCalendarToken(accountId, token); err != nil {
+		return "", err
+	}
+
+	// The feed should contain the moodle events right away, not only after the next scheduled sync
+	if err := app.SyncMoodleCalendarByAccountId(accountId); err != nil {
+		logging.Errorf("Error while syncing moodle calendar of account %s: %s", accountId, err.Error())
+	}
+
+	return token, nil
+}
+
+// Fetches the moodle calendar events of an account and stores them
+func (app *App) SyncMoodleCalendar(ctx context.Context, m models.MoodleCalendarInfo) error {
+	logging.Debugf("Syncing moodle calendar of account %s (id: %s)", m.AuthId, m.AccountId)
+
+	var events []models.MoodleCalendarEvent
+	err := app.withMoodleToken(ctx, m.AccountId, m.AuthId, m.AuthPw, m.MoodleToken, func(token string) error {
+		var err error
+		events, err = app.Moodle.GetCalendarEvents(ctx, token, time.Now())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return app.DB.SetMoodleCalendarEvents(m.AccountId, events)
+}
+
+func (app *App) SyncMoodleCalendarByAccountId(accountId string) error {
+	m, err := app.DB.GetMoodleCalendarInfos(accountId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := app.newUpdateContext()
+	defer cancel()
+
+	return app.SyncMoodleCalendar(ctx, m)
+}
+
+// Syncs the moodle calendars of all accounts with a calendar feed using the worker pool
+func (app *App) SyncAllMoodleCalendars() (workerpool.Summary, error) {
+	ms, err := app.DB.GetAllMoodleCalendarInfos()
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	pool := app.newUpdaterPool(app.Config.MAX_ERROS_TO_STOP_SYNCING_MOODLE_CALENDARS)
+
+	return pool.Run(len(ms), func(ctx context.Context, i int) workerpool.Result {
+		err := app.SyncMoodleCalendar(ctx, ms[i])
+		if err != nil {
+			logging.Errorf("Error while syncing moodle calendar of %s: %s", ms[i].AuthId, err.Error())
+			err = fmt.Errorf("account %s: %w", ms[i].AccountId, err)
+		}
+
+		return workerpool.Result{Err: err}
+	}), nil
+}
+
+func (app *App) EnableMoodleCalendarSync() error {
+	return app.addRecordedJob(JobMoodleCalendarSync, app.Config.MOODLE_CALENDAR_SYNCCRON, app.SyncAllMoodleCalendars)
+}
+
+// Returns the calendar events of the moodle calendar and the assignment deadlines. Deadlines which
+// are in the moodle calendar already are left out.
+func moodleCalendarEvents(accountId string, events []models.MoodleCalendarEvent, assignments []models.MoodleAssignment) []calendar.Event {
+	var calendarEvents []calendar.Event
+
+	assignmentsInCalendar := make(map[int]bool)
+	for _, e := range events {
+		if e.ModuleName == "assign" {
+			assignmentsInCalendar[e.Instance] = true
+		}
+
+		calendarEvents = append(calendarEvents, calendar.Event{
+			UID:         calendar.UID(accountId, "moodle-event", e.Id),
+			Summary:     e.Name,
+			Description: e.CourseName,
+			Start:       e.Start,
+			End:         e.Start,
+			URL:         e.Url,
+		})
+	}
+
+	for _, a := range assignments {
+		if a.DueDate.IsZero() || assignmentsInCalendar[a.Id] {
+			continue
+		}
+
+		calendarEvents = append(calendarEvents, calendar.Event{
+			UID:         calendar.UID(accountId, "moodle-assignment", a.Id),
+			Summary:     fmt.Sprintf("Abgabe: %s", a.Name),
+			Description: a.CourseName,
+			Start:       a.DueDate,
+			End:         a.DueDate,
+		})
+	}
+
+	return calendarEvents
+}
+
+// Returns the configured period times, logs an error and returns none if they are invalid
+func (app *App) periodTimes() []substitutions.PeriodTime {
+	periodTimes, err := substitutions.ParsePeriodTimes(app.Config.SUBSTITUTION_PERIOD_TIMES)
+	if err != nil {
+		logging.Errorf("Invalid SUBSTITUTION_PERIOD_TIMES: %s", err.Error())
+		return nil
+	}
+	return periodTimes
+}
+
+// Returns an event for every substitution whose date can be parsed. If the times of the period
+// are known, the event lasts for the period, otherwise it's an all day event.
+// The dates and period times are local times of the school, independent of the time zone the database returns.
+func substitutionCalendarEvents(accountId string, s models.Substitutions, periodTimes []substitutions.PeriodTime) []calendar.Event {
+	updatedAt := s.UpdatedAt.In(time.Local)
+
+	var calendarEvents []calendar.Event
+	for _, entry := range s.Entries {
+		date, err := substitutions.ParseDate(entry.Date, updatedAt)
+		if err != nil {
+			logging.Debugf("Skipping substitution without a date in calendar: %s", err.Error())
+			continue
+		}
+
+		// All fields are part of the id, rows of the same period may only differ in the teacher or the room
+		id := fmt.Sprintf("%x", sha1.Sum([]byte(strings.Join([]string{date.Format("20060102"), entry.Period, entry.Class, entry.Subject,
+			entry.OriginalTeacher, entry.SubstituteTeacher, entry.Room, entry.Note}, "|"))))
+		event := calendar.Event{
+			UID:     calendar.UID(accountId, "substitution", id[:16]),
+			Summary: fmt.Sprintf("Vertretung: %s", substitutionToText(entry)),
+			Start:   date,
+			AllDay:  true,
+		}
+
+		if start, end, ok := substitutions.PeriodTimeRange(entry.Period, date, periodTimes); ok {
+			event.Start, event.End, event.AllDay = start, end, false
+		}
+
+		calendarEvents = append(calendarEvents, event)
+	}
+	return calendarEvents
+}
+
+// Returns the id of the account the calendar token belongs to, the feeds of disabled accounts don't exist;
+// error produced by user; error not produced by user
+func (app *App) getAccountIdByCalendarToken(token string) (string, error, error) {
+	accountId, err := app.DB.GetAccountIdByCalendarToken(token)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return "", errors.New("calendar does not exist"), nil
+		}
+		return "", nil, err
+	}
+
+	a, err := app.DB.GetAccount(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return "", errors.New("calendar does not exist"), nil
+		}
+		return "", nil, err
+	}
+
+	if a.Disabled {
+		return "", errors.New("calendar does not exist"), nil
+	}
+
+	return accountId, nil, nil
+}
+
+// Returns the iCalendar feed of the account the token belongs to; error produced by user; error not produced by user
+func (app *App) GetCalendarFeed(token string) (string, error, error) {
+	accountId, user_err, db_err := app.getAccountIdByCalendarToken(token)
+	if user_err != nil || db_err != nil {
+		return "", user_err, db_err
+	}
+
+	events, err := app.DB.GetMoodleCalendarEvents(accountId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var assignments []models.MoodleAssignment
+	if m, err := app.DB.GetMoodleAssignments(accountId); err == nil {
+		assignments = m.Assignments
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return "", nil, err
+	}
+
+	calendarEvents := moodleCalendarEvents(accountId, events, assignments)
+
+	if s, err := app.DB.GetSubstitutions(accountId); err == nil {
+		calendarEvents = append(calendarEvents, substitutionCalendarEvents(accountId, s, app.periodTimes())...)
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return "", nil, err
+	}
+
+	return calendar.Encode("PurrmannPlus", calendarEvents, time.Now()), nil, nil
+}
+
+// Returns the iCalendar feed of the substitutions of the account the token belongs to; error produced by user; error not produced by user
+func (app *App) GetSubstitutionCalendarFeed(token string) (string, error, error) {
+	accountId, user_err, db_err := app.getAccountIdByCalendarToken(token)
+	if user_err != nil || db_err != nil {
+		return "", user_err, db_err
+	}
+
+	var calendarEvents []calendar.Event
+	if s, err := app.DB.GetSubstitutions(accountId); err == nil {
+		calendarEvents = substitutionCalendarEvents(accountId, s, app.periodTimes())
+	} else if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+		return "", nil, err
+	}
+
+	return calendar.Encode("PurrmannPlus Vertretungsplan", calendarEvents, time.Now()), nil, nil
+}
+
+// Replaces the calendar token of the account, so that the old feed urls stop working
+func (app *App) RotateCalendarToken(accountId string) (string, error) {
+	token, err := utils.GenerateToken(calendarTokenLength)
+	if err != nil {
+		return "", err
+	}
+
+	if err := app.DB.SetCalendarToken(accountId, token); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// Removes the calendar token of the account, the feeds stop working and aren't synced anymore
+func (app *App) RevokeCalendarToken(accountId string) error {
+	return app.DB.RemoveCalendarToken(accountId)
+}
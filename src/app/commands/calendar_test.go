@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/services/moodle/moodletest"
+	"github.com/dattito/purrmannplus-backend/services/substitutions"
+)
+
+func TestCalendarToken(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.SetCalendarToken(a.Id, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := app.GetOrCreateCalendarToken(a.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token != "token" {
+		t.Errorf("app.GetOrCreateCalendarToken() = %q, want the existing token", token)
+	}
+
+	rotated, err := app.RotateCalendarToken(a.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rotated == token {
+		t.Error("rotating kept the token")
+	}
+
+	if _, user_err, db_err := app.GetSubstitutionCalendarFeed(token); user_err == nil || db_err != nil {
+		t.Errorf("old token: got user_err = %v, db_err = %v, want a user error", user_err, db_err)
+	}
+
+	if _, user_err, db_err := app.GetSubstitutionCalendarFeed(rotated); user_err != nil || db_err != nil {
+		t.Errorf("new token: got user_err = %v, db_err = %v", user_err, db_err)
+	}
+
+	if err := app.RevokeCalendarToken(a.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, user_err, _ := app.GetCalendarFeed(rotated); user_err == nil {
+		t.Error("revoked token still works")
+	}
+}
+
+func TestCalendarFeedsOfDisabledAccounts(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.SetCalendarToken(a.Id, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DB.SetAccountDisabled(a.Id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, user_err, db_err := app.GetCalendarFeed("token"); user_err == nil || db_err != nil {
+		t.Errorf("moodle feed: got user_err = %v, db_err = %v, want a user error", user_err, db_err)
+	}
+
+	if _, user_err, db_err := app.GetSubstitutionCalendarFeed("token"); user_err == nil || db_err != nil {
+		t.Errorf("substitution feed: got user_err = %v, db_err = %v, want a user error", user_err, db_err)
+	}
+}
+
+func TestSyncMoodleCalendarUsesTheCachedToken(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	srv := moodletest.NewServer()
+	defer srv.Close()
+	app.Moodle.Url = srv.URL
+
+	// The stored password isn't accepted by moodle, so the sync only works with the cached token
+	token := srv.AddUser("alice", "anderesPasswort")
+	srv.SetResponse("alice", "core_calendar_get_action_events_by_timesort", map[string]interface{}{"events": []interface{}{}})
+	if err := app.DB.SetAccountMoodleToken(a.Id, token); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DB.SetCalendarToken(a.Id, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.SyncMoodleCalendarByAccountId(a.Id); err != nil {
+		t.Errorf("SyncMoodleCalendarByAccountId() = %v, want the cached token to be used", err)
+	}
+}
+
+func TestSubstitutionCalendarFeed(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.SetCalendarToken(a.Id, "token"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DB.AddAccountToSubstitution(a.Id, "alice", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := []models.Substitution{{Date: "Mo 13.12.", Period: "3", Subject: "Mathe", Room: "A101"}}
+	if err := app.DB.SetSubstitutions(a.Id, entries, false); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, user_err, db_err := app.GetSubstitutionCalendarFeed("token")
+	if user_err != nil || db_err != nil {
+		t.Fatalf("got user_err = %v, db_err = %v", user_err, db_err)
+	}
+
+	if strings.Count(feed, "BEGIN:VEVENT") != 1 || !strings.Contains(feed, "Mathe") {
+		t.Errorf("feed doesn't contain the substitution:\n%s", feed)
+	}
+}
+
+func TestSubstitutionCalendarEventsOfTheSamePeriodHaveDifferentUIDs(t *testing.T) {
+	s := models.Substitutions{
+		UpdatedAt: time.Date(2021, 12, 12, 18, 0, 0, 0, time.Local),
+		Entries: []models.Substitution{
+			{Date: "Mo 13.12.", Period: "3", Class: "10a", Subject: "Sport", OriginalTeacher: "WEB", SubstituteTeacher: "MÜL", Room: "Halle 1"},
+			{Date: "Mo 13.12.", Period: "3", Class: "10a", Subject: "Sport", OriginalTeacher: "WEB", SubstituteTeacher: "SCH", Room: "Halle 2"},
+		},
+	}
+
+	events := substitutionCalendarEvents("account", s, nil)
+	if len(events) != 2 || events[0].UID == events[1].UID {
+		t.Errorf("substitutionCalendarEvents() = %+v, want two events with different UIDs", events)
+	}
+}
+
+func TestSubstitutionCalendarEventsUseLocalTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	local := time.Local
+	time.Local = berlin
+	defer func() { time.Local = local }()
+
+	periodTimes, err := substitutions.ParsePeriodTimes("07:45-08:30,08:35-09:20")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// SQLite returns the time of the last update in UTC
+	s := models.Substitutions{
+		UpdatedAt: time.Date(2021, 12, 12, 23, 30, 0, 0, time.UTC),
+		Entries:   []models.Substitution{{Date: "Mo 13.12.", Period: "2", Subject: "Mathe"}},
+	}
+
+	events := substitutionCalendarEvents("account", s, periodTimes)
+	if len(events) != 1 {
+		t.Fatalf("got %d events, want 1", len(events))
+	}
+
+	wantStart := time.Date(2021, 12, 13, 8, 35, 0, 0, berlin)
+	wantEnd := time.Date(2021, 12, 13, 9, 20, 0, 0, berlin)
+	if !events[0].Start.Equal(wantStart) || !events[0].End.Equal(wantEnd) {
+		t.Errorf("event lasts from %s to %s, want %s to %s", events[0].Start, events[0].End, wantStart, wantEnd)
+	}
+}
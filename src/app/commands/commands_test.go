@@ -0,0 +1,80 @@
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/database/providers/memory"
+	"github.com/dattito/purrmannplus-backend/services/notifier"
+)
+
+// A notifier which remembers the sent messages instead of sending them
+type fakeNotifier struct {
+	mu       sync.Mutex
+	messages []string
+	failing  bool // If true, sending fails like during an outage of the channel
+}
+
+func (f *fakeNotifier) Send(message, recipient string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing {
+		return errors.New("channel is not available")
+	}
+
+	f.messages = append(f.messages, message)
+	return nil
+}
+
+func (f *fakeNotifier) setFailing(failing bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failing = failing
+}
+
+func (f *fakeNotifier) sent() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]string(nil), f.messages...)
+}
+
+// Returns an App with an empty in-memory database, signal messages are sent to the returned fake
+func setupTest(t *testing.T) (*App, *fakeNotifier) {
+	t.Helper()
+
+	cfg := &config.Config{
+		DIGEST_TIMES:        "06:30,18:00",
+		UPDATER_CONCURRENCY: 2,
+		UPDATER_JOB_TIMEOUT: 10,
+	}
+
+	n := &fakeNotifier{}
+	app, err := New(cfg, memory.NewMemoryProvider(), nil, notifier.Notifiers{notifier.ChannelSignal: n}, nil, http.DefaultClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return app, n
+}
+
+// Creates an account with a phone number
+func createTestAccount(t *testing.T, app *App, username string) models.Account {
+	t.Helper()
+
+	a, err := app.DB.AddAccount(username, "password", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.DB.AddAccountInfo(a.Id, "+4915112345678"); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Names of the scheduled jobs, used in the job run history
+const (
+	JobSubstitutionUpdater     = "substitution_updater"
+	JobMoodleAssignmentUpdater = "moodle_assignment_updater"
+	JobMoodleReminder          = "moodle_reminder"
+	JobMoodleForumUpdater      = "moodle_forum_updater"
+	JobMoodleGradeUpdater      = "moodle_grade_updater"
+	JobMoodleCalendarSync      = "moodle_calendar_sync"
+	JobNotificationOutbox      = "notification_outbox"
+)
+
+// Runs an updater and stores the run in the job run history
+func (app *App) runAndRecordJob(job string, run func() (workerpool.Summary, error)) {
+	startedAt := time.Now()
+
+	summary, err := run()
+
+	jobRun := models.JobRun{
+		Job:               job,
+		StartedAt:         startedAt,
+		FinishedAt:        time.Now(),
+		AccountsProcessed: summary.Processed,
+		Errors:            summary.Failed,
+		MessagesSent:      summary.MessagesSent,
+	}
+
+	if err != nil {
+		logging.Errorf("Error while running job %s: %s", job, err.Error())
+		jobRun.Error = err.Error()
+	} else {
+		logRunSummary(job, summary)
+		if summary.Aborted {
+			jobRun.Error = fmt.Sprintf("aborted after %d errors", summary.Failed)
+		}
+	}
+
+	if _, err := app.DB.AddJobRun(jobRun); err != nil {
+		logging.Errorf("Error while storing run of job %s: %s", job, err.Error())
+	}
+}
+
+// Schedules an updater, every run is stored in the job run history. Returns an error if the cron expression is invalid
+func (app *App) addRecordedJob(job, cron string, run func() (workerpool.Summary, error)) error {
+	if err := app.Scheduler.AddJob(job, cron, func() {
+		app.runAndRecordJob(job, run)
+	}); err != nil {
+		return fmt.Errorf("scheduling job %s: %w", job, err)
+	}
+	return nil
+}
+
+// Returns the latest runs of a job, newest first. If job is empty, the runs of all jobs are returned
+func (app *App) GetJobRuns(job string, limit int) ([]models.JobRun, error) {
+	return app.DB.GetJobRuns(job, limit)
+}
@@ -1,168 +1,173 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/config"
-	"github.com/dattito/purrmannplus-backend/database"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
 	"github.com/dattito/purrmannplus-backend/services/moodle"
-	"github.com/dattito/purrmannplus-backend/services/scheduler"
-	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
 	"github.com/dattito/purrmannplus-backend/utils"
-	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
 )
 
-func moodleAssignmentsDifferenceAmount(mayNewAssignments, old_assignments []int) []int {
-	var difference []int
-	for _, new_assignment := range mayNewAssignments {
-		found := false
-		for _, old_assignment := range old_assignments {
-			if new_assignment == old_assignment {
-				found = true
-				break
-			}
-		}
-		if !found {
-			difference = append(difference, new_assignment)
-		}
+// Returns the assignments which are not in the old assignments and aren't submitted or graded yet
+func newMoodleAssignments(mayNewAssignments, oldAssignments []models.MoodleAssignment) []models.MoodleAssignment {
+	oldIds := make(map[int]bool)
+	for _, oldAssignment := range oldAssignments {
+		oldIds[oldAssignment.Id] = true
 	}
-	return difference
-}
 
-func moodleAssignmentsToTextMessage(newAssignments []int, assignmentIdToCourseNameMap map[int]string) string {
-	if len(newAssignments) == 0 {
-		return "Du hast keine neuen Moodle-Aufgaben"
-	}
-
-	var courseNamesThatHaveBeenNamed []string
-
-	var text string = "Du hast neue Moodle-Aufgaben in: \n"
-	for _, assignmentId := range newAssignments {
-		courseName := assignmentIdToCourseNameMap[assignmentId]
-		if !utils.Contains(courseNamesThatHaveBeenNamed, courseName) {
-			courseNamesThatHaveBeenNamed = append(courseNamesThatHaveBeenNamed, courseName)
-			text += fmt.Sprintf("%s\n", courseName)
+	var newAssignments []models.MoodleAssignment
+	for _, assignment := range mayNewAssignments {
+		if !oldIds[assignment.Id] && !assignment.IsDone() {
+			newAssignments = append(newAssignments, assignment)
 		}
 	}
-	return text
+	return newAssignments
 }
 
-// Returns error produced by user; error not produced by user
-func AddAccountToMoodleAssignmentUpdater(accountId string) (error, error) {
-	if _, err := database.DB.GetMoodleAssignments(accountId); err != nil {
-		if !errors.Is(err, &db_errors.ErrRecordNotFound) {
-			return nil, err
-		}
-	} else {
-		return errors.New("account is already in moodle assignment updater"), nil
+// Returns true if both lists contain the same assignments in the same order
+func moodleAssignmentsEqual(a, b []models.MoodleAssignment) bool {
+	if len(a) != len(b) {
+		return false
 	}
 
-	ai, err := database.DB.GetAccountInfo(accountId)
-	if err != nil {
-		if errors.Is(err, &db_errors.ErrRecordNotFound) {
-			return errors.New("phone number has to be added first"), nil
+	for i := range a {
+		if a[i].Id != b[i].Id || a[i].CourseId != b[i].CourseId || a[i].CourseName != b[i].CourseName ||
+			a[i].Name != b[i].Name || a[i].Intro != b[i].Intro ||
+			!a[i].DueDate.Equal(b[i].DueDate) || !a[i].CutoffDate.Equal(b[i].CutoffDate) ||
+			a[i].SubmissionStatus != b[i].SubmissionStatus {
+			return false
 		}
-		return nil, err
 	}
+	return true
+}
 
-	if ai.PhoneNumber == "" {
-		return errors.New("phone number has to be added first"), nil
+// Produces a human readable line from a single assignment
+func moodleAssignmentToText(a models.MoodleAssignment) string {
+	if a.DueDate.IsZero() {
+		return a.Name
 	}
+	return fmt.Sprintf("%s (fällig am %s Uhr)", a.Name, a.DueDate.Format("02.01.2006 15:04"))
+}
 
-	a, err := database.DB.GetAccount(accountId)
-	if err != nil {
-		return nil, err
+// Produces the lines of the assignments, grouped by their course
+func moodleAssignmentsByCourseToText(assignments []models.MoodleAssignment) string {
+	var courseNames []string
+	assignmentsOfCourse := make(map[string][]models.MoodleAssignment)
+	for _, assignment := range assignments {
+		if !utils.Contains(courseNames, assignment.CourseName) {
+			courseNames = append(courseNames, assignment.CourseName)
+		}
+		assignmentsOfCourse[assignment.CourseName] = append(assignmentsOfCourse[assignment.CourseName], assignment)
 	}
 
-	correct, err := moodle.CheckCredentials(a.Username, a.Password)
-	if err != nil {
-		return nil, err
+	var text string
+	for _, courseName := range courseNames {
+		text += fmt.Sprintf("\n%s:\n", courseName)
+		for _, assignment := range assignmentsOfCourse[courseName] {
+			text += fmt.Sprintf("%s\n", moodleAssignmentToText(assignment))
+		}
 	}
+	return text
+}
 
-	if !correct {
-		return errors.New("credentials are incorrect for moodle"), nil
+func moodleAssignmentsToTextMessage(newAssignments []models.MoodleAssignment) string {
+	if len(newAssignments) == 0 {
+		return "Du hast keine neuen Moodle-Aufgaben"
 	}
 
-	if err := database.DB.AddAccountToMoodleAssignmentUpdater(accountId); err != nil {
-		return nil, err
-	}
+	return "Du hast neue Moodle-Aufgaben: \n" + moodleAssignmentsByCourseToText(newAssignments)
+}
 
-	return nil, UpdateMoodleAssignmentsByAccountId(accountId)
+func moodleAssignmentUpdaterAccount(m models.MoodleAssignmentInfo) moodleUpdaterAccount {
+	return moodleUpdaterAccount{AuthId: m.AuthId, AuthPw: m.AuthPw, MoodleToken: m.MoodleToken, PhoneNumber: m.PhoneNumber, AccountId: m.AccountId, NotSetYet: m.NotSetYet}
 }
 
-func RemoveAccountFromMoodleAssignmentUpdater(accountId string) error {
-	return database.DB.RemoveAccountFromMoodleAssignmentUpdater(accountId)
+// Returns error produced by user; error not produced by user
+func (app *App) AddAccountToMoodleAssignmentUpdater(accountId string) (error, error) {
+	return app.addAccountToMoodleUpdater(accountId, "moodle assignment updater",
+		func() error {
+			_, err := app.DB.GetMoodleAssignments(accountId)
+			return err
+		},
+		func() error { return app.DB.AddAccountToMoodleAssignmentUpdater(accountId) },
+		func() error { return app.UpdateMoodleAssignmentsByAccountId(accountId) },
+	)
 }
 
-func UpdateMoodleAssignments(m models.MoodleAssignmentInfo) error {
-	logging.Debugf("Updating moodle assignments of account %s (id: %s)", m.AuthId, m.AccountId)
+func (app *App) RemoveAccountFromMoodleAssignmentUpdater(accountId string) error {
+	return app.DB.RemoveAccountFromMoodleAssignmentUpdater(accountId)
+}
 
-	rawAssignments, err := moodle.GetRawAssignmentsByCredentials(m.AuthId, m.AuthPw)
+// Returns the stored moodle assignments of an account; error produced by user; error not produced by user
+func (app *App) GetMoodleAssignments(accountId string) (models.MoodleAssignments, error, error) {
+	m, err := app.DB.GetMoodleAssignments(accountId)
 	if err != nil {
-		return err
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return models.MoodleAssignments{}, errors.New("account is not in moodle assignment updater"), nil
+		}
+		return models.MoodleAssignments{}, nil, err
 	}
-	mayNewAssignments := moodle.GetAssignmentIDs(rawAssignments)
-
-	old_assignments := m.AssignmentIds
-
-	newAssignments := moodleAssignmentsDifferenceAmount(mayNewAssignments, old_assignments)
 
-	// If there are no new assignments, we don't need to do anything
-	if len(newAssignments) == 0 {
-		return nil
-	}
+	return m, nil, nil
+}
 
-	if err = database.DB.SetMoodleAssignments(m.AccountId, mayNewAssignments, false); err != nil {
-		return err
-	}
+// Updates the moodle assignments for a given account and sends a notification, returns the number of sent messages
+func (app *App) UpdateMoodleAssignments(ctx context.Context, m models.MoodleAssignmentInfo) (int, error) {
+	var mayNewAssignments []models.MoodleAssignment
 
-	logging.Debugf("Successfully updated moodle assignments of %s", m.AuthId)
-	if m.NotSetYet || len(newAssignments) == 0 {
-		return nil
-	}
+	return app.updateMoodleSnapshot(ctx, moodleAssignmentUpdaterAccount(m), moodleSnapshotUpdate{
+		Name: "moodle assignments",
+		Fetch: func(ctx context.Context, token string) error {
+			rawAssignments, err := app.Moodle.GetRawAssignments(ctx, token)
+			if err != nil {
+				return err
+			}
 
-	// Send a message to the user if there are new assignments
-	return signal_message_sender.SignalMessageSender.Send(moodleAssignmentsToTextMessage(newAssignments, moodle.GetAssignmentIdToCourseNameMap(rawAssignments)), m.PhoneNumber)
+			mayNewAssignments = moodle.GetAssignments(rawAssignments)
+			return app.Moodle.AddSubmissionStatuses(ctx, token, mayNewAssignments, m.Assignments, time.Now())
+		},
+		Unchanged: func() bool { return moodleAssignmentsEqual(mayNewAssignments, m.Assignments) },
+		Store:     func() error { return app.DB.SetMoodleAssignments(m.AccountId, mayNewAssignments, false) },
+		Message: func() string {
+			newAssignments := newMoodleAssignments(mayNewAssignments, m.Assignments)
+			if len(newAssignments) == 0 {
+				return ""
+			}
+			return moodleAssignmentsToTextMessage(newAssignments)
+		},
+	})
 }
 
-func UpdateMoodleAssignmentsByAccountId(accountId string) error {
-	m, err := database.DB.GetMoodleAssignmentInfos(accountId)
+func (app *App) UpdateMoodleAssignmentsByAccountId(accountId string) error {
+	m, err := app.DB.GetMoodleAssignmentInfos(accountId)
 	if err != nil {
 		return err
 	}
 
-	return UpdateMoodleAssignments(m)
+	ctx, cancel := app.newUpdateContext()
+	defer cancel()
+
+	_, err = app.UpdateMoodleAssignments(ctx, m)
+	return err
 }
 
-func UpdateAllMoodleAssignments() error {
-	ms, err := database.DB.GetAllMoodleAssignmentInfos()
+// Updates the moodle assignments of all accounts using the worker pool and sends notifications
+func (app *App) UpdateAllMoodleAssignments() (workerpool.Summary, error) {
+	ms, err := app.DB.GetAllMoodleAssignmentInfos()
 	if err != nil {
-		return err
-	}
-
-	errCount := 0
-
-	for _, m := range ms {
-		err = UpdateMoodleAssignments(m)
-		if err != nil {
-			logging.Errorf("Error while updating moodle assignments of %s: %s", m.AuthId, err.Error())
-			errCount++
-			if errCount > config.MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS {
-				return errors.New("got too many errors while updating moodle assignments, stopping")
-			}
-		}
+		return workerpool.Summary{}, err
 	}
 
-	return nil
+	return app.updateAllMoodleAccounts("moodle assignments", app.Config.MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS, len(ms), func(i int) string { return ms[i].AccountId }, func(ctx context.Context, i int) (int, error) {
+		return app.UpdateMoodleAssignments(ctx, ms[i])
+	}), nil
 }
 
-func EnableMoodleAssignmentUpdater() {
-	scheduler.AddJob(config.MOODLE_UPDATECRON, func() {
-		if err := UpdateAllMoodleAssignments(); err != nil {
-			logging.Errorf("Error while updating moodle assignments: %s", err.Error())
-		}
-	})
+func (app *App) EnableMoodleAssignmentUpdater() error {
+	return app.addRecordedJob(JobMoodleAssignmentUpdater, app.Config.MOODLE_UPDATECRON, app.UpdateAllMoodleAssignments)
 }
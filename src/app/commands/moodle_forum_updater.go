@@ -0,0 +1,145 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Announcements longer than this are shortened in the messages
+const maxAnnouncementMessageLength = 500
+
+// Returns the announcements which are not known yet
+func newMoodleAnnouncements(announcements []models.MoodleAnnouncement, knownDiscussionIds []int) []models.MoodleAnnouncement {
+	known := make(map[int]bool)
+	for _, id := range knownDiscussionIds {
+		known[id] = true
+	}
+
+	var newAnnouncements []models.MoodleAnnouncement
+	for _, a := range announcements {
+		if !known[a.DiscussionId] {
+			newAnnouncements = append(newAnnouncements, a)
+		}
+	}
+	return newAnnouncements
+}
+
+// Returns true if both lists contain the same ids in the same order
+func discussionIdsEqual(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func moodleAnnouncementsToTextMessage(announcements []models.MoodleAnnouncement) string {
+	if len(announcements) == 0 {
+		return "Du hast keine neuen Moodle-Ankündigungen"
+	}
+
+	var text string = "Du hast neue Moodle-Ankündigungen: \n"
+	for _, a := range announcements {
+		message := a.Message
+		if utf8.RuneCountInString(message) > maxAnnouncementMessageLength {
+			message = string([]rune(message)[:maxAnnouncementMessageLength]) + "…"
+		}
+
+		text += fmt.Sprintf("\n%s: %s\n", a.CourseName, a.Subject)
+		if a.Author != "" {
+			text += fmt.Sprintf("von %s\n", a.Author)
+		}
+		text += fmt.Sprintf("%s\n", message)
+	}
+	return text
+}
+
+func moodleForumUpdaterAccount(m models.MoodleForumInfo) moodleUpdaterAccount {
+	return moodleUpdaterAccount{AuthId: m.AuthId, AuthPw: m.AuthPw, MoodleToken: m.MoodleToken, PhoneNumber: m.PhoneNumber, AccountId: m.AccountId, NotSetYet: m.NotSetYet}
+}
+
+// Returns error produced by user; error not produced by user
+func (app *App) AddAccountToMoodleForumUpdater(accountId string) (error, error) {
+	return app.addAccountToMoodleUpdater(accountId, "moodle forum updater",
+		func() error {
+			_, err := app.DB.GetMoodleForumInfos(accountId)
+			return err
+		},
+		func() error { return app.DB.AddAccountToMoodleForumUpdater(accountId) },
+		func() error { return app.UpdateMoodleForumsByAccountId(accountId) },
+	)
+}
+
+func (app *App) RemoveAccountFromMoodleForumUpdater(accountId string) error {
+	return app.DB.RemoveAccountFromMoodleForumUpdater(accountId)
+}
+
+// Checks the announcement forums for a given account and sends a notification, returns the number of sent messages
+func (app *App) UpdateMoodleForums(ctx context.Context, m models.MoodleForumInfo) (int, error) {
+	var announcements []models.MoodleAnnouncement
+	var discussionIds []int
+
+	return app.updateMoodleSnapshot(ctx, moodleForumUpdaterAccount(m), moodleSnapshotUpdate{
+		Name: "moodle forums",
+		Fetch: func(ctx context.Context, token string) error {
+			var err error
+			announcements, err = app.Moodle.GetAnnouncements(ctx, token)
+			if err != nil {
+				return err
+			}
+
+			discussionIds = nil
+			for _, a := range announcements {
+				discussionIds = append(discussionIds, a.DiscussionId)
+			}
+			return nil
+		},
+		Unchanged: func() bool { return discussionIdsEqual(discussionIds, m.DiscussionIds) },
+		Store:     func() error { return app.DB.SetMoodleDiscussions(m.AccountId, discussionIds, false) },
+		Message: func() string {
+			newAnnouncements := newMoodleAnnouncements(announcements, m.DiscussionIds)
+			if len(newAnnouncements) == 0 {
+				return ""
+			}
+			return moodleAnnouncementsToTextMessage(newAnnouncements)
+		},
+	})
+}
+
+func (app *App) UpdateMoodleForumsByAccountId(accountId string) error {
+	m, err := app.DB.GetMoodleForumInfos(accountId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := app.newUpdateContext()
+	defer cancel()
+
+	_, err = app.UpdateMoodleForums(ctx, m)
+	return err
+}
+
+// Checks the announcement forums of all accounts using the worker pool and sends notifications
+func (app *App) UpdateAllMoodleForums() (workerpool.Summary, error) {
+	ms, err := app.DB.GetAllMoodleForumInfos()
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	return app.updateAllMoodleAccounts("moodle forums", app.Config.MAX_ERROS_TO_STOP_UPDATING_MOODLE_FORUMS, len(ms), func(i int) string { return ms[i].AccountId }, func(ctx context.Context, i int) (int, error) {
+		return app.UpdateMoodleForums(ctx, ms[i])
+	}), nil
+}
+
+func (app *App) EnableMoodleForumUpdater() error {
+	return app.addRecordedJob(JobMoodleForumUpdater, app.Config.MOODLE_FORUM_UPDATECRON, app.UpdateAllMoodleForums)
+}
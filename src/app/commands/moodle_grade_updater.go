@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// A grade which was released or changed since the last snapshot
+type moodleGradeChange struct {
+	Grade    models.MoodleGrade
+	OldGrade string // Empty if the grade was newly released
+}
+
+// Returns the grades which were released or changed compared to the old snapshot
+func moodleGradeChanges(grades, oldGrades []models.MoodleGrade) []moodleGradeChange {
+	old := make(map[int]models.MoodleGrade)
+	for _, g := range oldGrades {
+		old[g.ItemId] = g
+	}
+
+	var changes []moodleGradeChange
+	for _, g := range grades {
+		oldGrade, ok := old[g.ItemId]
+		if !ok {
+			changes = append(changes, moodleGradeChange{Grade: g})
+		} else if oldGrade.Grade != g.Grade {
+			changes = append(changes, moodleGradeChange{Grade: g, OldGrade: oldGrade.Grade})
+		}
+	}
+	return changes
+}
+
+// Returns true if both snapshots contain the same grades in the same order
+func moodleGradesEqual(a, b []models.MoodleGrade) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func moodleGradeChangesToTextMessage(changes []moodleGradeChange) string {
+	if len(changes) == 0 {
+		return "Du hast keine neuen Moodle-Noten"
+	}
+
+	var text string = "Du hast neue Moodle-Noten: \n\n"
+	for _, c := range changes {
+		if c.OldGrade == "" {
+			text += fmt.Sprintf("%s: %s: %s\n", c.Grade.CourseName, c.Grade.ItemName, c.Grade.Grade)
+		} else {
+			text += fmt.Sprintf("Geändert: %s: %s: %s (vorher %s)\n", c.Grade.CourseName, c.Grade.ItemName, c.Grade.Grade, c.OldGrade)
+		}
+	}
+	return text
+}
+
+func moodleGradeUpdaterAccount(m models.MoodleGradeInfo) moodleUpdaterAccount {
+	return moodleUpdaterAccount{AuthId: m.AuthId, AuthPw: m.AuthPw, MoodleToken: m.MoodleToken, PhoneNumber: m.PhoneNumber, AccountId: m.AccountId, NotSetYet: m.NotSetYet}
+}
+
+// Returns error produced by user; error not produced by user
+func (app *App) AddAccountToMoodleGradeUpdater(accountId string) (error, error) {
+	return app.addAccountToMoodleUpdater(accountId, "moodle grade updater",
+		func() error {
+			_, err := app.DB.GetMoodleGradeInfos(accountId)
+			return err
+		},
+		func() error { return app.DB.AddAccountToMoodleGradeUpdater(accountId) },
+		func() error { return app.UpdateMoodleGradesByAccountId(accountId) },
+	)
+}
+
+func (app *App) RemoveAccountFromMoodleGradeUpdater(accountId string) error {
+	return app.DB.RemoveAccountFromMoodleGradeUpdater(accountId)
+}
+
+// Checks the grades of a given account and sends a notification, returns the number of sent messages
+func (app *App) UpdateMoodleGrades(ctx context.Context, m models.MoodleGradeInfo) (int, error) {
+	var grades []models.MoodleGrade
+
+	return app.updateMoodleSnapshot(ctx, moodleGradeUpdaterAccount(m), moodleSnapshotUpdate{
+		Name: "moodle grades",
+		Fetch: func(ctx context.Context, token string) error {
+			var err error
+			grades, err = app.Moodle.GetGrades(ctx, token)
+			return err
+		},
+		Unchanged: func() bool { return moodleGradesEqual(grades, m.Grades) },
+		Store:     func() error { return app.DB.SetMoodleGrades(m.AccountId, grades, false) },
+		Message: func() string {
+			changes := moodleGradeChanges(grades, m.Grades)
+			if len(changes) == 0 {
+				return ""
+			}
+			return moodleGradeChangesToTextMessage(changes)
+		},
+	})
+}
+
+func (app *App) UpdateMoodleGradesByAccountId(accountId string) error {
+	m, err := app.DB.GetMoodleGradeInfos(accountId)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := app.newUpdateContext()
+	defer cancel()
+
+	_, err = app.UpdateMoodleGrades(ctx, m)
+	return err
+}
+
+// Checks the grades of all accounts using the worker pool and sends notifications
+func (app *App) UpdateAllMoodleGrades() (workerpool.Summary, error) {
+	ms, err := app.DB.GetAllMoodleGradeInfos()
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	return app.updateAllMoodleAccounts("moodle grades", app.Config.MAX_ERROS_TO_STOP_UPDATING_MOODLE_GRADES, len(ms), func(i int) string { return ms[i].AccountId }, func(ctx context.Context, i int) (int, error) {
+		return app.UpdateMoodleGrades(ctx, ms[i])
+	}), nil
+}
+
+func (app *App) EnableMoodleGradeUpdater() error {
+	return app.addRecordedJob(JobMoodleGradeUpdater, app.Config.MOODLE_GRADE_UPDATECRON, app.UpdateAllMoodleGrades)
+}
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/utils"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Returns the assignments a reminder has to be sent for and the reminders which have to be stored.
+// Only one reminder is sent per assignment, even if several offsets were reached since the last run.
+func dueMoodleReminders(accountId string, assignments []models.MoodleAssignment, sentReminders []models.MoodleReminder,
+	disabledCourses map[int]bool, offsets []time.Duration, now time.Time) ([]models.MoodleAssignment, []models.MoodleReminder) {
+
+	sent := make(map[string]bool)
+	for _, r := range sentReminders {
+		sent[fmt.Sprintf("%d/%d", r.AssignmentId, r.Offset)] = true
+	}
+
+	var dueAssignments []models.MoodleAssignment
+	var reminders []models.MoodleReminder
+	for _, assignment := range assignments {
+		if assignment.DueDate.IsZero() || !assignment.DueDate.After(now) || disabledCourses[assignment.CourseId] || assignment.IsDone() {
+			continue
+		}
+
+		due := false
+		for _, offset := range offsets {
+			if now.Before(assignment.DueDate.Add(-offset)) || sent[fmt.Sprintf("%d/%d", assignment.Id, offset)] {
+				continue
+			}
+
+			due = true
+			reminders = append(reminders, models.MoodleReminder{
+				AccountId:    accountId,
+				AssignmentId: assignment.Id,
+				Offset:       offset,
+				SentAt:       now,
+			})
+		}
+
+		if due {
+			dueAssignments = append(dueAssignments, assignment)
+		}
+	}
+
+	return dueAssignments, reminders
+}
+
+func moodleRemindersToTextMessage(assignments []models.MoodleAssignment) string {
+	return "Erinnerung: Diese Moodle-Aufgaben sind bald fällig: \n" + moodleAssignmentsByCourseToText(assignments)
+}
+
+// Sends the reminders which are due for an account, returns the number of sent messages
+func (app *App) SendMoodleReminders(m models.MoodleAssignmentInfo, offsets []time.Duration, now time.Time) (int, error) {
+	sentReminders, err := app.DB.GetMoodleReminders(m.AccountId)
+	if err != nil {
+		return 0, err
+	}
+
+	settings, err := app.DB.GetMoodleCourseSettings(m.AccountId)
+	if err != nil {
+		return 0, err
+	}
+
+	disabledCourses := make(map[int]bool)
+	for _, setting := range settings {
+		disabledCourses[setting.CourseId] = !setting.RemindersEnabled
+	}
+
+	dueAssignments, reminders := dueMoodleReminders(m.AccountId, m.Assignments, sentReminders, disabledCourses, offsets, now)
+	if len(dueAssignments) == 0 {
+		return 0, nil
+	}
+
+	// The reminders are stored before sending, so that a failing channel doesn't lead to the same reminder every run
+	if err := app.DB.AddMoodleReminders(reminders); err != nil {
+		return 0, err
+	}
+
+	if err := app.SendNotification(m.AccountId, m.PhoneNumber, moodleRemindersToTextMessage(dueAssignments)); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// Sends the due reminders of all accounts in the moodle assignment updater
+func (app *App) SendAllMoodleReminders() (workerpool.Summary, error) {
+	offsets, err := utils.ParseDurations(app.Config.MOODLE_REMINDER_OFFSETS)
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	ms, err := app.DB.GetAllMoodleAssignmentInfos()
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	now := time.Now()
+	pool := app.newUpdaterPool(app.Config.MAX_ERROS_TO_STOP_SENDING_MOODLE_REMINDERS)
+
+	return pool.Run(len(ms), func(ctx context.Context, i int) workerpool.Result {
+		messagesSent, err := app.SendMoodleReminders(ms[i], offsets, now)
+		if err != nil {
+			logging.Errorf("Error while sending moodle reminders to %s: %s", ms[i].AuthId, err.Error())
+			err = fmt.Errorf("account %s: %w", ms[i].AccountId, err)
+		}
+
+		return workerpool.Result{MessagesSent: messagesSent, Err: err}
+	}), nil
+}
+
+// Activates the scheduler to send the moodle reminders, does nothing if no offsets are configured
+func (app *App) EnableMoodleReminders() error {
+	if offsets, _ := utils.ParseDurations(app.Config.MOODLE_REMINDER_OFFSETS); len(offsets) == 0 {
+		return nil
+	}
+
+	return app.addRecordedJob(JobMoodleReminder, app.Config.MOODLE_REMINDER_CRON, app.SendAllMoodleReminders)
+}
+
+// Returns the settings of all courses of the account; error produced by user; error not produced by user
+func (app *App) GetMoodleCourseSettings(accountId string) ([]models.MoodleCourseSetting, error, error) {
+	m, err := app.DB.GetMoodleAssignments(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return nil, errors.New("account is not in moodle assignment updater"), nil
+		}
+		return nil, nil, err
+	}
+
+	storedSettings, err := app.DB.GetMoodleCourseSettings(accountId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Courses without a stored setting have the reminders enabled
+	settings := []models.MoodleCourseSetting{}
+	indexOfCourse := make(map[int]int)
+	for _, assignment := range m.Assignments {
+		if _, ok := indexOfCourse[assignment.CourseId]; ok || assignment.CourseId == 0 {
+			continue
+		}
+		indexOfCourse[assignment.CourseId] = len(settings)
+		settings = append(settings, models.MoodleCourseSetting{
+			AccountId:        accountId,
+			CourseId:         assignment.CourseId,
+			CourseName:       assignment.CourseName,
+			RemindersEnabled: true,
+		})
+	}
+
+	for _, s := range storedSettings {
+		if i, ok := indexOfCourse[s.CourseId]; ok {
+			settings[i].RemindersEnabled = s.RemindersEnabled
+		} else {
+			settings = append(settings, s)
+		}
+	}
+
+	return settings, nil, nil
+}
+
+// Enables or disables the reminders of a course; error produced by user; error not produced by user
+func (app *App) SetMoodleCourseSetting(accountId string, courseId int, remindersEnabled bool) (models.MoodleCourseSetting, error, error) {
+	if courseId <= 0 {
+		return models.MoodleCourseSetting{}, errors.New("invalid course id"), nil
+	}
+
+	if _, err := app.DB.GetMoodleAssignments(accountId); err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return models.MoodleCourseSetting{}, errors.New("account is not in moodle assignment updater"), nil
+		}
+		return models.MoodleCourseSetting{}, nil, err
+	}
+
+	s, err := app.DB.SetMoodleCourseSetting(accountId, courseId, remindersEnabled)
+	if err != nil {
+		return models.MoodleCourseSetting{}, nil, err
+	}
+
+	return s, nil, nil
+}
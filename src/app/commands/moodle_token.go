@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/dattito/purrmannplus-backend/services/moodle"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+// Calls moodle with the cached token of the account. Only if there is no cached token or moodle
+// rejects it, a new token is requested with the credentials and stored for the next calls.
+func (app *App) withMoodleToken(ctx context.Context, accountId, username, password, cachedToken string, call func(token string) error) error {
+	if cachedToken != "" {
+		err := call(cachedToken)
+		if !errors.Is(err, moodle.ErrInvalidToken) {
+			return err
+		}
+		logging.Debugf("Cached moodle token of %s is invalid, logging in again", username)
+	}
+
+	token, err := app.Moodle.GetToken(ctx, username, password)
+	if err != nil {
+		return err
+	}
+
+	if token == "" {
+		return errors.New("moodle credentials are incorrect")
+	}
+
+	if err := app.DB.SetAccountMoodleToken(accountId, token); err != nil {
+		return err
+	}
+
+	return call(token)
+}
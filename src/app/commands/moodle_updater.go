@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// An account in one of the moodle updaters
+type moodleUpdaterAccount struct {
+	AuthId      string
+	AuthPw      string
+	MoodleToken string
+	PhoneNumber string
+	AccountId   string
+	NotSetYet   bool
+}
+
+// The steps of a moodle updater, which fetches a snapshot of an account from moodle and notifies about the changes
+type moodleSnapshotUpdate struct {
+	Name      string                                        // e.g. "moodle grades", used in the logs
+	Fetch     func(ctx context.Context, token string) error // Fetches the current snapshot with the token
+	Unchanged func() bool                                   // Returns true if the fetched snapshot equals the stored one
+	Store     func() error                                  // Stores the fetched snapshot
+	Message   func() string                                 // Returns the message about the changes, empty if there is nothing to send
+}
+
+// Adds an account to a moodle updater after checking the moodle credentials and runs the first update.
+// Returns error produced by user; error not produced by user
+func (app *App) addAccountToMoodleUpdater(accountId, updaterName string, getInfos func() error, add func() error, update func() error) (error, error) {
+	if err := getInfos(); err != nil {
+		if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return nil, err
+		}
+	} else {
+		return fmt.Errorf("account is already in %s", updaterName), nil
+	}
+
+	hasChannel, err := app.HasNotificationChannel(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hasChannel {
+		return errors.New("phone number or notification channel has to be added first"), nil
+	}
+
+	a, err := app.DB.GetAccount(accountId)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := app.Moodle.GetToken(context.Background(), a.Username, a.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == "" {
+		return errors.New("credentials are incorrect for moodle"), nil
+	}
+
+	if err := app.DB.SetAccountMoodleToken(accountId, token); err != nil {
+		return nil, err
+	}
+
+	if err := add(); err != nil {
+		return nil, err
+	}
+
+	return nil, update()
+}
+
+// Fetches the snapshot of an account, stores it if it changed and notifies about the changes.
+// Nothing is sent on the first update, which only stores the snapshot. Returns the number of sent messages
+func (app *App) updateMoodleSnapshot(ctx context.Context, a moodleUpdaterAccount, u moodleSnapshotUpdate) (int, error) {
+	logging.Debugf("Updating %s of account %s (id: %s)", u.Name, a.AuthId, a.AccountId)
+
+	err := app.withMoodleToken(ctx, a.AccountId, a.AuthId, a.AuthPw, a.MoodleToken, func(token string) error {
+		return u.Fetch(ctx, token)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// If nothing changed, we don't need to do anything
+	if u.Unchanged() && !a.NotSetYet {
+		return 0, nil
+	}
+
+	if err := u.Store(); err != nil {
+		return 0, err
+	}
+
+	logging.Debugf("Successfully updated %s of %s", u.Name, a.AuthId)
+
+	if a.NotSetYet {
+		return 0, nil
+	}
+
+	message := u.Message()
+	if message == "" {
+		return 0, nil
+	}
+
+	if err := app.SendNotification(a.AccountId, a.PhoneNumber, message); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
+}
+
+// Updates the given number of accounts of a moodle updater using the worker pool,
+// accountId returns the id of the i-th account for the logs and errors
+func (app *App) updateAllMoodleAccounts(name string, maxErrors, count int, accountId func(i int) string, update func(ctx context.Context, i int) (int, error)) workerpool.Summary {
+	pool := app.newUpdaterPool(maxErrors)
+
+	return pool.Run(count, func(ctx context.Context, i int) workerpool.Result {
+		messagesSent, err := update(ctx, i)
+		if err != nil {
+			logging.Errorf("Error while updating %s of account %s: %s", name, accountId(i), err.Error())
+			err = fmt.Errorf("account %s: %w", accountId(i), err)
+		}
+
+		return workerpool.Result{MessagesSent: messagesSent, Err: err}
+	})
+}
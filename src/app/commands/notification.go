@@ -0,0 +1,159 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/services/notifier"
+	"github.com/dattito/purrmannplus-backend/utils"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+// Sends the message to the account, unless its notifications are paused.
+// During the quiet hours or in the digest mode, the message is queued in the outbox and delivered later.
+func (app *App) SendNotification(accountId, phoneNumber, message string) error {
+	preference, err := app.DB.GetNotificationPreference(accountId)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if preference.IsPaused(now) {
+		logging.Debugf("Notifications of account %s are paused, message is dropped", accountId)
+		return nil
+	}
+
+	if preference.InQuietHours(now) || (preference.Digest && app.digestEnabled()) {
+		logging.Debugf("Queueing notification of account %s in the outbox", accountId)
+		return app.DB.AddOutboxMessage(accountId, phoneNumber, message)
+	}
+
+	_, err = app.deliverNotification(accountId, phoneNumber, message)
+	return err
+}
+
+// Sends the message through all notification channels of the account, returns the number of channels it was delivered to.
+// If the account has no channels set, the message is sent via signal to the phone number.
+func (app *App) deliverNotification(accountId, phoneNumber, message string) (int, error) {
+	settings, err := app.DB.GetNotificationSettings(accountId)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(settings) == 0 {
+		if phoneNumber == "" {
+			logging.Warningf("Account %s has no notification channel, message is dropped", accountId)
+			return 0, nil
+		}
+		settings = []models.NotificationSetting{{AccountId: accountId, Channel: notifier.ChannelSignal, Recipient: phoneNumber}}
+	}
+
+	delivered := 0
+	var errs []string
+	for _, setting := range settings {
+		n, err := app.Notifiers.Get(setting.Channel)
+		if err == nil {
+			err = n.Send(message, setting.Recipient)
+		}
+
+		if err == nil {
+			delivered++
+		} else {
+			errs = append(errs, fmt.Sprintf("%s: %s", setting.Channel, err.Error()))
+
+			if err := app.DB.AddSendFailure(accountId, setting.Channel, err.Error()); err != nil {
+				logging.Errorf("Error while storing send failure: %s", err.Error())
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return delivered, fmt.Errorf("error while sending notification: %s", strings.Join(errs, "; "))
+	}
+
+	return delivered, nil
+}
+
+// Returns true if the account has a way to receive notifications (a phone number or a notification channel)
+func (app *App) HasNotificationChannel(accountId string) (bool, error) {
+	settings, err := app.DB.GetNotificationSettings(accountId)
+	if err != nil {
+		return false, err
+	}
+
+	if len(settings) > 0 {
+		return true, nil
+	}
+
+	return app.HasPhoneNumber(accountId)
+}
+
+func (app *App) GetNotificationSettings(accountId string) ([]models.NotificationSetting, error) {
+	return app.DB.GetNotificationSettings(accountId)
+}
+
+// Sets a notification channel of an account. For signal, the verified phone number is used as recipient.
+// Returns error produced by user; error not produced by user
+func (app *App) SetNotificationSetting(accountId, channel, recipient string) (models.NotificationSetting, error, error) {
+	if channel == notifier.ChannelSignal {
+		ai, err := app.DB.GetAccountInfo(accountId)
+		if err != nil {
+			if errors.Is(err, &db_errors.ErrRecordNotFound) {
+				return models.NotificationSetting{}, errors.New("phone number has to be added first"), nil
+			}
+			return models.NotificationSetting{}, nil, err
+		}
+		recipient = ai.PhoneNumber
+	}
+
+	if err := app.Notifiers.ValidateRecipient(channel, recipient); err != nil {
+		return models.NotificationSetting{}, err, nil
+	}
+
+	n, err := app.DB.SetNotificationSetting(accountId, channel, recipient)
+	if err != nil {
+		return models.NotificationSetting{}, nil, err
+	}
+
+	return n, nil, nil
+}
+
+func (app *App) RemoveNotificationSetting(accountId, channel string) error {
+	return app.DB.RemoveNotificationSetting(accountId, channel)
+}
+
+func (app *App) GetNotificationPreference(accountId string) (models.NotificationPreference, error) {
+	return app.DB.GetNotificationPreference(accountId)
+}
+
+// Validates and stores the notification preference of an account.
+// Returns error produced by user; error not produced by user
+func (app *App) SetNotificationPreference(p models.NotificationPreference) (models.NotificationPreference, error, error) {
+	if !p.PausedUntil.IsZero() {
+		if !p.PausedUntil.After(time.Now()) {
+			return models.NotificationPreference{}, errors.New("paused_until has to be in the future"), nil
+		}
+		p.Paused = true
+	}
+
+	if (p.QuietHoursStart == "") != (p.QuietHoursEnd == "") {
+		return models.NotificationPreference{}, errors.New("quiet_hours_start and quiet_hours_end have to be set both or none"), nil
+	}
+
+	for _, clock := range []string{p.QuietHoursStart, p.QuietHoursEnd} {
+		if _, err := utils.ParseClock(clock); clock != "" && err != nil {
+			return models.NotificationPreference{}, errors.New("quiet hours have to be in the format HH:MM"), nil
+		}
+	}
+
+	p, err := app.DB.SetNotificationPreference(p)
+	if err != nil {
+		return models.NotificationPreference{}, nil, err
+	}
+
+	return p, nil, nil
+}
@@ -0,0 +1,284 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils"
+)
+
+// Returns quiet hours which contain the current time
+func currentQuietHours() (string, string) {
+	now := time.Now()
+	return now.Add(-time.Hour).Format("15:04"), now.Add(time.Hour).Format("15:04")
+}
+
+func TestSendNotificationDelivers(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.SendNotification(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := n.sent(); len(sent) != 1 || sent[0] != "Hallo" {
+		t.Errorf("sent messages = %v, want [Hallo]", sent)
+	}
+}
+
+func TestSendNotificationDropsWhenPaused(t *testing.T) {
+	for name, p := range map[string]models.NotificationPreference{
+		"paused":       {Paused: true},
+		"paused until": {Paused: true, PausedUntil: time.Now().Add(time.Hour)},
+		"opted out":    {OptedOut: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			app, n := setupTest(t)
+			a := createTestAccount(t, app, "alice")
+
+			p.AccountId = a.Id
+			if _, err := app.DB.SetNotificationPreference(p); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := app.SendNotification(a.Id, "+4915112345678", "Hallo"); err != nil {
+				t.Fatal(err)
+			}
+
+			if sent := n.sent(); len(sent) != 0 {
+				t.Errorf("sent messages = %v, want none", sent)
+			}
+
+			if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 0 {
+				t.Errorf("got %d queued messages, want none", len(messages))
+			}
+		})
+	}
+}
+
+func TestSendNotificationAfterPauseEnded(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	p := models.NotificationPreference{AccountId: a.Id, Paused: true, PausedUntil: time.Now().Add(-time.Minute)}
+	if _, err := app.DB.SetNotificationPreference(p); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.SendNotification(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := n.sent(); len(sent) != 1 {
+		t.Errorf("sent messages = %v, want one", sent)
+	}
+}
+
+func TestSendNotificationQueuesInQuietHours(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	start, end := currentQuietHours()
+	p := models.NotificationPreference{AccountId: a.Id, QuietHoursStart: start, QuietHoursEnd: end}
+	if _, err := app.DB.SetNotificationPreference(p); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, message := range []string{"Erste", "Zweite"} {
+		if err := app.SendNotification(a.Id, "+4915112345678", message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if sent := n.sent(); len(sent) != 0 {
+		t.Errorf("sent messages = %v, want none", sent)
+	}
+
+	messages, err := app.DB.GetOutboxMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 2 || messages[0].Message != "Erste" || messages[1].Message != "Zweite" {
+		t.Fatalf("queued messages = %v, want Erste and Zweite", messages)
+	}
+
+	// Still in the quiet hours, so nothing may be delivered
+	if _, err := app.DeliverAllOutboxMessages(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := n.sent(); len(sent) != 0 {
+		t.Errorf("sent messages = %v, want none", sent)
+	}
+
+	// After the quiet hours, both messages are merged into one
+	p.QuietHoursStart, p.QuietHoursEnd = "", ""
+	if _, err := app.DB.SetNotificationPreference(p); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := app.DeliverAllOutboxMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.MessagesSent != 1 {
+		t.Errorf("MessagesSent = %d, want 1", summary.MessagesSent)
+	}
+
+	if sent := n.sent(); len(sent) != 1 || sent[0] != outboxMessagesToTextMessage(messages) {
+		t.Errorf("sent messages = %v, want the merged message", sent)
+	}
+
+	if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 0 {
+		t.Errorf("got %d queued messages after delivery, want none", len(messages))
+	}
+}
+
+func TestOutboxDropsMessagesWhenPaused(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.AddOutboxMessage(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.DB.SetNotificationPreference(models.NotificationPreference{AccountId: a.Id, OptedOut: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := app.DeliverAllOutboxMessages(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := n.sent(); len(sent) != 0 {
+		t.Errorf("sent messages = %v, want none", sent)
+	}
+
+	if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 0 {
+		t.Errorf("got %d queued messages, want none", len(messages))
+	}
+}
+
+func TestOutboxKeepsMessagesWhenSendingFails(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.AddOutboxMessage(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	n.setFailing(true)
+
+	summary, err := app.DeliverAllOutboxMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.Failed != 1 || summary.MessagesSent != 0 {
+		t.Errorf("summary during the outage = %+v, want one failed account", summary)
+	}
+
+	if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 1 {
+		t.Fatalf("got %d queued messages after the failed delivery, want 1", len(messages))
+	}
+
+	n.setFailing(false)
+
+	summary, err = app.DeliverAllOutboxMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if summary.MessagesSent != 1 {
+		t.Errorf("MessagesSent after the outage = %d, want 1", summary.MessagesSent)
+	}
+
+	if sent := n.sent(); len(sent) != 1 || sent[0] != "Hallo" {
+		t.Errorf("sent messages = %v, want Hallo", sent)
+	}
+
+	if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 0 {
+		t.Errorf("got %d queued messages after the delivery, want none", len(messages))
+	}
+}
+
+func TestSendNotificationDigest(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if _, err := app.DB.SetNotificationPreference(models.NotificationPreference{AccountId: a.Id, Digest: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.SendNotification(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if sent := n.sent(); len(sent) != 0 {
+		t.Errorf("sent messages = %v, want none", sent)
+	}
+
+	messages, err := app.DB.GetOutboxMessages()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(messages) != 1 {
+		t.Fatalf("got %d queued messages, want 1", len(messages))
+	}
+
+	clocks, _ := utils.ParseClocks(app.Config.DIGEST_TIMES)
+	p, _ := app.DB.GetNotificationPreference(a.Id)
+	created := messages[0].CreatedAt
+
+	if outboxDeliverable(p, created, created.Add(time.Minute), []time.Duration{utils.SinceMidnight(created) + 2*time.Minute}) {
+		t.Error("digest is deliverable before the next digest time")
+	}
+
+	if !outboxDeliverable(p, created, created.Add(24*time.Hour), clocks) {
+		t.Error("digest isn't deliverable a day later")
+	}
+}
+
+func TestSetNotificationPreferenceValidation(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	for name, p := range map[string]models.NotificationPreference{
+		"pause in the past":      {PausedUntil: time.Now().Add(-time.Hour)},
+		"only quiet hours start": {QuietHoursStart: "22:00"},
+		"invalid quiet hours":    {QuietHoursStart: "22 Uhr", QuietHoursEnd: "06:00"},
+	} {
+		p.AccountId = a.Id
+		if _, user_err, db_err := app.SetNotificationPreference(p); user_err == nil || db_err != nil {
+			t.Errorf("%s: got user_err = %v, db_err = %v, want a user error", name, user_err, db_err)
+		}
+	}
+
+	pausedUntil := time.Now().Add(24 * time.Hour)
+	p, user_err, db_err := app.SetNotificationPreference(models.NotificationPreference{
+		AccountId:       a.Id,
+		PausedUntil:     pausedUntil,
+		QuietHoursStart: "22:00",
+		QuietHoursEnd:   "06:30",
+	})
+	if user_err != nil || db_err != nil {
+		t.Fatalf("got user_err = %v, db_err = %v", user_err, db_err)
+	}
+
+	if !p.Paused {
+		t.Error("setting paused_until didn't pause the notifications")
+	}
+
+	stored, err := app.GetNotificationPreference(a.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !stored.PausedUntil.Equal(pausedUntil) || stored.QuietHoursStart != "22:00" || stored.QuietHoursEnd != "06:30" {
+		t.Errorf("stored preference = %+v", stored)
+	}
+}
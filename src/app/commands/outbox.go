@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Returns true if digest times are configured, otherwise the digest mode is ignored
+func (app *App) digestEnabled() bool {
+	clocks, _ := utils.ParseClocks(app.Config.DIGEST_TIMES)
+	return len(clocks) > 0
+}
+
+// Returns the latest digest time which is not after now, zero if there are no digest times
+func lastDigestTime(now time.Time, clocks []time.Duration) time.Time {
+	var last time.Time
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	for _, day := range []time.Time{midnight.AddDate(0, 0, -1), midnight} {
+		for _, clock := range clocks {
+			t := day.Add(clock)
+			if !t.After(now) && t.After(last) {
+				last = t
+			}
+		}
+	}
+	return last
+}
+
+// Returns true if the queued messages of an account may be delivered now.
+// In the digest mode, they are delivered after the first digest time since the oldest message was queued.
+func outboxDeliverable(p models.NotificationPreference, oldest, now time.Time, digestClocks []time.Duration) bool {
+	if p.InQuietHours(now) {
+		return false
+	}
+
+	if !p.Digest || len(digestClocks) == 0 {
+		return true
+	}
+
+	return lastDigestTime(now, digestClocks).After(oldest)
+}
+
+// Merges the queued messages into a single message
+func outboxMessagesToTextMessage(messages []models.OutboxMessage) string {
+	if len(messages) == 1 {
+		return messages[0].Message
+	}
+
+	texts := make([]string, len(messages))
+	for i, message := range messages {
+		texts[i] = message.Message
+	}
+
+	return fmt.Sprintf("Du hast %d neue Benachrichtigungen: \n\n%s", len(messages), strings.Join(texts, "\n\n"))
+}
+
+// Delivers the queued messages of an account if allowed, returns the number of sent messages
+func (app *App) deliverOutboxMessages(messages []models.OutboxMessage, now time.Time, digestClocks []time.Duration) (int, error) {
+	accountId := messages[0].AccountId
+
+	p, err := app.DB.GetNotificationPreference(accountId)
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]string, len(messages))
+	for i, message := range messages {
+		ids[i] = message.Id
+	}
+
+	// Messages queued before the notifications were paused are dropped like all others
+	if p.IsPaused(now) {
+		return 0, app.DB.RemoveOutboxMessages(ids)
+	}
+
+	if !outboxDeliverable(p, messages[0].CreatedAt, now, digestClocks) {
+		return 0, nil
+	}
+
+	phoneNumber := messages[len(messages)-1].PhoneNumber
+	delivered, sendErr := app.deliverNotification(accountId, phoneNumber, outboxMessagesToTextMessage(messages))
+
+	// If no channel could deliver the messages, they are kept and sent again in the next run.
+	// Otherwise they are removed, so that the working channels don't get the same messages again.
+	if delivered == 0 && sendErr != nil {
+		return 0, sendErr
+	}
+
+	if err := app.DB.RemoveOutboxMessages(ids); err != nil {
+		return 0, err
+	}
+
+	if delivered == 0 {
+		return 0, nil
+	}
+
+	return 1, sendErr
+}
+
+// Delivers the queued messages of all accounts which may receive notifications now
+func (app *App) DeliverAllOutboxMessages() (workerpool.Summary, error) {
+	digestClocks, err := utils.ParseClocks(app.Config.DIGEST_TIMES)
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	messages, err := app.DB.GetOutboxMessages()
+	if err != nil {
+		return workerpool.Summary{}, err
+	}
+
+	// Messages are ordered by their creation, so the messages of every account stay in order
+	var accountIds []string
+	messagesOfAccount := make(map[string][]models.OutboxMessage)
+	for _, message := range messages {
+		if _, ok := messagesOfAccount[message.AccountId]; !ok {
+			accountIds = append(accountIds, message.AccountId)
+		}
+		messagesOfAccount[message.AccountId] = append(messagesOfAccount[message.AccountId], message)
+	}
+
+	now := time.Now()
+	pool := app.newUpdaterPool(workerpool.NoErrorLimit)
+
+	return pool.Run(len(accountIds), func(ctx context.Context, i int) workerpool.Result {
+		messagesSent, err := app.deliverOutboxMessages(messagesOfAccount[accountIds[i]], now, digestClocks)
+		if err != nil {
+			logging.Errorf("Error while delivering queued notifications of account %s: %s", accountIds[i], err.Error())
+			err = fmt.Errorf("account %s: %w", accountIds[i], err)
+		}
+
+		return workerpool.Result{MessagesSent: messagesSent, Err: err}
+	}), nil
+}
+
+// Activates the scheduler to deliver the notifications held back by quiet hours or the digest mode
+func (app *App) EnableNotificationOutbox() error {
+	return app.addRecordedJob(JobNotificationOutbox, app.Config.NOTIFICATION_OUTBOX_CRON, app.DeliverAllOutboxMessages)
+}
@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
+	"github.com/dattito/purrmannplus-backend/services/substitutions"
+	"github.com/dattito/purrmannplus-backend/utils"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+const signalBotHelpText = `Verfügbare Befehle:
+plan - Zeigt deine aktuellen Vertretungen
+aufgaben - Zeigt deine offenen Moodle-Aufgaben
+pause - Pausiert alle Benachrichtigungen
+pause TT.MM.JJJJ - Pausiert alle Benachrichtigungen bis zu diesem Tag, z.B. für die Ferien
+stop - Meldet dich von allen Benachrichtigungen ab
+start - Setzt die Benachrichtigungen fort
+hilfe - Zeigt diese Hilfe`
+
+// Produces the text of the stored substitutions which aren't over yet
+func substitutionPlanToText(s models.Substitutions, now time.Time) string {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	var text string
+	day := ""
+	for _, entry := range s.Entries {
+		if date, err := substitutions.ParseDate(entry.Date, now); err == nil && date.Before(today) {
+			continue
+		}
+
+		if text == "" || entry.Date != day {
+			day = entry.Date
+			text += fmt.Sprintf("\n%s:\n", day)
+		}
+		text += fmt.Sprintf("%s\n", substitutionToText(entry))
+	}
+
+	if text == "" {
+		return "Du hast keine Vertretungen"
+	}
+	return "Deine Vertretungen: \n" + text
+}
+
+// Produces the text of the stored assignments which aren't done and aren't over yet
+func openMoodleAssignmentsToText(assignments []models.MoodleAssignment, now time.Time) string {
+	var open []models.MoodleAssignment
+	for _, assignment := range assignments {
+		if assignment.IsDone() || (!assignment.DueDate.IsZero() && assignment.DueDate.Before(now)) {
+			continue
+		}
+		open = append(open, assignment)
+	}
+
+	if len(open) == 0 {
+		return "Du hast keine offenen Moodle-Aufgaben"
+	}
+	return "Deine offenen Moodle-Aufgaben: \n" + moodleAssignmentsByCourseToText(open)
+}
+
+func (app *App) signalPlanCommand(accountId string) (string, error) {
+	s, err := app.DB.GetSubstitutions(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return "Du bist nicht für den Vertretungsplan angemeldet", nil
+		}
+		return "", err
+	}
+
+	return substitutionPlanToText(s, time.Now()), nil
+}
+
+func (app *App) signalAssignmentsCommand(accountId string) (string, error) {
+	m, err := app.DB.GetMoodleAssignments(accountId)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return "Du bist nicht für die Moodle-Aufgaben angemeldet", nil
+		}
+		return "", err
+	}
+
+	return openMoodleAssignmentsToText(m.Assignments, time.Now()), nil
+}
+
+// Pauses the notifications, either until it's lifted or until the given day
+func (app *App) signalPauseCommand(accountId string, args []string) (string, error) {
+	var pausedUntil time.Time
+	if len(args) > 0 {
+		var err error
+		pausedUntil, err = time.ParseInLocation("02.01.2006", args[0], time.Local)
+		if err != nil {
+			return "Das Datum muss im Format TT.MM.JJJJ angegeben werden, z.B. \"pause 06.01.2027\"", nil
+		}
+	}
+
+	p, err := app.DB.GetNotificationPreference(accountId)
+	if err != nil {
+		return "", err
+	}
+
+	p.Paused, p.PausedUntil, p.OptedOut = true, pausedUntil, false
+	_, user_err, db_err := app.SetNotificationPreference(p)
+	if db_err != nil {
+		return "", db_err
+	}
+
+	if user_err != nil {
+		return "Das Datum muss in der Zukunft liegen", nil
+	}
+
+	if pausedUntil.IsZero() {
+		return "Deine Benachrichtigungen sind pausiert. Schreibe \"start\", um sie wieder zu erhalten.", nil
+	}
+	return fmt.Sprintf("Deine Benachrichtigungen sind bis zum %s pausiert.", pausedUntil.Format("02.01.2006")), nil
+}
+
+func (app *App) signalStopCommand(accountId string) (string, error) {
+	p, err := app.DB.GetNotificationPreference(accountId)
+	if err != nil {
+		return "", err
+	}
+
+	p.OptedOut = true
+	if _, err := app.DB.SetNotificationPreference(p); err != nil {
+		return "", err
+	}
+	return "Du bist von allen Benachrichtigungen abgemeldet. Schreibe \"start\", um sie wieder zu erhalten.", nil
+}
+
+func (app *App) signalStartCommand(accountId string) (string, error) {
+	p, err := app.DB.GetNotificationPreference(accountId)
+	if err != nil {
+		return "", err
+	}
+
+	p.Paused, p.PausedUntil, p.OptedOut = false, time.Time{}, false
+	if _, err := app.DB.SetNotificationPreference(p); err != nil {
+		return "", err
+	}
+	return "Du erhältst wieder Benachrichtigungen.", nil
+}
+
+// Runs the command for all accounts, the answer is the same for every account
+func forAllAccounts(accounts []models.Account, command func(accountId string) (string, error)) (string, error) {
+	var answer string
+	for _, a := range accounts {
+		var err error
+		if answer, err = command(a.Id); err != nil {
+			return "", err
+		}
+	}
+	return answer, nil
+}
+
+// Runs the command for all accounts and joins the answers, which are headed by the usernames if there are multiple accounts
+func joinAccountAnswers(accounts []models.Account, command func(accountId string) (string, error)) (string, error) {
+	answers := make([]string, len(accounts))
+	for i, a := range accounts {
+		answer, err := command(a.Id)
+		if err != nil {
+			return "", err
+		}
+
+		if len(accounts) > 1 {
+			answer = fmt.Sprintf("%s:\n%s", a.Username, answer)
+		}
+		answers[i] = answer
+	}
+	return strings.Join(answers, "\n\n"), nil
+}
+
+// Returns the answer to a command sent via signal. The command applies to all given accounts, which share the phone number of the sender.
+func (app *App) HandleSignalCommand(accounts []models.Account, text string) (string, error) {
+	fields := strings.Fields(strings.ToLower(text))
+	if len(fields) == 0 || len(accounts) == 0 {
+		return signalBotHelpText, nil
+	}
+
+	switch fields[0] {
+	case "plan":
+		return joinAccountAnswers(accounts, app.signalPlanCommand)
+	case "aufgaben":
+		return joinAccountAnswers(accounts, app.signalAssignmentsCommand)
+	case "pause":
+		return forAllAccounts(accounts, func(accountId string) (string, error) {
+			return app.signalPauseCommand(accountId, fields[1:])
+		})
+	case "stop":
+		return forAllAccounts(accounts, app.signalStopCommand)
+	case "start":
+		return forAllAccounts(accounts, app.signalStartCommand)
+	default:
+		return signalBotHelpText, nil
+	}
+}
+
+// Answers a single incoming signal message. It applies to all accounts with the phone number of the sender,
+// messages of unknown or disabled accounts are ignored.
+func (app *App) handleIncomingSignalMessage(message signal_message_sender.IncomingMessage) error {
+	phoneNumber, err := utils.FormatPhoneNumber(message.Sender)
+	if err != nil {
+		logging.Debugf("Ignoring signal message of invalid phone number %s", message.Sender)
+		return nil
+	}
+
+	accountIds, err := app.DB.GetAccountIdsByPhoneNumber(phoneNumber)
+	if err != nil {
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			logging.Debugf("Ignoring signal message of unknown phone number %s", phoneNumber)
+			return nil
+		}
+		return err
+	}
+
+	var accounts []models.Account
+	for _, accountId := range accountIds {
+		a, err := app.DB.GetAccount(accountId)
+		if err != nil {
+			return err
+		}
+
+		if a.Disabled {
+			logging.Debugf("Ignoring signal message to disabled account %s", accountId)
+			continue
+		}
+		accounts = append(accounts, a)
+	}
+
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	answer, err := app.HandleSignalCommand(accounts, message.Text)
+	if err != nil {
+		return err
+	}
+
+	return app.Signal.Send(answer, message.Sender)
+}
+
+// Receives the incoming signal messages once and answers them
+func (app *App) ReceiveSignalCommands(ctx context.Context) error {
+	messages, err := app.Signal.Receive(ctx, app.Config.SIGNAL_RECEIVE_TIMEOUT)
+	if err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		if err := app.handleIncomingSignalMessage(message); err != nil {
+			logging.Errorf("Error while answering signal message of %s: %s", message.Sender, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Starts polling for incoming signal messages in the background
+func (app *App) EnableSignalCommands() {
+	go func() {
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Duration(app.Config.SIGNAL_RECEIVE_TIMEOUT+30)*time.Second)
+			err := app.ReceiveSignalCommands(ctx)
+			cancel()
+
+			if err != nil {
+				logging.Errorf("Error while receiving signal messages: %s", err.Error())
+				// Don't flood the signal cli api while it's unavailable
+				time.Sleep(time.Duration(app.Config.SIGNAL_RECEIVE_TIMEOUT) * time.Second)
+			}
+		}
+	}()
+}
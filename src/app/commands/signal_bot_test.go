@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
+	"github.com/dattito/purrmannplus-backend/utils"
+)
+
+// A signal client which answers from the fake notifier, incoming messages are handled directly in the tests
+type fakeSignalClient struct {
+	*fakeNotifier
+}
+
+func (f fakeSignalClient) Receive(ctx context.Context, timeout int) ([]signal_message_sender.IncomingMessage, error) {
+	return nil, nil
+}
+
+func TestHandleSignalCommandStopAndStart(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if _, err := app.HandleSignalCommand([]models.Account{a}, "STOP"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := app.DB.GetNotificationPreference(a.Id)
+	if !p.OptedOut {
+		t.Error("stop didn't opt out")
+	}
+
+	if _, err := app.HandleSignalCommand([]models.Account{a}, "start"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ = app.DB.GetNotificationPreference(a.Id)
+	if p.IsPaused(time.Now()) {
+		t.Errorf("notifications are still paused after start: %+v", p)
+	}
+}
+
+func TestSignalStopAppliesToAllAccountsOfThePhoneNumber(t *testing.T) {
+	app, n := setupTest(t)
+	app.Signal = fakeSignalClient{n}
+
+	// Siblings who share a phone number, which is stored formatted like the commands do
+	phoneNumber, err := utils.FormatPhoneNumber("+4915112345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var accounts []models.Account
+	for _, username := range []string{"alice", "bob"} {
+		a, err := app.DB.AddAccount(username, "password", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := app.DB.AddAccountInfo(a.Id, phoneNumber); err != nil {
+			t.Fatal(err)
+		}
+		accounts = append(accounts, a)
+	}
+
+	if err := app.handleIncomingSignalMessage(signal_message_sender.IncomingMessage{Sender: "+4915112345678", Text: "stop"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range accounts {
+		if p, _ := app.DB.GetNotificationPreference(a.Id); !p.OptedOut {
+			t.Errorf("stop didn't opt out %s", a.Username)
+		}
+	}
+
+	if sent := n.sent(); len(sent) != 1 {
+		t.Errorf("got %d answers, want 1", len(sent))
+	}
+}
+
+func TestHandleSignalCommandPause(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if _, err := app.HandleSignalCommand([]models.Account{a}, "pause"); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ := app.DB.GetNotificationPreference(a.Id)
+	if !p.Paused || !p.PausedUntil.IsZero() {
+		t.Errorf("pause without date: got %+v", p)
+	}
+
+	tomorrow := time.Now().AddDate(0, 0, 2)
+	if _, err := app.HandleSignalCommand([]models.Account{a}, "pause "+tomorrow.Format("02.01.2006")); err != nil {
+		t.Fatal(err)
+	}
+
+	p, _ = app.DB.GetNotificationPreference(a.Id)
+	if !p.Paused || p.PausedUntil.Format("02.01.2006") != tomorrow.Format("02.01.2006") {
+		t.Errorf("pause with date: got %+v", p)
+	}
+
+	// Invalid and past dates don't change the pause
+	for _, text := range []string{"pause morgen", "pause 01.01.2000"} {
+		answer, err := app.HandleSignalCommand([]models.Account{a}, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if answer == "" {
+			t.Errorf("%q: got no answer", text)
+		}
+
+		if q, _ := app.DB.GetNotificationPreference(a.Id); !q.PausedUntil.Equal(p.PausedUntil) {
+			t.Errorf("%q changed the pause to %s", text, q.PausedUntil)
+		}
+	}
+}
+
+func TestHandleSignalCommandHelp(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	for _, text := range []string{"", "hilfe", "unbekannt"} {
+		answer, err := app.HandleSignalCommand([]models.Account{a}, text)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if answer != signalBotHelpText {
+			t.Errorf("%q: got %q, want the help text", text, answer)
+		}
+	}
+}
@@ -1,68 +1,108 @@
 package commands
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/config"
-	"github.com/dattito/purrmannplus-backend/database"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
-	"github.com/dattito/purrmannplus-backend/services/scheduler"
-	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
 	"github.com/dattito/purrmannplus-backend/services/substitutions"
-	"github.com/dattito/purrmannplus-backend/utils"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
 )
 
-// Returns a map of the differences between two maps (difference amount)
-func substitutionsDifferenceAmount(newSubstituations, oldSubstituations map[string][]string) map[string][]string {
-	s := map[string][]string{}
+// Removes the removed entries of days which are already over, as they just dropped off the plan
+func withoutPastRemovals(changes []substitutions.Change, now time.Time) []substitutions.Change {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
-	for day, lessons := range newSubstituations {
-		if len(oldSubstituations[day]) <= 0 {
-			s[day] = lessons
-			continue
+	var relevantChanges []substitutions.Change
+	for _, change := range changes {
+		if change.Type == substitutions.Removed {
+			if date, err := substitutions.ParseDate(change.Old.Date, now); err == nil && date.Before(today) {
+				continue
+			}
 		}
+		relevantChanges = append(relevantChanges, change)
+	}
+	return relevantChanges
+}
 
-		for _, lesson := range lessons {
-			if !utils.Contains(oldSubstituations[day], lesson) {
-				s[day] = append(s[day], lesson)
-			}
+// Produces a human readable line from a single substitution
+func substitutionToText(s models.Substitution) string {
+	text := fmt.Sprintf("%s. Stunde", s.Period)
+	if s.Class != "" {
+		text += fmt.Sprintf(" (%s)", s.Class)
+	}
+	if s.Subject != "" {
+		text += fmt.Sprintf(" %s", s.Subject)
+	}
+	if s.OriginalTeacher != "" || s.SubstituteTeacher != "" {
+		text += fmt.Sprintf(": %s → %s", s.OriginalTeacher, s.SubstituteTeacher)
+	}
+	if s.Room != "" {
+		text += fmt.Sprintf(", Raum %s", s.Room)
+	}
+	if s.Note != "" {
+		text += fmt.Sprintf(" (%s)", s.Note)
+	}
+	return text
+}
+
+// Produces a human readable line from a single change
+func substitutionChangeToText(change substitutions.Change) string {
+	switch change.Type {
+	case substitutions.Removed:
+		return fmt.Sprintf("Vertretung entfällt: %s", substitutionToText(change.Old))
+	case substitutions.Modified:
+		changedFields := change.ChangedFields()
+		if len(changedFields) == 1 && changedFields[0] == "room" {
+			return fmt.Sprintf("Raum geändert: %s. Stunde %s jetzt in Raum %s (vorher %s)",
+				change.New.Period, change.New.Subject, change.New.Room, change.Old.Room)
 		}
+		return fmt.Sprintf("Geändert: %s", substitutionToText(change.New))
+	default:
+		return substitutionToText(change.New)
 	}
-	return s
 }
 
-// Produces a human readable text message from a map of substitutions
-func substituationToTextMessage(substitution map[string][]string) string {
-	if len(substitution) == 0 {
+// Produces a human readable text message from a list of changes, grouped by day and sorted by period
+func substitutionChangesToTextMessage(changes []substitutions.Change, now time.Time) string {
+	if len(changes) == 0 {
 		return "Du hast keine neuen Vertretungen"
 	}
 
-	var text string = "Du hast neue Vertretungen: \n"
+	changes = append([]substitutions.Change(nil), changes...)
+	substitutions.SortChanges(changes, now)
+
+	var text string = "Dein Vertretungsplan hat sich geändert: \n"
+
+	day := ""
+	for i, change := range changes {
+		date := change.Entry().Date
 
-	for day, lessons := range substitution {
-		text += fmt.Sprintf("\n%s:\n", day)
-		for _, lesson := range lessons {
-			text += fmt.Sprintf("%s\n", lesson)
+		if i == 0 || date != day {
+			day = date
+			text += fmt.Sprintf("\n%s:\n", day)
 		}
+		text += fmt.Sprintf("%s\n", substitutionChangeToText(change))
 	}
 	return text
 }
 
 // Returns error produced by user; error not produced by user
-func AddAccountToSubstitutionUpdater(accountId string) (error, error) {
-	a, err := database.DB.GetAccount(accountId)
+func (app *App) AddAccountToSubstitutionUpdater(accountId string) (error, error) {
+	a, err := app.DB.GetAccount(accountId)
 	if err != nil {
 		return nil, err
 	}
 
-	return AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, a.Username, a.Password)
+	return app.AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, a.Username, a.Password)
 }
 
-func AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, authId, authPw string) (error, error) {
-	if _, err := database.DB.GetSubstitutions(accountId); err != nil {
+func (app *App) AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, authId, authPw string) (error, error) {
+	if _, err := app.DB.GetSubstitutions(accountId); err != nil {
 		if !errors.Is(err, &db_errors.ErrRecordNotFound) {
 			return nil, err
 		}
@@ -70,26 +110,23 @@ func AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, authId, aut
 		return errors.New("account is already in substitution updater"), nil
 	}
 
-	ai, err := database.DB.GetAccountInfo(accountId)
+	hasChannel, err := app.HasNotificationChannel(accountId)
 	if err != nil {
-		if errors.Is(err, &db_errors.ErrRecordNotFound) {
-			return errors.New("phone number has to be added first"), nil
-		}
 		return nil, err
 	}
 
-	if ai.PhoneNumber == "" {
-		return errors.New("phone number has to be added first"), nil
+	if !hasChannel {
+		return errors.New("phone number or notification channel has to be added first"), nil
 	}
 
-	if _, err := database.DB.GetAccount(accountId); err != nil {
+	if _, err := app.DB.GetAccount(accountId); err != nil {
 		if errors.Is(err, &db_errors.ErrRecordNotFound) {
 			return errors.New("account does not exist"), nil
 		}
 		return nil, err
 	}
 
-	correct, err := substitutions.CheckCredentials(authId, authPw)
+	correct, err := app.Substitutions.CheckCredentials(authId, authPw)
 	if err != nil {
 		return nil, err
 	}
@@ -98,90 +135,104 @@ func AddAccountToSubstitutionUpdaterWithCustomCredentials(accountId, authId, aut
 		return errors.New("credentials are incorrect for the substitution updater"), nil
 	}
 
-	if err = database.DB.AddAccountToSubstitution(accountId, authId, authPw); err != nil {
+	if err = app.DB.AddAccountToSubstitution(accountId, authId, authPw); err != nil {
 		return nil, err
 	}
 
-	return nil, UpdateSubstitutionsByAccountId(accountId)
+	return nil, app.UpdateSubstitutionsByAccountId(accountId)
 }
 
-func RemoveAccountFromSubstitutionUpdater(accountId string) error {
-	return database.DB.RemoveAccountFromSubstitutionUpdater(accountId)
+func (app *App) RemoveAccountFromSubstitutionUpdater(accountId string) error {
+	return app.DB.RemoveAccountFromSubstitutionUpdater(accountId)
 }
 
-// Updates the substitutions for a given account and sends a message via signal
-func UpdateSubstitutions(m models.SubstitutionInfo) error {
-	logging.Debugf("Updating substitutions of account %s (id: %s)", m.AuthId, m.AccountId)
-	mayNewSubstitutions, err := substitutions.GetSubstituationOfStudent(m.AuthId, m.AuthPw)
+// Returns the stored substitutions of an account; error produced by user; error not produced by user
+func (app *App) GetSubstitutions(accountId string) (models.Substitutions, error, error) {
+	s, err := app.DB.GetSubstitutions(accountId)
 	if err != nil {
-		return err
+		if errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return models.Substitutions{}, errors.New("account is not in substitution updater"), nil
+		}
+		return models.Substitutions{}, nil, err
 	}
 
-	old_substitutions := m.Entries
+	return s, nil, nil
+}
 
-	newSubstitutions := substitutionsDifferenceAmount(mayNewSubstitutions, old_substitutions)
+// Updates the substitutions for a given account and sends a notification, returns the number of sent messages
+func (app *App) UpdateSubstitutions(ctx context.Context, m models.SubstitutionInfo) (int, error) {
+	logging.Debugf("Updating substitutions of account %s (id: %s)", m.AuthId, m.AccountId)
+	mayNewSubstitutions, err := app.Substitutions.GetSubstituationOfStudent(ctx, m.AuthId, m.AuthPw)
+	if err != nil {
+		return 0, err
+	}
+
+	changes := substitutions.Diff(m.Entries, mayNewSubstitutions)
 
-	// If there are no new substitutions, we don't need to do anything
-	if len(newSubstitutions) == 0 {
-		return nil
+	// If nothing changed, we don't need to do anything
+	if len(changes) == 0 && !m.NotSetYet {
+		return 0, nil
 	}
 
-	if err = database.DB.SetSubstitutions(m.AccountId, mayNewSubstitutions, false); err != nil {
-		return err
+	if err = app.DB.SetSubstitutions(m.AccountId, mayNewSubstitutions, false); err != nil {
+		return 0, err
 	}
 
 	logging.Debugf("Successfully updated substitutions of %s", m.AuthId)
 
-	// Send a message to the user if there are new substitutions
-	if m.NotSetYet || len(newSubstitutions) == 0 {
-		return nil
+	// Send a message to the user if there are relevant changes
+	now := time.Now()
+	relevantChanges := withoutPastRemovals(changes, now)
+	if m.NotSetYet || len(relevantChanges) == 0 {
+		return 0, nil
 	}
 
-	// Send a message to the user if there are new substitutions
-	return signal_message_sender.SignalMessageSender.Send(substituationToTextMessage(newSubstitutions), m.PhoneNumber)
+	if err := app.SendNotification(m.AccountId, m.PhoneNumber, substitutionChangesToTextMessage(relevantChanges, now)); err != nil {
+		return 0, err
+	}
+
+	return 1, nil
 }
 
-// Updates the substitutions for a given account and sends a message via signal
-func UpdateSubstitutionsByAccountId(accountId string) error {
-	m, err := database.DB.GetSubstitutionInfos(accountId)
+// Updates the substitutions for a given account and sends a notification
+func (app *App) UpdateSubstitutionsByAccountId(accountId string) error {
+	m, err := app.DB.GetSubstitutionInfos(accountId)
 	if err != nil {
 		return err
 	}
 
-	return UpdateSubstitutions(m)
+	ctx, cancel := app.newUpdateContext()
+	defer cancel()
+
+	_, err = app.UpdateSubstitutions(ctx, m)
+	return err
 }
 
-// Updates all substitutions and sends a message via signal
-func UpdateAllSubstitutions() error {
-	ms, err := database.DB.GetAllSubstitutionInfos()
+// Updates all substitutions using the worker pool and sends notifications
+func (app *App) UpdateAllSubstitutions() (workerpool.Summary, error) {
+	ms, err := app.DB.GetAllSubstitutionInfos()
 	if err != nil {
-		return err
+		return workerpool.Summary{}, err
 	}
 
-	errCount := 0
+	pool := app.newUpdaterPool(app.Config.MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS)
 
-	for _, m := range ms {
-		err := UpdateSubstitutions(m)
+	return pool.Run(len(ms), func(ctx context.Context, i int) workerpool.Result {
+		messagesSent, err := app.UpdateSubstitutions(ctx, ms[i])
 		if err != nil {
-			logging.Errorf("Error updating substitutions for account %s: %s", m.AccountId, err.Error())
-			errCount++
-			if errCount > config.MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS {
-				return errors.New("got too many errors updating substitutions, stopping")
-			}
+			logging.Errorf("Error updating substitutions for account %s: %s", ms[i].AccountId, err.Error())
+			err = fmt.Errorf("account %s: %w", ms[i].AccountId, err)
 		}
-	}
-	return nil
+
+		return workerpool.Result{MessagesSent: messagesSent, Err: err}
+	}), nil
 }
 
 // Activates the scheduler to update the substitutions
-func EnableSubstitutionUpdater() {
-	scheduler.AddJob(config.SUBSTITUTIONS_UPDATECRON, func() {
-		if err := UpdateAllSubstitutions(); err != nil {
-			logging.Errorf("Error updating substitutions: %v", err)
-		}
-	})
+func (app *App) EnableSubstitutionUpdater() error {
+	return app.addRecordedJob(JobSubstitutionUpdater, app.Config.SUBSTITUTIONS_UPDATECRON, app.UpdateAllSubstitutions)
 }
 
-func CheckSubstitutionCredentials(username, password string) (bool, error) {
-	return substitutions.CheckCredentials(username, password)
-}
\ No newline at end of file
+func (app *App) CheckSubstitutionCredentials(username, password string) (bool, error) {
+	return app.Substitutions.CheckCredentials(username, password)
+}
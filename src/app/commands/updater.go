@@ -0,0 +1,33 @@
+package commands
+
+import (
+	"context"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+	"github.com/dattito/purrmannplus-backend/utils/workerpool"
+)
+
+// Returns the worker pool which updates the accounts of an updater
+func (app *App) newUpdaterPool(maxErrors int) workerpool.Pool {
+	return workerpool.Pool{
+		Concurrency: app.Config.UPDATER_CONCURRENCY,
+		Timeout:     time.Duration(app.Config.UPDATER_JOB_TIMEOUT) * time.Second,
+		MaxErrors:   maxErrors,
+	}
+}
+
+// Returns a context with the timeout for updating a single account
+func (app *App) newUpdateContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), time.Duration(app.Config.UPDATER_JOB_TIMEOUT)*time.Second)
+}
+
+// Logs the summary of an updater run
+func logRunSummary(name string, s workerpool.Summary) {
+	if s.Aborted {
+		logging.Errorf("%s: got too many errors, stopped after %d accounts", name, s.Processed)
+	}
+
+	logging.Infof("%s: processed %d accounts in %s, %d failed, %d messages sent",
+		name, s.Processed, s.Finished.Sub(s.Started).Round(time.Millisecond), s.Failed, s.MessagesSent)
+}
@@ -0,0 +1,295 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/services/moodle/moodletest"
+	"github.com/dattito/purrmannplus-backend/services/substitutions"
+	"github.com/dattito/purrmannplus-backend/services/substitutions/substitutionstest"
+)
+
+func TestNewMoodleAssignments(t *testing.T) {
+	old := []models.MoodleAssignment{{Id: 1}, {Id: 2}}
+	may := []models.MoodleAssignment{
+		{Id: 1},
+		{Id: 3},
+		{Id: 4, SubmissionStatus: models.MoodleSubmissionStatusSubmitted},
+		{Id: 5, SubmissionStatus: models.MoodleSubmissionStatusGraded},
+	}
+
+	got := newMoodleAssignments(may, old)
+	if len(got) != 1 || got[0].Id != 3 {
+		t.Errorf("newMoodleAssignments() = %v, want only assignment 3", got)
+	}
+
+	if !moodleAssignmentsEqual(old, []models.MoodleAssignment{{Id: 1}, {Id: 2}}) {
+		t.Error("equal assignments aren't equal")
+	}
+
+	if moodleAssignmentsEqual(old, []models.MoodleAssignment{{Id: 1}, {Id: 2, SubmissionStatus: models.MoodleSubmissionStatusSubmitted}}) {
+		t.Error("a changed submission status isn't detected")
+	}
+}
+
+func TestWithoutPastRemovals(t *testing.T) {
+	now := time.Date(2021, 12, 15, 10, 0, 0, 0, time.Local)
+	changes := []substitutions.Change{
+		{Type: substitutions.Removed, Old: models.Substitution{Date: "Mo 13.12.", Period: "1"}},
+		{Type: substitutions.Removed, Old: models.Substitution{Date: "Mi 15.12.", Period: "2"}},
+		{Type: substitutions.Added, New: models.Substitution{Date: "Do 16.12.", Period: "3"}},
+	}
+
+	got := withoutPastRemovals(changes, now)
+	if len(got) != 2 || got[0].Old.Period != "2" || got[1].New.Period != "3" {
+		t.Errorf("withoutPastRemovals() = %v, want the removal of today and the addition", got)
+	}
+}
+
+func TestSubstitutionChangesToTextMessageGroupsDays(t *testing.T) {
+	now := time.Date(2021, 12, 12, 10, 0, 0, 0, time.Local)
+	old := []models.Substitution{
+		{Date: "Mo 13.12.", Period: "5", Subject: "Sport"},
+		{Date: "Di 14.12.", Period: "2", Subject: "Deutsch"},
+	}
+	current := []models.Substitution{
+		{Date: "Mo 13.12.", Period: "1", Subject: "Mathe"},
+		{Date: "Di 14.12.", Period: "4", Subject: "Physik"},
+	}
+
+	got := substitutionChangesToTextMessage(substitutions.Diff(old, current), now)
+
+	if n := strings.Count(got, "Mo 13.12.:"); n != 1 {
+		t.Errorf("day header of monday appears %d times in %q", n, got)
+	}
+	if n := strings.Count(got, "Di 14.12.:"); n != 1 {
+		t.Errorf("day header of tuesday appears %d times in %q", n, got)
+	}
+
+	order := []string{"Mo 13.12.:", "1. Stunde", "entfällt: 5. Stunde", "Di 14.12.:", "entfällt: 2. Stunde", "4. Stunde"}
+	last := -1
+	for _, part := range order {
+		i := strings.Index(got, part)
+		if i <= last {
+			t.Fatalf("%q isn't in the expected order %v in %q", part, order, got)
+		}
+		last = i
+	}
+}
+
+func TestUpdateMoodleAssignmentsRequestsOnlyOpenStatuses(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+	if err := app.DB.AddAccountToMoodleAssignmentUpdater(a.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := moodletest.NewServer()
+	defer srv.Close()
+	app.Moodle.Url = srv.URL
+
+	token := srv.AddUser("alice", "password")
+
+	future := time.Now().Add(24 * time.Hour).Unix()
+	past := time.Now().Add(-24 * time.Hour).Unix()
+	assignment := func(id int, dueDate int64) map[string]interface{} {
+		return map[string]interface{}{"id": id, "course": 1, "name": "Aufgabe", "duedate": dueDate}
+	}
+	srv.SetResponse("alice", "mod_assign_get_assignments", map[string]interface{}{
+		"courses": []interface{}{map[string]interface{}{
+			"id":       1,
+			"fullname": "Mathe",
+			"assignments": []interface{}{
+				assignment(1, future), // Known as submitted
+				assignment(2, past),   // Closed
+				assignment(3, future), // Known, but not submitted yet
+				assignment(4, future), // New
+			},
+		}},
+	})
+	srv.SetResponse("alice", "mod_assign_get_submission_status", map[string]interface{}{
+		"lastattempt": map[string]interface{}{"submission": map[string]interface{}{"status": "submitted"}},
+	})
+
+	m := models.MoodleAssignmentInfo{
+		AuthId:      "alice",
+		AuthPw:      "password",
+		MoodleToken: token,
+		AccountId:   a.Id,
+		Assignments: []models.MoodleAssignment{
+			{Id: 1, SubmissionStatus: models.MoodleSubmissionStatusSubmitted},
+			{Id: 2, SubmissionStatus: models.MoodleSubmissionStatusNew},
+			{Id: 3, SubmissionStatus: models.MoodleSubmissionStatusNew},
+		},
+	}
+	if _, err := app.UpdateMoodleAssignments(context.Background(), m); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := srv.Calls("mod_assign_get_submission_status"); calls != 2 {
+		t.Errorf("requested %d submission statuses, want 2 for the open assignments which aren't submitted", calls)
+	}
+
+	stored, err := app.DB.GetMoodleAssignments(a.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[int]string{
+		1: models.MoodleSubmissionStatusSubmitted,
+		2: models.MoodleSubmissionStatusNew,
+		3: models.MoodleSubmissionStatusSubmitted,
+		4: models.MoodleSubmissionStatusSubmitted,
+	}
+	if len(stored.Assignments) != len(want) {
+		t.Fatalf("stored %d assignments, want %d", len(stored.Assignments), len(want))
+	}
+	for _, assignment := range stored.Assignments {
+		if assignment.SubmissionStatus != want[assignment.Id] {
+			t.Errorf("status of assignment %d = %q, want %q", assignment.Id, assignment.SubmissionStatus, want[assignment.Id])
+		}
+	}
+}
+
+func TestGetAllInfosSkipsDisabledAccounts(t *testing.T) {
+	app, _ := setupTest(t)
+	alice := createTestAccount(t, app, "alice")
+	bob := createTestAccount(t, app, "bob")
+
+	for _, a := range []models.Account{alice, bob} {
+		if err := app.DB.AddAccountToSubstitution(a.Id, a.Username, "password"); err != nil {
+			t.Fatal(err)
+		}
+		if err := app.DB.AddAccountToMoodleAssignmentUpdater(a.Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := app.DB.SetAccountDisabled(bob.Id, true); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := app.DB.GetAllSubstitutionInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s) != 1 || s[0].AccountId != alice.Id || !s[0].NotSetYet || s[0].PhoneNumber == "" {
+		t.Errorf("GetAllSubstitutionInfos() = %+v, want only alice", s)
+	}
+
+	m, err := app.DB.GetAllMoodleAssignmentInfos()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(m) != 1 || m[0].AccountId != alice.Id {
+		t.Errorf("GetAllMoodleAssignmentInfos() = %+v, want only alice", m)
+	}
+}
+
+func TestAddAccountToSubstitutionUpdaterTwice(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.AddAccountToSubstitution(a.Id, "alice", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if user_err, db_err := app.AddAccountToSubstitutionUpdater(a.Id); user_err == nil || db_err != nil {
+		t.Errorf("got user_err = %v, db_err = %v, want a user error", user_err, db_err)
+	}
+}
+
+func TestDeleteAccount(t *testing.T) {
+	app, _ := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	if err := app.DB.AddAccountToSubstitution(a.Id, "alice", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DB.AddOutboxMessage(a.Id, "+4915112345678", "Hallo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := app.DeleteAccount(a.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, user_err, _ := app.GetSubstitutions(a.Id); user_err == nil {
+		t.Error("substitutions of the deleted account still exist")
+	}
+
+	if messages, _ := app.DB.GetOutboxMessages(); len(messages) != 0 {
+		t.Errorf("got %d queued messages of the deleted account", len(messages))
+	}
+}
+
+// Returns the page of a student with the plan tables of two days in the future and a notice between them.
+// The given period is used for the lesson of the second day.
+func substitutionPageWithNotice(authId string, now time.Time, secondPeriod string) (string, []models.Substitution) {
+	first := "Mo " + now.AddDate(0, 0, 1).Format("02.01.")
+	second := "Di " + now.AddDate(0, 0, 2).Format("02.01.")
+
+	page := fmt.Sprintf(`<html><body>
+<table id="wikihead"><tr><td><a href="pmwiki.php?n=Main.%s&amp;action=logout">abmelden</a></td></tr></table>
+<div id="wikitext">
+<table><tr><td colspan="7">%s</td></tr><tr><td>1</td><td>10a</td><td>Mathe</td><td>MÜL</td><td>SCH</td><td>104</td><td></td></tr></table>
+<table class="hinweis"><tr><td>Bitte beachten:</td><td>Der Plan wird um 7 Uhr aktualisiert.</td></tr></table>
+<table><tr><td colspan="7">%s</td></tr><tr><td>%s</td><td>10a</td><td>Deutsch</td><td>KRA</td><td>LEH</td><td>210</td><td></td></tr></table>
+</div>
+</body></html>`, authId, first, second, secondPeriod)
+
+	return page, []models.Substitution{
+		{Date: first, Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+		{Date: second, Period: "2", Class: "10a", Subject: "Deutsch", OriginalTeacher: "KRA", SubstituteTeacher: "LEH", Room: "210"},
+	}
+}
+
+func TestUpdateSubstitutionsDoesNotCancelLessonsOfUnparsablePages(t *testing.T) {
+	app, n := setupTest(t)
+	a := createTestAccount(t, app, "alice")
+
+	pmwiki := substitutionstest.NewServer()
+	defer pmwiki.Close()
+	pmwiki.AddStudent("alice", "password")
+	app.Substitutions = substitutions.NewClient(pmwiki.URL, app.HTTP)
+
+	if err := app.DB.AddAccountToSubstitution(a.Id, "alice", "password"); err != nil {
+		t.Fatal(err)
+	}
+
+	page, stored := substitutionPageWithNotice("alice", time.Now(), "2")
+	if err := app.DB.SetSubstitutions(a.Id, stored, false); err != nil {
+		t.Fatal(err)
+	}
+
+	// The days after a notice are still part of the plan
+	pmwiki.SetPage("alice", page)
+	if err := app.UpdateSubstitutionsByAccountId(a.Id); err != nil {
+		t.Fatalf("UpdateSubstitutionsByAccountId() = %v, want nil", err)
+	}
+
+	// A row which can't be parsed rejects the page instead of cancelling the lesson
+	page, _ = substitutionPageWithNotice("alice", time.Now(), "Pause")
+	pmwiki.SetPage("alice", page)
+	if err := app.UpdateSubstitutionsByAccountId(a.Id); err == nil {
+		t.Error("UpdateSubstitutionsByAccountId() of a page with an unparsable row = nil, want an error")
+	}
+
+	for _, message := range n.sent() {
+		if strings.Contains(message, "entfällt") {
+			t.Errorf("got a cancellation although the plan didn't change: %q", message)
+		}
+	}
+
+	s, user_err, db_err := app.GetSubstitutions(a.Id)
+	if user_err != nil || db_err != nil || len(s.Entries) != len(stored) {
+		t.Errorf("stored substitutions = %+v, want the previous %d entries", s.Entries, len(stored))
+	}
+}
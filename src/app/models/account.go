@@ -5,10 +5,18 @@ import (
 	"strings"
 )
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type Account struct {
-	Id       string
-	Username string
-	Password string
+	Id           string
+	Username     string
+	Password     string // The moodle password, used to fetch data from moodle
+	PasswordHash string // Salted hash of the password used to log in to PurrmannPlus
+	Role         string // Either RoleUser or RoleAdmin
+	Disabled     bool   // Disabled accounts can't log in and aren't updated
 }
 
 func NewValidAccount(username, password string) (*Account, error) {
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// An action event of the moodle calendar, e.g. the deadline of a quiz
+type MoodleCalendarEvent struct {
+	Id         int
+	Name       string
+	CourseName string
+	ModuleName string // The module the event belongs to, e.g. "assign" or "quiz"
+	Instance   int    // The id of the module instance, e.g. the id of the assignment
+	Start      time.Time
+	Url        string
+}
+
+// The data needed to sync the moodle calendar of an account
+type MoodleCalendarInfo struct {
+	AuthId      string
+	AuthPw      string
+	MoodleToken string // Cached webservice token, empty if there is none yet
+	AccountId   string
+}
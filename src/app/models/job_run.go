@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// A single run of a scheduled job
+type JobRun struct {
+	Id                string
+	Job               string
+	StartedAt         time.Time
+	FinishedAt        time.Time
+	AccountsProcessed int
+	Errors            int
+	MessagesSent      int
+	Error             string // Set if the run failed or was aborted
+}
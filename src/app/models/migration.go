@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Whether a versioned schema migration is applied to the database
+type MigrationStatus struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
@@ -1,25 +1,71 @@
 package models
 
+import "time"
+
+// The response of mod_assign_get_assignments
 type MoodleCourse struct {
 	Courses []struct {
+		ID          int    `json:"id"`
 		FullName    string `json:"fullname"`
 		Assignments []struct {
-			ID int `json:"id"`
+			ID         int    `json:"id"`
+			Course     int    `json:"course"`
+			Name       string `json:"name"`
+			Intro      string `json:"intro"`
+			DueDate    int64  `json:"duedate"`    // Unix timestamp, 0 if not set
+			CutoffDate int64  `json:"cutoffdate"` // Unix timestamp, 0 if not set
 		} `json:"assignments"`
 	} `json:"courses"`
 }
 
+// The submission status of a moodle assignment
+const (
+	MoodleSubmissionStatusNew       = "new"
+	MoodleSubmissionStatusDraft     = "draft"
+	MoodleSubmissionStatusSubmitted = "submitted"
+	MoodleSubmissionStatusGraded    = "graded"
+)
+
+// A single moodle assignment of an account
+type MoodleAssignment struct {
+	Id         int
+	CourseId   int
+	CourseName string
+	Name       string
+	Intro      string
+	DueDate    time.Time // Zero if the assignment has no due date
+	CutoffDate time.Time // Zero if the assignment has no cutoff date
+	// One of the MoodleSubmissionStatus constants, empty if unknown
+	SubmissionStatus string
+}
+
+// Returns true if the assignment was already submitted or graded
+func (a MoodleAssignment) IsDone() bool {
+	return a.SubmissionStatus == MoodleSubmissionStatusSubmitted || a.SubmissionStatus == MoodleSubmissionStatusGraded
+}
+
+// Returns true if nothing can be submitted anymore, i.e. the cutoff date or, without one, the due date has passed
+func (a MoodleAssignment) IsClosed(now time.Time) bool {
+	deadline := a.CutoffDate
+	if deadline.IsZero() {
+		deadline = a.DueDate
+	}
+	return !deadline.IsZero() && deadline.Before(now)
+}
+
 type MoodleAssignments struct {
 	AccountId   string
-	Assignments []int
+	Assignments []MoodleAssignment
+	UpdatedAt   time.Time
 }
 
 type MoodleAssignmentInfo struct {
 	AuthId                  string
 	AuthPw                  string
+	MoodleToken             string // Cached webservice token, empty if there is none yet
 	PhoneNumber             string
 	AccountId               string
 	MoodleUserAssignmentsId string
-	AssignmentIds           []int
+	Assignments             []MoodleAssignment
 	NotSetYet               bool
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// A discussion in the announcement forum of a moodle course
+type MoodleAnnouncement struct {
+	DiscussionId int
+	CourseId     int
+	CourseName   string
+	Subject      string
+	Message      string // The message as plain text
+	Author       string
+	CreatedAt    time.Time
+}
+
+type MoodleForumInfo struct {
+	AuthId        string
+	AuthPw        string
+	MoodleToken   string // Cached webservice token, empty if there is none yet
+	PhoneNumber   string
+	AccountId     string
+	DiscussionIds []int // The discussions which are already known
+	NotSetYet     bool
+}
@@ -0,0 +1,20 @@
+package models
+
+// A released grade of a grade item in a moodle course
+type MoodleGrade struct {
+	CourseId   int
+	CourseName string
+	ItemId     int
+	ItemName   string
+	Grade      string // The grade as formatted by moodle, e.g. "12,00"
+}
+
+type MoodleGradeInfo struct {
+	AuthId      string
+	AuthPw      string
+	MoodleToken string // Cached webservice token, empty if there is none yet
+	PhoneNumber string
+	AccountId   string
+	Grades      []MoodleGrade // The last snapshot of the grades
+	NotSetYet   bool
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// A reminder which was sent for a moodle assignment
+type MoodleReminder struct {
+	AccountId    string
+	AssignmentId int
+	Offset       time.Duration // The time before the due date the reminder belongs to
+	SentAt       time.Time
+}
+
+// The settings of an account for a single moodle course
+type MoodleCourseSetting struct {
+	AccountId        string
+	CourseId         int
+	CourseName       string // Taken from the stored assignments, empty if the course has no assignments anymore
+	RemindersEnabled bool
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/utils"
+)
+
+// Controls whether and when an account receives notifications
+type NotificationPreference struct {
+	AccountId       string
+	Paused          bool
+	PausedUntil     time.Time // If set, the pause ends at this time, otherwise it lasts until it's lifted
+	OptedOut        bool      // Set when the user unsubscribed, e.g. with "stop" via signal
+	QuietHoursStart string    // Time of day like "22:00", empty if the account has no quiet hours
+	QuietHoursEnd   string    // Time of day like "06:30", may be before the start if the quiet hours span midnight
+	Digest          bool      // If true, notifications are collected and sent at the configured digest times
+}
+
+// Returns true if no notifications should be sent to the account at the given time
+func (n NotificationPreference) IsPaused(now time.Time) bool {
+	if n.OptedOut {
+		return true
+	}
+
+	return n.Paused && (n.PausedUntil.IsZero() || now.Before(n.PausedUntil))
+}
+
+// Returns true if the given time is within the quiet hours of the account
+func (n NotificationPreference) InQuietHours(now time.Time) bool {
+	if n.QuietHoursStart == "" || n.QuietHoursEnd == "" {
+		return false
+	}
+
+	start, err := utils.ParseClock(n.QuietHoursStart)
+	if err != nil {
+		return false
+	}
+
+	end, err := utils.ParseClock(n.QuietHoursEnd)
+	if err != nil {
+		return false
+	}
+
+	t := utils.SinceMidnight(now)
+	if start <= end {
+		return t >= start && t < end
+	}
+	return t >= start || t < end
+}
@@ -0,0 +1,9 @@
+package models
+
+// A notification channel an account receives its messages through
+type NotificationSetting struct {
+	Id        string
+	AccountId string
+	Channel   string
+	Recipient string
+}
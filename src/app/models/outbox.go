@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// A notification which is held back because of quiet hours or the digest mode
+type OutboxMessage struct {
+	Id          string
+	AccountId   string
+	PhoneNumber string
+	Message     string
+	CreatedAt   time.Time
+}
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// A notification which couldn't be sent
+type SendFailure struct {
+	Id        string
+	AccountId string
+	Channel   string
+	Error     string
+	CreatedAt time.Time
+}
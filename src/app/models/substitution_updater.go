@@ -1,8 +1,23 @@
 package models
 
+import "time"
+
+// A single row of the substitution plan
+type Substitution struct {
+	Date              string `json:"date"` // Weekday header of the plan, e.g. "Mo 13.12."
+	Period            string `json:"period"`
+	Class             string `json:"class"`
+	Subject           string `json:"subject"`
+	OriginalTeacher   string `json:"original_teacher"`
+	SubstituteTeacher string `json:"substitute_teacher"`
+	Room              string `json:"room"`
+	Note              string `json:"note"`
+}
+
 type Substitutions struct {
 	AccountId string
-	Entries   map[string][]string
+	Entries   []Substitution
+	UpdatedAt time.Time
 }
 
 type SubstitutionInfo struct {
@@ -11,6 +26,6 @@ type SubstitutionInfo struct {
 	PhoneNumber     string
 	AccountId       string
 	SubstitutionsId string
-	Entries         map[string][]string
+	Entries         []Substitution
 	NotSetYet       bool
 }
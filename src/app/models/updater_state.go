@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// The state of the updaters of a single account
+type UpdaterState struct {
+	AccountId                  string
+	Disabled                   bool
+	SubstitutionUpdater        bool // True if the account is in the substitution updater
+	SubstitutionCount          int
+	SubstitutionsUpdatedAt     time.Time
+	MoodleAssignmentUpdater    bool // True if the account is in the moodle assignment updater
+	MoodleAssignmentCount      int
+	MoodleAssignmentsUpdatedAt time.Time
+	MoodleForumUpdater         bool // True if the account is in the moodle forum updater
+	MoodleGradeUpdater         bool // True if the account is in the moodle grade updater
+	NotificationChannels       []string
+}
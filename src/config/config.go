@@ -8,8 +8,8 @@ import (
 	"github.com/dattito/purrmannplus-backend/utils"
 )
 
-// ENVIRONMENT VARIABLES
-var (
+// The configuration of the application, loaded from the environment variables
+type Config struct {
 	DOT_ENV_FILE_PATH                             string // Path to the .env file, only needed if USE_DOT_ENV_FILE is set to true
 	USE_DOT_ENV_FILE                              bool   // If true, the .env file will be loaded
 	DATABASE_LOG_LEVEL                            int    // Log level for the database: 1-4: 1:Silent, 2:Error, 3:Warn, 4: Info
@@ -24,15 +24,41 @@ var (
 	ENABLE_API                                    bool   // If true, the api will be enabled, otherwise there will be no listener
 	ENABLE_SUBSTITUTIONS_SCHEDULER                bool   // If true, the substitutions scheduler will be enabled
 	SUBSTITUTIONS_UPDATECRON                      string // Cron expression for the substitutions scheduler
-	MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS      int    // If the substitutions scheduler encounters more than this number of errors, it will stop
-	MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS int    // If the substitutions scheduler encounters more than this number of errors, it will stop
+	MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS      int    // If the substitutions scheduler encounters more than this number of errors, it will stop, a negative value disables the limit
+	MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS int    // If the moodle assignment scheduler encounters more than this number of errors, it will stop, a negative value disables the limit
+	MAX_ERROS_TO_STOP_UPDATING_MOODLE_FORUMS      int    // Like MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS for the moodle announcement forum scheduler
+	MAX_ERROS_TO_STOP_UPDATING_MOODLE_GRADES      int    // Like MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS for the moodle grade scheduler
+	MAX_ERROS_TO_STOP_SYNCING_MOODLE_CALENDARS    int    // Like MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS for syncing the moodle calendars
+	MAX_ERROS_TO_STOP_SENDING_MOODLE_REMINDERS    int    // Like MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS for sending the moodle reminders
 	MOODLE_UPDATECRON                             string // Cron expression for the moodle scheduler
+	MOODLE_REMINDER_OFFSETS                       string // Comma separated durations before the due date of an assignment when a reminder is sent, empty disables reminders
+	MOODLE_REMINDER_CRON                          string // Cron expression for checking if reminders have to be sent
+	MOODLE_FORUM_UPDATECRON                       string // Cron expression for the moodle announcement forum scheduler
+	MOODLE_GRADE_UPDATECRON                       string // Cron expression for the moodle grade scheduler
+	MOODLE_CALENDAR_SYNCCRON                      string // Cron expression for syncing the moodle calendars of the calendar feeds
+	DIGEST_TIMES                                  string // Comma separated times of day like "06:30,18:00" when accounts in the digest mode get their notifications
+	NOTIFICATION_OUTBOX_CRON                      string // Cron expression for delivering the notifications held back by quiet hours or the digest mode
+	UPDATER_CONCURRENCY                           int    // Number of accounts which are updated at the same time
+	UPDATER_JOB_TIMEOUT                           int    // Timeout in seconds for updating a single account
+	SUBSTITUTION_RATE_LIMIT                       int    // Max requests per second to the substitution website, 0 means no limit
+	SUBSTITUTION_PERIOD_TIMES                     string // Comma separated times of the periods like "07:45-08:30,08:35-09:20", if empty, substitutions are all day events in the calendar
+	MOODLE_RATE_LIMIT                             int    // Max requests per second to the moodle website, 0 means no limit
 	DATABASE_URI                                  string // The database uri in the format of the given database type
-	DATABASE_TYPE                                 string // The database type: SQLITE, POSTGRES, MYSQL
-	DATABASE_AUTOMIGRATE                          bool   // If true, the database will be automatically migrated on startup
+	DATABASE_TYPE                                 string // The database type: SQLITE, POSTGRES, MYSQL or MEMORY (nothing is persisted, for tests and demos)
+	DATABASE_AUTOMIGRATE                          bool   // If true, pending database migrations are applied on startup, otherwise use the "migrate" subcommand
+	DATABASE_ENCRYPTION_KEY                       string // The secret used to encrypt the stored credentials
 	SIGNAL_CLI_GRPC_API_URL                       string // The url of the signal cli grpc api
 	SIGNAL_SENDER_PHONENUMBER                     string // The phonenumber of the signal sender
+	ENABLE_SIGNAL_COMMANDS                        bool   // If true, incoming signal messages are received and answered as commands
+	SIGNAL_RECEIVE_TIMEOUT                        int    // Timeout in seconds of a single receive request to the signal cli grpc api
+	SMTP_HOST                                     string // The host of the smtp server, if empty, notifications via email are disabled
+	SMTP_PORT                                     int    // The port of the smtp server
+	SMTP_USERNAME                                 string // The username for the smtp server, if empty, no authentication is used
+	SMTP_PASSWORD                                 string // The password for the smtp server
+	SMTP_FROM                                     string // The sender address of notification emails
+	TELEGRAM_BOT_TOKEN                            string // The token of the telegram bot, if empty, notifications via telegram are disabled
 	JWT_SECRET                                    string // The secret used to sign the jwt tokens
+	ADMIN_USERNAMES                               string // Comma separated list of usernames which have the admin role, removed usernames lose it with their next request
 	SUBSTITUTION_URL                              string // The url of the substitution website
 	MOODLE_URL                                    string // The url of the moodle website
 	LOGGING_FILE                                  string // The file to log to, if empty, logs to stdout
@@ -41,133 +67,228 @@ var (
 	PATH_TO_API_STATIC                            string // The path to the static files of the api, default is "./api/providers/rest/static"
 	CONTACT_EMAIL                                 string // The email address users can send emails to
 	CONTACT_INSTAGRAM                             string // The instagram account users can send messages to
-)
-
-// END OF ENDVIRONMENT VARIABLES
 
-var JWT_SHORTLIVING_SECRET string
+	JWT_SHORTLIVING_SECRET string // Generated on every start, signs the tokens of the phone number confirmation links
+}
 
-func Init() error {
+// Returns the configuration read from the environment variables and the .env file
+func Load() (*Config, error) {
+	c := &Config{}
 
 	var err error
 
-	DOT_ENV_FILE_PATH = utils.GetEnv("DOT_ENV_FILE_PATH", ".env")
+	c.DOT_ENV_FILE_PATH = utils.GetEnv("DOT_ENV_FILE_PATH", ".env")
 
-	USE_DOT_ENV_FILE, err = utils.GetBoolEnv("USE_DOT_ENV_FILE", true)
+	c.USE_DOT_ENV_FILE, err = utils.GetBoolEnv("USE_DOT_ENV_FILE", true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if USE_DOT_ENV_FILE {
-		if _, err := os.Stat(DOT_ENV_FILE_PATH); !os.IsNotExist(err) {
+	if c.USE_DOT_ENV_FILE {
+		if _, err := os.Stat(c.DOT_ENV_FILE_PATH); !os.IsNotExist(err) {
 			err = utils.LoadDotEnvFile()
 			if err != nil {
-				return err
+				return nil, err
 			}
 		}
 	}
 
-	DATABASE_LOG_LEVEL, err = utils.GetIntEnv("DATABASE_LOG_LEVEL", 1)
+	c.DATABASE_LOG_LEVEL, err = utils.GetIntEnv("DATABASE_LOG_LEVEL", 1)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	LISTENING_PORT, err = utils.GetIntEnv("LISTENING_PORT", 3000)
+	c.LISTENING_PORT, err = utils.GetIntEnv("LISTENING_PORT", 3000)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	API_URL = utils.GetEnv("API_URL", fmt.Sprintf("http://localhost:%d", LISTENING_PORT))
+	c.API_URL = utils.GetEnv("API_URL", fmt.Sprintf("http://localhost:%d", c.LISTENING_PORT))
 
-	CORS_ALLOWED_ORIGINS = utils.GetEnv("CORS_ALLOWED_ORIGINS", "")
+	c.CORS_ALLOWED_ORIGINS = utils.GetEnv("CORS_ALLOWED_ORIGINS", "")
 
 	// If set, in the authorization cookie will be set the domain
-	AUTHORIZATION_COOKIE_DOMAIN = utils.GetEnv("AUTHORIZATION_COOKIE_DOMAIN", "")
+	c.AUTHORIZATION_COOKIE_DOMAIN = utils.GetEnv("AUTHORIZATION_COOKIE_DOMAIN", "")
+
+	c.AUTHORIZATION_COOKIE_HTTPONLY, err = utils.GetBoolEnv("AUTHORIZATION_COOKIE_HTTPONLY", false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AUTHORIZATION_COOKIE_SECURE, err = utils.GetBoolEnv("AUTHORIZATION_COOKIE_SECURE", false)
+	if err != nil {
+		return nil, err
+	}
+
+	c.AUTHORIZATION_COOKIE_SAMESITE = utils.GetEnv("AUTHORIZATION_COOKIE_SAMESITE", "lax")
+	if !utils.Contains([]string{"lax", "strict", "disabled", "none"}, strings.ToLower(c.AUTHORIZATION_COOKIE_SAMESITE)) {
+		return nil, fmt.Errorf("AUTHORIZATION_COOKIE_SAMESITE must be one of lax, strict, disabled, none")
+	}
+
+	c.AUTHORIZATION_EXPIRATION_TIME, err = utils.GetIntEnv("AUTH_EXPIRATION_TIME", 2678400)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ENABLE_API, err = utils.GetBoolEnv("ENABLE_API", true)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ENABLE_SUBSTITUTIONS_SCHEDULER, err = utils.GetBoolEnv("ENABLE_SUBSTITUTIONS_SCHEDULER", true)
+	if err != nil {
+		return nil, err
+	}
+	c.SUBSTITUTIONS_UPDATECRON = utils.GetEnv("SUBSTITUTIONS_UPDATECRON", "*/10 6-23 * * *")
+	c.MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	c.MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	c.MAX_ERROS_TO_STOP_UPDATING_MOODLE_FORUMS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_MOODLE_FORUMS", 5)
+	if err != nil {
+		return nil, err
+	}
 
-	AUTHORIZATION_COOKIE_HTTPONLY, err = utils.GetBoolEnv("AUTHORIZATION_COOKIE_HTTPONLY", false)
+	c.MAX_ERROS_TO_STOP_UPDATING_MOODLE_GRADES, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_MOODLE_GRADES", 5)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	AUTHORIZATION_COOKIE_SECURE, err = utils.GetBoolEnv("AUTHORIZATION_COOKIE_SECURE", false)
+	c.MAX_ERROS_TO_STOP_SYNCING_MOODLE_CALENDARS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_SYNCING_MOODLE_CALENDARS", 5)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	AUTHORIZATION_COOKIE_SAMESITE = utils.GetEnv("AUTHORIZATION_COOKIE_SAMESITE", "lax")
-	if !utils.Contains([]string{"lax", "strict", "disabled", "none"}, strings.ToLower(AUTHORIZATION_COOKIE_SAMESITE)) {
-		return fmt.Errorf("AUTHORIZATION_COOKIE_SAMESITE must be one of lax, strict, disabled, none")
+	c.MAX_ERROS_TO_STOP_SENDING_MOODLE_REMINDERS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_SENDING_MOODLE_REMINDERS", 5)
+	if err != nil {
+		return nil, err
+	}
+
+	c.MOODLE_UPDATECRON = utils.GetEnv("MOODLE_UPDATECRON", "0 6-23 * * *")
+
+	c.MOODLE_REMINDER_OFFSETS = utils.GetEnv("MOODLE_REMINDER_OFFSETS", "24h,2h")
+	if _, err := utils.ParseDurations(c.MOODLE_REMINDER_OFFSETS); err != nil {
+		return nil, fmt.Errorf("can't convert enviroment variable to durations: MOODLE_REMINDER_OFFSETS (Value: %v)", c.MOODLE_REMINDER_OFFSETS)
+	}
+
+	c.MOODLE_REMINDER_CRON = utils.GetEnv("MOODLE_REMINDER_CRON", "*/5 * * * *")
+
+	c.MOODLE_FORUM_UPDATECRON = utils.GetEnv("MOODLE_FORUM_UPDATECRON", "30 6-23 * * *")
+
+	c.MOODLE_GRADE_UPDATECRON = utils.GetEnv("MOODLE_GRADE_UPDATECRON", "45 6-23 * * *")
+
+	c.MOODLE_CALENDAR_SYNCCRON = utils.GetEnv("MOODLE_CALENDAR_SYNCCRON", "50 6-23 * * *")
+
+	c.DIGEST_TIMES = utils.GetEnv("DIGEST_TIMES", "06:30,18:00")
+	if _, err := utils.ParseClocks(c.DIGEST_TIMES); err != nil {
+		return nil, fmt.Errorf("can't convert enviroment variable to times of day: DIGEST_TIMES (Value: %v)", c.DIGEST_TIMES)
+	}
+
+	c.NOTIFICATION_OUTBOX_CRON = utils.GetEnv("NOTIFICATION_OUTBOX_CRON", "*/5 * * * *")
+
+	c.UPDATER_CONCURRENCY, err = utils.GetIntEnv("UPDATER_CONCURRENCY", 4)
+	if err != nil {
+		return nil, err
 	}
 
-	AUTHORIZATION_EXPIRATION_TIME, err = utils.GetIntEnv("AUTH_EXPIRATION_TIME", 2678400)
+	c.UPDATER_JOB_TIMEOUT, err = utils.GetIntEnv("UPDATER_JOB_TIMEOUT", 60)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ENABLE_API, err = utils.GetBoolEnv("ENABLE_API", true)
+	c.SUBSTITUTION_RATE_LIMIT, err = utils.GetIntEnv("SUBSTITUTION_RATE_LIMIT", 5)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	ENABLE_SUBSTITUTIONS_SCHEDULER, err = utils.GetBoolEnv("ENABLE_SUBSTITUTIONS_SCHEDULER", true)
+	c.MOODLE_RATE_LIMIT, err = utils.GetIntEnv("MOODLE_RATE_LIMIT", 5)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	SUBSTITUTIONS_UPDATECRON = utils.GetEnv("SUBSTITUTIONS_UPDATECRON", "*/10 6-23 * * *")
-	MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_SUBSTITUTIONS", 5)
+
+	c.SUBSTITUTION_PERIOD_TIMES = utils.GetEnv("SUBSTITUTION_PERIOD_TIMES", "")
+
+	c.DATABASE_URI = utils.GetEnv("DATABASE_URI", "db.sqlite")
+	c.DATABASE_TYPE = utils.GetEnv("DATABASE_TYPE", "SQLITE")
+
+	c.DATABASE_AUTOMIGRATE, err = utils.GetBoolEnv("DATABASE_AUTOMIGRATE", true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS, err = utils.GetIntEnv("MAX_ERROS_TO_STOP_UPDATING_MOODLE_ASSIGNMENTS", 5)
+	c.DATABASE_ENCRYPTION_KEY, err = utils.GetEnvInDev("DATABASE_ENCRYPTION_KEY", "secret")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	MOODLE_UPDATECRON = utils.GetEnv("MOODLE_UPDATECRON", "0 6-23 * * *")
-	DATABASE_URI = utils.GetEnv("DATABASE_URI", "db.sqlite")
-	DATABASE_TYPE = utils.GetEnv("DATABASE_TYPE", "SQLITE")
+	c.SIGNAL_CLI_GRPC_API_URL, err = utils.GetEnvInDev("SIGNAL_CLI_GRPC_API_URL", "localhost:9000")
+	if err != nil {
+		return nil, err
+	}
+
+	c.SIGNAL_SENDER_PHONENUMBER, err = utils.GetEnvInDev("SIGNAL_SENDER_PHONENUMBER", "+1555123456")
+	if err != nil {
+		return nil, err
+	}
 
-	DATABASE_AUTOMIGRATE, err = utils.GetBoolEnv("DATABASE_AUTOMIGRATE", true)
+	c.ENABLE_SIGNAL_COMMANDS, err = utils.GetBoolEnv("ENABLE_SIGNAL_COMMANDS", false)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	SIGNAL_CLI_GRPC_API_URL, err = utils.GetEnvInDev("SIGNAL_CLI_GRPC_API_URL", "localhost:9000")
+	c.SIGNAL_RECEIVE_TIMEOUT, err = utils.GetIntEnv("SIGNAL_RECEIVE_TIMEOUT", 10)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	SIGNAL_SENDER_PHONENUMBER, err = utils.GetEnvInDev("SIGNAL_SENDER_PHONENUMBER", "+1555123456")
+	c.SMTP_HOST = utils.GetEnv("SMTP_HOST", "")
+
+	c.SMTP_PORT, err = utils.GetIntEnv("SMTP_PORT", 587)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	JWT_SECRET, err = utils.GetEnvInDev("JWT_SECRET", "secret")
+	c.SMTP_USERNAME = utils.GetEnv("SMTP_USERNAME", "")
+
+	c.SMTP_PASSWORD = utils.GetEnv("SMTP_PASSWORD", "")
+
+	c.SMTP_FROM = utils.GetEnv("SMTP_FROM", c.SMTP_USERNAME)
+
+	c.TELEGRAM_BOT_TOKEN = utils.GetEnv("TELEGRAM_BOT_TOKEN", "")
+
+	c.JWT_SECRET, err = utils.GetEnvInDev("JWT_SECRET", "secret")
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	JWT_SHORTLIVING_SECRET = utils.GenerateString(128)
+	c.JWT_SHORTLIVING_SECRET = utils.GenerateString(128)
+
+	c.ADMIN_USERNAMES = utils.GetEnv("ADMIN_USERNAMES", "")
 
-	SUBSTITUTION_URL = utils.GetEnv("SUBSTITUTION_URL", "")
+	c.SUBSTITUTION_URL = utils.GetEnv("SUBSTITUTION_URL", "")
 
-	MOODLE_URL = utils.GetEnv("MOODLE_URL", "")
+	c.MOODLE_URL = utils.GetEnv("MOODLE_URL", "")
 
-	LOGGING_FILE = utils.GetEnv("LOGGING_FILE", "")
+	c.LOGGING_FILE = utils.GetEnv("LOGGING_FILE", "")
 
-	LOG_LEVEL, err = utils.GetIntEnv("LOG_LEVEL", 2)
+	c.LOG_LEVEL, err = utils.GetIntEnv("LOG_LEVEL", 2)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	PATH_TO_API_VIEWS = utils.GetEnv("PATH_TO_API_VIEWS", "./api/providers/rest/views")
+	c.PATH_TO_API_VIEWS = utils.GetEnv("PATH_TO_API_VIEWS", "./api/providers/rest/views")
 
-	PATH_TO_API_STATIC = utils.GetEnv("PATH_TO_API_STATIC", "./api/providers/rest/static")
+	c.PATH_TO_API_STATIC = utils.GetEnv("PATH_TO_API_STATIC", "./api/providers/rest/static")
 
-	CONTACT_EMAIL = utils.GetEnv("CONTACT_EMAIL", "")
+	c.CONTACT_EMAIL = utils.GetEnv("CONTACT_EMAIL", "")
 
-	CONTACT_INSTAGRAM = utils.GetEnv("CONTACT_INSTAGRAM", "")
+	c.CONTACT_INSTAGRAM = utils.GetEnv("CONTACT_INSTAGRAM", "")
 
-	return nil
+	return c, nil
 }
@@ -3,17 +3,37 @@ package database
 import (
 	"github.com/dattito/purrmannplus-backend/config"
 	"github.com/dattito/purrmannplus-backend/database/providers"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
 )
 
-var DB provider.Provider
+// Opens the connection to the database without changing it
+func Connect(cfg *config.Config) (provider.Provider, error) {
+	return provider.GetProvider(cfg)
+}
+
+// Opens the connection to the database, applies the pending migrations if enabled and encrypts old credentials
+func Init(cfg *config.Config) (provider.Provider, error) {
+	db, err := Connect(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-func Init() error {
-	var err error
-	DB, err = provider.GetProvider()
+	if cfg.DATABASE_AUTOMIGRATE {
+		count, err := db.MigrateUp()
+		if err != nil {
+			return nil, err
+		}
+
+		if count > 0 {
+			logging.Infof("Applied %d database migrations", count)
+		}
+	}
 
-	if config.DATABASE_AUTOMIGRATE {
-		return DB.CreateTables()
+	// Credentials stored before the encryption was introduced are encrypted once
+	count, err := db.EncryptCredentials()
+	if count > 0 {
+		logging.Infof("Encrypted %d stored credentials", count)
 	}
 
-	return err
+	return db, err
 }
@@ -2,11 +2,13 @@ package gorm
 
 import (
 	"errors"
+	"time"
 
 	app_models "github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/config"
 	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/dattito/purrmannplus-backend/database/providers/gorm/migrations"
 	"github.com/dattito/purrmannplus-backend/database/providers/gorm/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
 	"gorm.io/driver/mysql"
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -15,16 +17,19 @@ import (
 )
 
 type GormProvider struct {
-	DB *gorm.DB
+	DB      *gorm.DB
+	Dialect string             // The database type: SQLITE, POSTGRES or MYSQL
+	cipher  *encryption.Cipher // Encrypts the stored credentials
 }
 
-// Returns a GormProvider object with a connection to the database
-func NewGormProvider() (*GormProvider, error) {
+// Returns a GormProvider object with a connection to the database of the given type,
+// the stored credentials are encrypted with a key derived from encryptionKey
+func NewGormProvider(databaseType, uri string, logLevel int, encryptionKey string) (*GormProvider, error) {
 
 	type Open func(string) gorm.Dialector
 	var o Open
 
-	switch config.DATABASE_TYPE {
+	switch databaseType {
 	case "POSTGRES":
 		o = postgres.Open
 	case "MYSQL":
@@ -32,17 +37,17 @@ func NewGormProvider() (*GormProvider, error) {
 	case "SQLITE":
 		o = sqlite.Open
 	default:
-		return &GormProvider{}, errors.New("DATABASE_TYPE env has to one of ('POSTGRES', 'MYSQL', 'SQLITE')")
+		return &GormProvider{}, errors.New("DATABASE_TYPE env has to one of ('POSTGRES', 'MYSQL', 'SQLITE', 'MEMORY')")
 	}
 
-	db, err := gorm.Open(o(config.DATABASE_URI), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.LogLevel(config.DATABASE_LOG_LEVEL)),
+	db, err := gorm.Open(o(uri), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.LogLevel(logLevel)),
 	})
 	if err != nil {
 		return &GormProvider{}, err
 	}
 
-	return &GormProvider{DB: db}, nil
+	return &GormProvider{DB: db, Dialect: databaseType, cipher: encryption.NewCipher(encryptionKey)}, nil
 }
 
 // Creates all tables in the database using AutoMigrate()
@@ -67,9 +72,170 @@ func (g *GormProvider) CreateTables() error {
 	if err != nil {
 		return err
 	}
+
+	err = g.DB.AutoMigrate(&models.MoodleAssignmentDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleReminderDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleCourseSettingDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleForumUpdaterDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleDiscussionDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleGradeUpdaterDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleGradeDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.CalendarTokenDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.MoodleCalendarEventDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.NotificationSettingDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.NotificationPreferenceDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.OutboxMessageDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.JobRunDB{})
+	if err != nil {
+		return err
+	}
+
+	err = g.DB.AutoMigrate(&models.SendFailureDB{})
+	if err != nil {
+		return err
+	}
 	return nil
 }
 
+// Returns the migrator of the database type. Databases which were created by
+// AutoMigrate() are adopted by running AutoMigrate() once instead of the baseline migration.
+func (g *GormProvider) migrator() (*migrations.Migrator, error) {
+	return migrations.NewMigrator(g.DB, g.Dialect, func(tx *gorm.DB) (bool, error) {
+		if !tx.Migrator().HasTable(&models.AccountDB{}) {
+			return false, nil
+		}
+
+		return true, (&GormProvider{DB: tx}).CreateTables()
+	})
+}
+
+// Applies all pending versioned migrations, returns the number of applied migrations
+func (g *GormProvider) MigrateUp() (int, error) {
+	m, err := g.migrator()
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Up()
+}
+
+// Reverts the given number of versioned migrations, returns the number of reverted migrations
+func (g *GormProvider) MigrateDown(steps int) (int, error) {
+	m, err := g.migrator()
+	if err != nil {
+		return 0, err
+	}
+
+	return m.Down(steps)
+}
+
+// Returns all versioned migrations and whether they are applied
+func (g *GormProvider) GetMigrationStatus() ([]app_models.MigrationStatus, error) {
+	m, err := g.migrator()
+	if err != nil {
+		return nil, err
+	}
+
+	ss, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []app_models.MigrationStatus{}
+	for _, s := range ss {
+		statuses = append(statuses, app_models.MigrationStatus{
+			Version:   s.Version,
+			Name:      s.Name,
+			Applied:   s.Applied,
+			AppliedAt: s.AppliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Encrypts all credentials which are still stored as plaintext, returns the number of encrypted values
+func (g *GormProvider) EncryptCredentials() (int, error) {
+	type credential struct {
+		Id     string `gorm:"column:id"`
+		AuthPw string `gorm:"column:auth_pw"`
+	}
+
+	count := 0
+	for _, table := range []string{models.AccountDB{}.TableName(), models.SubstitutionDB{}.TableName()} {
+		var credentials []credential
+		if err := g.DB.Table(table).Select("id", "auth_pw").Scan(&credentials).Error; err != nil {
+			return count, err
+		}
+
+		for _, c := range credentials {
+			if c.AuthPw == "" || encryption.IsEncrypted(c.AuthPw) {
+				continue
+			}
+
+			encrypted, err := g.cipher.Encrypt(c.AuthPw)
+			if err != nil {
+				return count, err
+			}
+
+			if err := g.DB.Table(table).Where("id = ?", c.Id).UpdateColumn("auth_pw", encrypted).Error; err != nil {
+				return count, err
+			}
+			count++
+		}
+	}
+
+	return count, nil
+}
+
 // Closes the database connection
 func (g *GormProvider) CloseDB() error {
 	dialect, err := g.DB.DB()
@@ -81,15 +247,24 @@ func (g *GormProvider) CloseDB() error {
 	return nil
 }
 
-// Adds an account with it's credendials (username=authId, password=authPw) to the database
-func (g *GormProvider) AddAccount(username, password string) (app_models.Account, error) {
+// Adds an account with it's credendials (username=authId, password=authPw) and the hash of the login password to the database
+func (g *GormProvider) AddAccount(username, password, passwordHash string) (app_models.Account, error) {
+
+	encryptedPassword, err := models.NewEncryptedString(g.cipher, password)
+	if err != nil {
+		return app_models.Account{}, err
+	}
 
 	accdb := models.AccountDB{
-		Username: username,
-		Password: password,
+		Username:     username,
+		Password:     encryptedPassword,
+		PasswordHash: passwordHash,
 	}
-	err := g.DB.Create(&accdb).Error
-	return accdb.ToAccount(), err
+	if err := g.DB.Create(&accdb).Error; err != nil {
+		return app_models.Account{}, err
+	}
+
+	return accdb.ToAccount(g.cipher)
 }
 
 // Returns account object of given accountId
@@ -104,15 +279,15 @@ func (g *GormProvider) GetAccount(id string) (app_models.Account, error) {
 		return app_models.Account{}, err
 	}
 
-	return accdb.ToAccount(), nil
+	return accdb.ToAccount(g.cipher)
 }
 
-// Gets account using username (authId) and password (authPw)
-func (g *GormProvider) GetAccountByCredentials(username, password string) (app_models.Account, error) {
+// Gets account using the username (authId)
+func (g *GormProvider) GetAccountByUsername(username string) (app_models.Account, error) {
 
 	accdb := models.AccountDB{}
 
-	err := g.DB.First(&accdb, "auth_id = ? AND auth_pw = ?", username, password).Error
+	err := g.DB.First(&accdb, "auth_id = ?", username).Error
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -121,7 +296,42 @@ func (g *GormProvider) GetAccountByCredentials(username, password string) (app_m
 		return app_models.Account{}, err
 	}
 
-	return accdb.ToAccount(), nil
+	return accdb.ToAccount(g.cipher)
+}
+
+// Updates the stored moodle password (authPw) of an account
+func (g *GormProvider) SetAccountPassword(accountId, password string) error {
+	value, err := models.NewEncryptedString(g.cipher, password)
+	if err != nil {
+		return err
+	}
+
+	return g.DB.Model(&models.AccountDB{}).Where("id = ?", accountId).Update("auth_pw", value).Error
+}
+
+// Updates the hash of the login password of an account
+func (g *GormProvider) SetAccountPasswordHash(accountId, passwordHash string) error {
+	return g.DB.Model(&models.AccountDB{}).Where("id = ?", accountId).Update("login_pw_hash", passwordHash).Error
+}
+
+// Updates the cached moodle webservice token of an account
+func (g *GormProvider) SetAccountMoodleToken(accountId, token string) error {
+	value, err := models.NewEncryptedString(g.cipher, token)
+	if err != nil {
+		return err
+	}
+
+	return g.DB.Model(&models.AccountDB{}).Where("id = ?", accountId).Update("moodle_token", value).Error
+}
+
+// Sets the role of an account (user or admin)
+func (g *GormProvider) SetAccountRole(accountId, role string) error {
+	return g.DB.Model(&models.AccountDB{}).Where("id = ?", accountId).Update("role", role).Error
+}
+
+// Disables or enables an account
+func (g *GormProvider) SetAccountDisabled(accountId string, disabled bool) error {
+	return g.DB.Model(&models.AccountDB{}).Where("id = ?", accountId).Update("disabled", disabled).Error
 }
 
 // what do think it does?
@@ -140,7 +350,11 @@ func (g *GormProvider) GetAccounts() ([]app_models.Account, error) {
 
 	var accs []app_models.Account
 	for _, v := range accdb {
-		accs = append(accs, v.ToAccount())
+		acc, err := v.ToAccount(g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		accs = append(accs, acc)
 	}
 
 	return accs, nil
@@ -177,6 +391,158 @@ func (g *GormProvider) GetAccountInfo(accountId string) (app_models.AccountInfo,
 	return accInfo.ToAccountInfo(), err
 }
 
+// Returns the ids of the accounts the phone number belongs to, the account the number was added to last comes first
+func (g *GormProvider) GetAccountIdsByPhoneNumber(phoneNumber string) ([]string, error) {
+	accInfos := []models.AccountInfoDB{}
+	err := g.DB.Order("updated_at DESC").Find(&accInfos, "phone_number = ?", phoneNumber).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if len(accInfos) == 0 {
+		return nil, &db_errors.ErrRecordNotFound
+	}
+
+	var accountIds []string
+	for _, accInfo := range accInfos {
+		accountIds = append(accountIds, accInfo.AccountId)
+	}
+	return accountIds, nil
+}
+
+// Returns the notification preference of an account, accounts without a stored preference aren't paused
+func (g *GormProvider) GetNotificationPreference(accountId string) (app_models.NotificationPreference, error) {
+	n := models.NotificationPreferenceDB{}
+	err := g.DB.First(&n, "account_id = ?", accountId).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return app_models.NotificationPreference{AccountId: accountId}, nil
+		}
+		return app_models.NotificationPreference{}, err
+	}
+	return n.ToNotificationPreference(), nil
+}
+
+// Sets the notification preference of an account, creates the preference if it doesn't exist yet
+func (g *GormProvider) SetNotificationPreference(p app_models.NotificationPreference) (app_models.NotificationPreference, error) {
+	n := models.NotificationPreferenceDB{}
+
+	if err := g.DB.Where(models.NotificationPreferenceDB{AccountId: p.AccountId}).FirstOrCreate(&n).Error; err != nil {
+		return app_models.NotificationPreference{}, err
+	}
+
+	n.Paused = p.Paused
+	n.PausedUntil = nil
+	if !p.PausedUntil.IsZero() {
+		pausedUntil := p.PausedUntil
+		n.PausedUntil = &pausedUntil
+	}
+	n.OptedOut = p.OptedOut
+	n.QuietHoursStart = p.QuietHoursStart
+	n.QuietHoursEnd = p.QuietHoursEnd
+	n.Digest = p.Digest
+
+	if err := g.DB.Save(&n).Error; err != nil {
+		return app_models.NotificationPreference{}, err
+	}
+
+	return n.ToNotificationPreference(), nil
+}
+
+// Queues a notification until it may be delivered
+func (g *GormProvider) AddOutboxMessage(accountId, phoneNumber, message string) error {
+	return g.DB.Create(&models.OutboxMessageDB{
+		AccountId:   accountId,
+		PhoneNumber: phoneNumber,
+		Message:     message,
+	}).Error
+}
+
+// Returns all queued notifications, oldest first
+func (g *GormProvider) GetOutboxMessages() ([]app_models.OutboxMessage, error) {
+	os := []models.OutboxMessageDB{}
+
+	if err := g.DB.Order("created_at ASC").Find(&os).Error; err != nil {
+		return []app_models.OutboxMessage{}, err
+	}
+
+	messages := []app_models.OutboxMessage{}
+	for _, o := range os {
+		messages = append(messages, o.ToOutboxMessage())
+	}
+
+	return messages, nil
+}
+
+// Removes the queued notifications with the given ids
+func (g *GormProvider) RemoveOutboxMessages(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return g.DB.Where("id IN ?", ids).Delete(&models.OutboxMessageDB{}).Error
+}
+
+// Sets the recipient of a notification channel of an account, creates the setting if it doesn't exist yet
+func (g *GormProvider) SetNotificationSetting(accountId, channel, recipient string) (app_models.NotificationSetting, error) {
+	n := models.NotificationSettingDB{}
+
+	if err := g.DB.Where(models.NotificationSettingDB{AccountId: accountId, Channel: channel}).FirstOrCreate(&n).Error; err != nil {
+		return app_models.NotificationSetting{}, err
+	}
+
+	n.Recipient = recipient
+
+	err := g.DB.Save(&n).Error
+	return n.ToNotificationSetting(), err
+}
+
+// Returns all notification channels of an account
+func (g *GormProvider) GetNotificationSettings(accountId string) ([]app_models.NotificationSetting, error) {
+	ns := []models.NotificationSettingDB{}
+
+	if err := g.DB.Find(&ns, "account_id = ?", accountId).Error; err != nil {
+		return []app_models.NotificationSetting{}, err
+	}
+
+	settings := []app_models.NotificationSetting{}
+	for _, n := range ns {
+		settings = append(settings, n.ToNotificationSetting())
+	}
+
+	return settings, nil
+}
+
+// Removes a notification channel of an account
+func (g *GormProvider) RemoveNotificationSetting(accountId, channel string) error {
+	return g.DB.Delete(&models.NotificationSettingDB{}, "account_id = ? AND channel = ?", accountId, channel).Error
+}
+
+// Stores a notification which couldn't be sent
+func (g *GormProvider) AddSendFailure(accountId, channel, sendError string) error {
+	return g.DB.Create(&models.SendFailureDB{
+		AccountId: accountId,
+		Channel:   channel,
+		Error:     sendError,
+	}).Error
+}
+
+// Returns the latest notifications which couldn't be sent, newest first
+func (g *GormProvider) GetSendFailures(limit int) ([]app_models.SendFailure, error) {
+	ss := []models.SendFailureDB{}
+
+	if err := g.DB.Order("created_at DESC").Limit(limit).Find(&ss).Error; err != nil {
+		return []app_models.SendFailure{}, err
+	}
+
+	failures := []app_models.SendFailure{}
+	for _, s := range ss {
+		failures = append(failures, s.ToSendFailure())
+	}
+
+	return failures, nil
+}
+
 // Removes an account from the substitution_updater table if exists
 func (g *GormProvider) RemoveAccountFromSubstitutionUpdater(accountId string) error {
 
@@ -198,10 +564,15 @@ func (g *GormProvider) AddAccountToSubstitution(accountId, authId, authPw string
 		return errors.New("account already registered in substitution updater")
 	}
 
+	encryptedAuthPw, err := models.NewEncryptedString(g.cipher, authPw)
+	if err != nil {
+		return err
+	}
+
 	substitution := models.SubstitutionDB{
 		AccountId: accountId,
 		AuthId:    authId,
-		AuthPw:    authPw,
+		AuthPw:    encryptedAuthPw,
 		Entries:   &models.Entries{},
 		NotSetYet: true,
 	}
@@ -210,7 +581,7 @@ func (g *GormProvider) AddAccountToSubstitution(accountId, authId, authPw string
 }
 
 // Updates the substitution of a given account
-func (g *GormProvider) SetSubstitutions(accountId string, entries map[string][]string, NotSetYet bool) error {
+func (g *GormProvider) SetSubstitutions(accountId string, entries []app_models.Substitution, NotSetYet bool) error {
 
 	var entriesE models.Entries = entries
 
@@ -256,11 +627,15 @@ func (g *GormProvider) GetSubstitutions(accountId string) (app_models.Substituti
 func (g *GormProvider) GetAllSubstitutionInfos() ([]app_models.SubstitutionInfo, error) {
 	m := []models.SubstitutionInfoDB{}
 
-	g.DB.Model(models.AccountDB{}).Select("account_infos.phone_number", "accounts.id AS 'account_id'", "substitutions.auth_id", "substitutions.auth_pw", "substitutions.id AS 'substitutions_id'", "substitutions.entries", "substitutions.not_set_yet").Joins("INNER JOIN substitutions ON substitutions.account_id = accounts.id").Joins("INNER JOIN account_infos ON account_infos.account_id = accounts.id").Scan(&m)
+	g.DB.Model(models.AccountDB{}).Select("COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "substitutions.auth_id", "substitutions.auth_pw", "substitutions.id AS 'substitutions_id'", "substitutions.entries", "substitutions.not_set_yet").Joins("INNER JOIN substitutions ON substitutions.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.disabled = ?", false).Scan(&m)
 
 	var mm []app_models.SubstitutionInfo
 	for _, v := range m {
-		mm = append(mm, v.ToSubstitutionInfo())
+		info, err := v.ToSubstitutionInfo(g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, info)
 	}
 
 	return mm, nil
@@ -269,14 +644,14 @@ func (g *GormProvider) GetAllSubstitutionInfos() ([]app_models.SubstitutionInfo,
 // Returns the accountId, auth_id, auth_pw, phone_number, substitutions_id and the substitutions of a given account
 func (g *GormProvider) GetSubstitutionInfos(accountId string) (app_models.SubstitutionInfo, error) {
 	m := models.SubstitutionInfoDB{}
-	err := g.DB.Model(models.AccountDB{}).Select("account_infos.phone_number", "accounts.id AS 'account_id'", "substitutions.auth_id", "substitutions.auth_pw", "substitutions.id AS 'substitutions_id'", "substitutions.entries", "substitutions.not_set_yet").Joins("INNER JOIN substitutions ON substitutions.account_id = accounts.id").Joins("INNER JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
+	err := g.DB.Model(models.AccountDB{}).Select("COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "substitutions.auth_id", "substitutions.auth_pw", "substitutions.id AS 'substitutions_id'", "substitutions.entries", "substitutions.not_set_yet").Joins("INNER JOIN substitutions ON substitutions.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return app_models.SubstitutionInfo{}, &db_errors.ErrRecordNotFound
 		}
 		return app_models.SubstitutionInfo{}, err
 	}
-	return m.ToSubstitutionInfo(), nil
+	return m.ToSubstitutionInfo(g.cipher)
 }
 
 func (g *GormProvider) AddAccountToMoodleAssignmentUpdater(accountId string) error {
@@ -297,41 +672,76 @@ func (g *GormProvider) AddAccountToMoodleAssignmentUpdater(accountId string) err
 	return g.DB.Create(&moodleAssignmentUpdater).Error
 }
 
-func (g *GormProvider) SetMoodleAssignments(accountId string, assignmentIds []int, notSetYet bool) error {
+// Replaces the stored moodle assignments of an account
+func (g *GormProvider) SetMoodleAssignments(accountId string, assignments []app_models.MoodleAssignment, notSetYet bool) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		m := models.MoodleUserAssignmentsDB{
+			AccountId: accountId,
+		}
 
-	var assignmentIdsE models.AssignmentIds = assignmentIds
+		if err := tx.FirstOrCreate(&m, "account_id = ?", accountId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &db_errors.ErrRecordNotFound
+			}
+			return err
+		}
 
-	m := models.MoodleUserAssignmentsDB{
-		AccountId: accountId,
-	}
+		m.AssignmentIds = &models.AssignmentIds{}
+		m.NotSetYet = notSetYet
 
-	if err := g.DB.FirstOrCreate(&m, "account_id = ?", accountId).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &db_errors.ErrRecordNotFound
+		if err := tx.Save(&m).Error; err != nil {
+			return err
 		}
-		return err
-	}
 
-	m.AssignmentIds = &assignmentIdsE
-	m.NotSetYet = notSetYet
+		if err := tx.Where("account_id = ?", accountId).Delete(&models.MoodleAssignmentDB{}).Error; err != nil {
+			return err
+		}
 
-	err := g.DB.Save(&m).Error
-	if err != nil {
-		return err
-	}
+		if len(assignments) == 0 {
+			return nil
+		}
 
-	return nil
+		var ms []models.MoodleAssignmentDB
+		for _, a := range assignments {
+			ms = append(ms, models.NewMoodleAssignmentDB(accountId, a))
+		}
+
+		return tx.Create(&ms).Error
+	})
 }
 
 func (g *GormProvider) RemoveAccountFromMoodleAssignmentUpdater(accountId string) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.MoodleAssignmentDB{}, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
 
-	if err := g.DB.Delete(&models.MoodleUserAssignmentsDB{}, "account_id = ?", accountId).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &db_errors.ErrRecordNotFound
+		if err := tx.Delete(&models.MoodleUserAssignmentsDB{}, "account_id = ?", accountId).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &db_errors.ErrRecordNotFound
+			}
+			return err
 		}
-		return err
+		return nil
+	})
+}
+
+// Returns the stored moodle assignments of the given accounts, grouped by account id
+func (g *GormProvider) getMoodleAssignmentsOfAccounts(accountIds []string) (map[string][]models.MoodleAssignmentDB, error) {
+	assignments := map[string][]models.MoodleAssignmentDB{}
+	if len(accountIds) == 0 {
+		return assignments, nil
 	}
-	return nil
+
+	ms := []models.MoodleAssignmentDB{}
+	if err := g.DB.Where("account_id IN ?", accountIds).Order("assignment_id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	for _, m := range ms {
+		assignments[m.AccountId] = append(assignments[m.AccountId], m)
+	}
+	return assignments, nil
 }
 
 func (g *GormProvider) GetMoodleAssignments(accountId string) (app_models.MoodleAssignments, error) {
@@ -346,17 +756,36 @@ func (g *GormProvider) GetMoodleAssignments(accountId string) (app_models.Moodle
 		return app_models.MoodleAssignments{}, err
 	}
 
-	return m.ToMoodleAssignments(), nil
+	assignments, err := g.getMoodleAssignmentsOfAccounts([]string{accountId})
+	if err != nil {
+		return app_models.MoodleAssignments{}, err
+	}
+
+	return m.ToMoodleAssignments(assignments[accountId]), nil
 }
 
 func (g *GormProvider) GetAllMoodleAssignmentInfos() ([]app_models.MoodleAssignmentInfo, error) {
 	m := []models.MoodleAssignmentInfoDB{}
 
-	g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "account_infos.phone_number", "accounts.id AS 'account_id'", "moodle_user_assignments.assignment_ids", "moodle_user_assignments.not_set_yet").Joins("INNER JOIN moodle_user_assignments ON moodle_user_assignments.account_id = accounts.id").Joins("INNER JOIN account_infos ON account_infos.account_id = accounts.id").Scan(&m)
+	g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_user_assignments.assignment_ids", "moodle_user_assignments.not_set_yet").Joins("INNER JOIN moodle_user_assignments ON moodle_user_assignments.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.disabled = ?", false).Scan(&m)
+
+	var accountIds []string
+	for _, v := range m {
+		accountIds = append(accountIds, v.AccountId)
+	}
+
+	assignments, err := g.getMoodleAssignmentsOfAccounts(accountIds)
+	if err != nil {
+		return nil, err
+	}
 
 	var mm []app_models.MoodleAssignmentInfo
 	for _, v := range m {
-		mm = append(mm, v.ToMoodleAssignmentInfo())
+		info, err := v.ToMoodleAssignmentInfo(assignments[v.AccountId], g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, info)
 	}
 
 	return mm, nil
@@ -364,12 +793,490 @@ func (g *GormProvider) GetAllMoodleAssignmentInfos() ([]app_models.MoodleAssignm
 
 func (g *GormProvider) GetMoodleAssignmentInfos(accountId string) (app_models.MoodleAssignmentInfo, error) {
 	m := models.MoodleAssignmentInfoDB{}
-	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "account_infos.phone_number", "accounts.id AS 'account_id'", "moodle_user_assignments.assignment_ids", "moodle_user_assignments.not_set_yet").Joins("INNER JOIN moodle_user_assignments ON moodle_user_assignments.account_id = accounts.id").Joins("INNER JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_user_assignments.assignment_ids", "moodle_user_assignments.not_set_yet").Joins("INNER JOIN moodle_user_assignments ON moodle_user_assignments.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return app_models.MoodleAssignmentInfo{}, &db_errors.ErrRecordNotFound
 		}
 		return app_models.MoodleAssignmentInfo{}, err
 	}
-	return m.ToMoodleAssignmentInfo(), nil
+
+	if m.AccountId == "" {
+		return app_models.MoodleAssignmentInfo{}, &db_errors.ErrRecordNotFound
+	}
+
+	assignments, err := g.getMoodleAssignmentsOfAccounts([]string{accountId})
+	if err != nil {
+		return app_models.MoodleAssignmentInfo{}, err
+	}
+
+	return m.ToMoodleAssignmentInfo(assignments[accountId], g.cipher)
+}
+
+// Stores the sent reminders
+func (g *GormProvider) AddMoodleReminders(reminders []app_models.MoodleReminder) error {
+	if len(reminders) == 0 {
+		return nil
+	}
+
+	var ms []models.MoodleReminderDB
+	for _, r := range reminders {
+		ms = append(ms, models.MoodleReminderDB{
+			AccountId:     r.AccountId,
+			AssignmentId:  r.AssignmentId,
+			OffsetSeconds: int64(r.Offset / time.Second),
+			SentAt:        r.SentAt,
+		})
+	}
+
+	return g.DB.Create(&ms).Error
+}
+
+// Returns the reminders which were already sent to an account
+func (g *GormProvider) GetMoodleReminders(accountId string) ([]app_models.MoodleReminder, error) {
+	ms := []models.MoodleReminderDB{}
+	if err := g.DB.Where("account_id = ?", accountId).Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	var reminders []app_models.MoodleReminder
+	for _, m := range ms {
+		reminders = append(reminders, m.ToMoodleReminder())
+	}
+	return reminders, nil
+}
+
+// Creates or updates the settings of an account for a moodle course
+func (g *GormProvider) SetMoodleCourseSetting(accountId string, courseId int, remindersEnabled bool) (app_models.MoodleCourseSetting, error) {
+	m := models.MoodleCourseSettingDB{}
+
+	if err := g.DB.Where(models.MoodleCourseSettingDB{AccountId: accountId, CourseId: courseId}).FirstOrCreate(&m).Error; err != nil {
+		return app_models.MoodleCourseSetting{}, err
+	}
+
+	m.RemindersEnabled = remindersEnabled
+
+	err := g.DB.Save(&m).Error
+	return m.ToMoodleCourseSetting(), err
+}
+
+// Returns the stored settings of an account for its moodle courses
+func (g *GormProvider) GetMoodleCourseSettings(accountId string) ([]app_models.MoodleCourseSetting, error) {
+	ms := []models.MoodleCourseSettingDB{}
+	if err := g.DB.Where("account_id = ?", accountId).Order("course_id").Find(&ms).Error; err != nil {
+		return nil, err
+	}
+
+	var settings []app_models.MoodleCourseSetting
+	for _, m := range ms {
+		settings = append(settings, m.ToMoodleCourseSetting())
+	}
+	return settings, nil
+}
+
+func (g *GormProvider) AddAccountToMoodleForumUpdater(accountId string) error {
+	if _, err := g.GetMoodleForumInfos(accountId); err != nil {
+		if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return err
+		}
+	} else {
+		return errors.New("account already exists in MoodleForumUpdater")
+	}
+
+	return g.DB.Create(&models.MoodleForumUpdaterDB{
+		AccountId: accountId,
+		NotSetYet: true,
+	}).Error
+}
+
+// Replaces the known discussions of an account
+func (g *GormProvider) SetMoodleDiscussions(accountId string, discussionIds []int, notSetYet bool) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		m := models.MoodleForumUpdaterDB{
+			AccountId: accountId,
+		}
+
+		if err := tx.FirstOrCreate(&m, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
+
+		m.NotSetYet = notSetYet
+
+		if err := tx.Save(&m).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("account_id = ?", accountId).Delete(&models.MoodleDiscussionDB{}).Error; err != nil {
+			return err
+		}
+
+		if len(discussionIds) == 0 {
+			return nil
+		}
+
+		var ds []models.MoodleDiscussionDB
+		for _, id := range discussionIds {
+			ds = append(ds, models.MoodleDiscussionDB{AccountId: accountId, DiscussionId: id})
+		}
+
+		return tx.Create(&ds).Error
+	})
+}
+
+func (g *GormProvider) RemoveAccountFromMoodleForumUpdater(accountId string) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.MoodleDiscussionDB{}, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.MoodleForumUpdaterDB{}, "account_id = ?", accountId).Error
+	})
+}
+
+// Returns the known discussions of the given accounts, grouped by account id
+func (g *GormProvider) getMoodleDiscussionsOfAccounts(accountIds []string) (map[string][]models.MoodleDiscussionDB, error) {
+	discussions := map[string][]models.MoodleDiscussionDB{}
+	if len(accountIds) == 0 {
+		return discussions, nil
+	}
+
+	ds := []models.MoodleDiscussionDB{}
+	if err := g.DB.Where("account_id IN ?", accountIds).Order("discussion_id").Find(&ds).Error; err != nil {
+		return nil, err
+	}
+
+	for _, d := range ds {
+		discussions[d.AccountId] = append(discussions[d.AccountId], d)
+	}
+	return discussions, nil
+}
+
+func (g *GormProvider) GetAllMoodleForumInfos() ([]app_models.MoodleForumInfo, error) {
+	m := []models.MoodleForumInfoDB{}
+
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_forum_updaters.not_set_yet").Joins("INNER JOIN moodle_forum_updaters ON moodle_forum_updaters.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.disabled = ?", false).Scan(&m).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var accountIds []string
+	for _, v := range m {
+		accountIds = append(accountIds, v.AccountId)
+	}
+
+	discussions, err := g.getMoodleDiscussionsOfAccounts(accountIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var mm []app_models.MoodleForumInfo
+	for _, v := range m {
+		info, err := v.ToMoodleForumInfo(discussions[v.AccountId], g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, info)
+	}
+
+	return mm, nil
+}
+
+func (g *GormProvider) GetMoodleForumInfos(accountId string) (app_models.MoodleForumInfo, error) {
+	m := models.MoodleForumInfoDB{}
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_forum_updaters.not_set_yet").Joins("INNER JOIN moodle_forum_updaters ON moodle_forum_updaters.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
+	if err != nil {
+		return app_models.MoodleForumInfo{}, err
+	}
+
+	if m.AccountId == "" {
+		return app_models.MoodleForumInfo{}, &db_errors.ErrRecordNotFound
+	}
+
+	discussions, err := g.getMoodleDiscussionsOfAccounts([]string{accountId})
+	if err != nil {
+		return app_models.MoodleForumInfo{}, err
+	}
+
+	return m.ToMoodleForumInfo(discussions[accountId], g.cipher)
+}
+
+func (g *GormProvider) AddAccountToMoodleGradeUpdater(accountId string) error {
+	if _, err := g.GetMoodleGradeInfos(accountId); err != nil {
+		if !errors.Is(err, &db_errors.ErrRecordNotFound) {
+			return err
+		}
+	} else {
+		return errors.New("account already exists in MoodleGradeUpdater")
+	}
+
+	return g.DB.Create(&models.MoodleGradeUpdaterDB{
+		AccountId: accountId,
+		NotSetYet: true,
+	}).Error
+}
+
+// Replaces the grade snapshot of an account
+func (g *GormProvider) SetMoodleGrades(accountId string, grades []app_models.MoodleGrade, notSetYet bool) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		m := models.MoodleGradeUpdaterDB{
+			AccountId: accountId,
+		}
+
+		if err := tx.FirstOrCreate(&m, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
+
+		m.NotSetYet = notSetYet
+
+		if err := tx.Save(&m).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("account_id = ?", accountId).Delete(&models.MoodleGradeDB{}).Error; err != nil {
+			return err
+		}
+
+		if len(grades) == 0 {
+			return nil
+		}
+
+		var gs []models.MoodleGradeDB
+		for _, grade := range grades {
+			gs = append(gs, models.NewMoodleGradeDB(accountId, grade))
+		}
+
+		return tx.Create(&gs).Error
+	})
+}
+
+func (g *GormProvider) RemoveAccountFromMoodleGradeUpdater(accountId string) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.MoodleGradeDB{}, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.MoodleGradeUpdaterDB{}, "account_id = ?", accountId).Error
+	})
+}
+
+// Returns the grade snapshots of the given accounts, grouped by account id
+func (g *GormProvider) getMoodleGradesOfAccounts(accountIds []string) (map[string][]models.MoodleGradeDB, error) {
+	grades := map[string][]models.MoodleGradeDB{}
+	if len(accountIds) == 0 {
+		return grades, nil
+	}
+
+	gs := []models.MoodleGradeDB{}
+	if err := g.DB.Where("account_id IN ?", accountIds).Order("course_id, item_id").Find(&gs).Error; err != nil {
+		return nil, err
+	}
+
+	for _, grade := range gs {
+		grades[grade.AccountId] = append(grades[grade.AccountId], grade)
+	}
+	return grades, nil
+}
+
+func (g *GormProvider) GetAllMoodleGradeInfos() ([]app_models.MoodleGradeInfo, error) {
+	m := []models.MoodleGradeInfoDB{}
+
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_grade_updaters.not_set_yet").Joins("INNER JOIN moodle_grade_updaters ON moodle_grade_updaters.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.disabled = ?", false).Scan(&m).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var accountIds []string
+	for _, v := range m {
+		accountIds = append(accountIds, v.AccountId)
+	}
+
+	grades, err := g.getMoodleGradesOfAccounts(accountIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var mm []app_models.MoodleGradeInfo
+	for _, v := range m {
+		info, err := v.ToMoodleGradeInfo(grades[v.AccountId], g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, info)
+	}
+
+	return mm, nil
+}
+
+func (g *GormProvider) GetMoodleGradeInfos(accountId string) (app_models.MoodleGradeInfo, error) {
+	m := models.MoodleGradeInfoDB{}
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "COALESCE(account_infos.phone_number, '') AS phone_number", "accounts.id AS 'account_id'", "moodle_grade_updaters.not_set_yet").Joins("INNER JOIN moodle_grade_updaters ON moodle_grade_updaters.account_id = accounts.id").Joins("LEFT JOIN account_infos ON account_infos.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
+	if err != nil {
+		return app_models.MoodleGradeInfo{}, err
+	}
+
+	if m.AccountId == "" {
+		return app_models.MoodleGradeInfo{}, &db_errors.ErrRecordNotFound
+	}
+
+	grades, err := g.getMoodleGradesOfAccounts([]string{accountId})
+	if err != nil {
+		return app_models.MoodleGradeInfo{}, err
+	}
+
+	return m.ToMoodleGradeInfo(grades[accountId], g.cipher)
+}
+
+// Returns the calendar token of an account
+func (g *GormProvider) GetCalendarToken(accountId string) (string, error) {
+	c := models.CalendarTokenDB{}
+	if err := g.DB.First(&c, "account_id = ?", accountId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", &db_errors.ErrRecordNotFound
+		}
+		return "", err
+	}
+	return c.Token, nil
+}
+
+// Creates or replaces the calendar token of an account
+func (g *GormProvider) SetCalendarToken(accountId, token string) error {
+	c := models.CalendarTokenDB{}
+
+	// The token is set on creation already, as an empty token would collide with the unique index
+	if err := g.DB.Where(models.CalendarTokenDB{AccountId: accountId}).Attrs(models.CalendarTokenDB{Token: token}).FirstOrCreate(&c).Error; err != nil {
+		return err
+	}
+
+	c.Token = token
+	return g.DB.Save(&c).Error
+}
+
+// Removes the calendar token of an account and its synced moodle calendar events
+func (g *GormProvider) RemoveCalendarToken(accountId string) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.MoodleCalendarEventDB{}, "account_id = ?", accountId).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&models.CalendarTokenDB{}, "account_id = ?", accountId).Error
+	})
+}
+
+// Returns the id of the account the calendar token belongs to
+func (g *GormProvider) GetAccountIdByCalendarToken(token string) (string, error) {
+	c := models.CalendarTokenDB{}
+	if err := g.DB.First(&c, "token = ?", token).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", &db_errors.ErrRecordNotFound
+		}
+		return "", err
+	}
+	return c.AccountId, nil
+}
+
+// Replaces the synced moodle calendar events of an account
+func (g *GormProvider) SetMoodleCalendarEvents(accountId string, events []app_models.MoodleCalendarEvent) error {
+	return g.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("account_id = ?", accountId).Delete(&models.MoodleCalendarEventDB{}).Error; err != nil {
+			return err
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		var es []models.MoodleCalendarEventDB
+		for _, e := range events {
+			es = append(es, models.NewMoodleCalendarEventDB(accountId, e))
+		}
+
+		return tx.Create(&es).Error
+	})
+}
+
+// Returns the synced moodle calendar events of an account
+func (g *GormProvider) GetMoodleCalendarEvents(accountId string) ([]app_models.MoodleCalendarEvent, error) {
+	es := []models.MoodleCalendarEventDB{}
+	if err := g.DB.Where("account_id = ?", accountId).Order("start").Find(&es).Error; err != nil {
+		return nil, err
+	}
+
+	var events []app_models.MoodleCalendarEvent
+	for _, e := range es {
+		events = append(events, e.ToMoodleCalendarEvent())
+	}
+	return events, nil
+}
+
+// Returns the data needed to sync the moodle calendars of all accounts with a calendar feed
+func (g *GormProvider) GetAllMoodleCalendarInfos() ([]app_models.MoodleCalendarInfo, error) {
+	m := []models.MoodleCalendarInfoDB{}
+
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "accounts.id AS 'account_id'").Joins("INNER JOIN calendar_tokens ON calendar_tokens.account_id = accounts.id").Where("accounts.disabled = ?", false).Scan(&m).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var mm []app_models.MoodleCalendarInfo
+	for _, v := range m {
+		info, err := v.ToMoodleCalendarInfo(g.cipher)
+		if err != nil {
+			return nil, err
+		}
+		mm = append(mm, info)
+	}
+
+	return mm, nil
+}
+
+// Returns the information needed to sync the moodle calendar of an account with a calendar feed
+func (g *GormProvider) GetMoodleCalendarInfos(accountId string) (app_models.MoodleCalendarInfo, error) {
+	m := models.MoodleCalendarInfoDB{}
+	err := g.DB.Model(models.AccountDB{}).Select("accounts.auth_id", "accounts.auth_pw", "accounts.moodle_token", "accounts.id AS 'account_id'").Joins("INNER JOIN calendar_tokens ON calendar_tokens.account_id = accounts.id").Where("accounts.id = ?", accountId).Scan(&m).Error
+	if err != nil {
+		return app_models.MoodleCalendarInfo{}, err
+	}
+
+	if m.AccountId == "" {
+		return app_models.MoodleCalendarInfo{}, &db_errors.ErrRecordNotFound
+	}
+
+	return m.ToMoodleCalendarInfo(g.cipher)
+}
+
+// Stores a run of a scheduled job
+func (g *GormProvider) AddJobRun(jobRun app_models.JobRun) (app_models.JobRun, error) {
+	j := models.JobRunDB{
+		Job:               jobRun.Job,
+		StartedAt:         jobRun.StartedAt,
+		FinishedAt:        jobRun.FinishedAt,
+		AccountsProcessed: jobRun.AccountsProcessed,
+		Errors:            jobRun.Errors,
+		MessagesSent:      jobRun.MessagesSent,
+		Error:             jobRun.Error,
+	}
+
+	err := g.DB.Create(&j).Error
+	return j.ToJobRun(), err
+}
+
+// Returns the latest runs of a job, newest first. If job is empty, the runs of all jobs are returned
+func (g *GormProvider) GetJobRuns(job string, limit int) ([]app_models.JobRun, error) {
+	js := []models.JobRunDB{}
+
+	q := g.DB.Order("started_at DESC").Limit(limit)
+	if job != "" {
+		q = q.Where("job = ?", job)
+	}
+
+	if err := q.Find(&js).Error; err != nil {
+		return []app_models.JobRun{}, err
+	}
+
+	runs := []app_models.JobRun{}
+	for _, j := range js {
+		runs = append(runs, j.ToJobRun())
+	}
+
+	return runs, nil
 }
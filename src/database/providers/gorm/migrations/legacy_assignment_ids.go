@@ -0,0 +1,91 @@
+package migrations
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"gorm.io/gorm"
+)
+
+type legacyAssignmentIds struct {
+	AccountId     string  `gorm:"column:account_id"`
+	AssignmentIds *string `gorm:"column:assignment_ids"`
+}
+
+// Moves the assignment ids stored as JSON in moodle_user_assignments into placeholder rows of moodle_assignments,
+// which are replaced by the next update without sending a notification
+func convertLegacyAssignmentIdsUp(tx *gorm.DB) error {
+	var rows []legacyAssignmentIds
+	if err := tx.Raw("SELECT account_id, assignment_ids FROM moodle_user_assignments").Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, row := range rows {
+		var ids []int
+		if row.AssignmentIds == nil || json.Unmarshal([]byte(*row.AssignmentIds), &ids) != nil || len(ids) == 0 {
+			continue
+		}
+
+		// Accounts which were updated after the assignments were moved already have their rows
+		var count int64
+		if err := tx.Raw("SELECT COUNT(*) FROM moodle_assignments WHERE account_id = ?", row.AccountId).Scan(&count).Error; err != nil {
+			return err
+		}
+
+		if count == 0 {
+			for _, assignmentId := range ids {
+				id, err := uuid.NewV4()
+				if err != nil {
+					return err
+				}
+
+				if err := tx.Exec("INSERT INTO moodle_assignments (id, created_at, updated_at, account_id, assignment_id, course_id, course_name, name, intro, submission_status) VALUES (?, ?, ?, ?, ?, 0, '', '', '', '')",
+					id.String(), now, now, row.AccountId, assignmentId).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Exec("UPDATE moodle_user_assignments SET assignment_ids = '[]' WHERE account_id = ?", row.AccountId).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Moves the placeholder rows back into the JSON column
+func convertLegacyAssignmentIdsDown(tx *gorm.DB) error {
+	var accountIds []string
+	if err := tx.Raw("SELECT account_id FROM moodle_user_assignments").Scan(&accountIds).Error; err != nil {
+		return err
+	}
+
+	for _, accountId := range accountIds {
+		var ids []int
+		if err := tx.Raw("SELECT assignment_id FROM moodle_assignments WHERE account_id = ? AND name = '' ORDER BY assignment_id", accountId).Scan(&ids).Error; err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			continue
+		}
+
+		b, err := json.Marshal(ids)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("UPDATE moodle_user_assignments SET assignment_ids = ? WHERE account_id = ?", string(b), accountId).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM moodle_assignments WHERE account_id = ? AND name = ''", accountId).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+//go:embed sql
+var sqlFiles embed.FS
+
+// Name of the migration files like "0002_reset_legacy_substitution_entries.up.sql"
+var fileNameRegex = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// A single schema change, either written in SQL for every dialect or in Go for data conversions
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// Migrations which are written in Go, as they can't be expressed in SQL for every dialect
+var goMigrations = []Migration{
+	{Version: 3, Name: "convert_legacy_assignment_ids", Up: convertLegacyAssignmentIdsUp, Down: convertLegacyAssignmentIdsDown},
+}
+
+// Returns the directory of the SQL files of a dialect as used in DATABASE_TYPE
+func dialectDir(dialect string) (string, error) {
+	switch dialect {
+	case "SQLITE":
+		return "sql/sqlite", nil
+	case "POSTGRES":
+		return "sql/postgres", nil
+	case "MYSQL":
+		return "sql/mysql", nil
+	default:
+		return "", fmt.Errorf("migrations are not available for the database type %q", dialect)
+	}
+}
+
+// Returns a function which executes the statements of a SQL file one after another
+func execStatements(content string) func(tx *gorm.DB) error {
+	var statements []string
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if strings.TrimSpace(statement) != "" {
+			statements = append(statements, strings.TrimSpace(statement))
+		}
+	}
+
+	return func(tx *gorm.DB) error {
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return fmt.Errorf("%w (statement: %s)", err, statement)
+			}
+		}
+		return nil
+	}
+}
+
+// Returns all migrations of the dialect ordered by their version
+func Load(dialect string) ([]Migration, error) {
+	dir, err := dialectDir(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := sqlFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := fileNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", version, m.Name, match[2])
+		}
+
+		content, err := sqlFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if match[3] == "up" {
+			m.Up = execStatements(string(content))
+		} else {
+			m.Down = execStatements(string(content))
+		}
+	}
+
+	for i := range goMigrations {
+		if _, ok := byVersion[goMigrations[i].Version]; ok {
+			return nil, fmt.Errorf("migration %d exists in SQL and in Go", goMigrations[i].Version)
+		}
+		byVersion[goMigrations[i].Version] = &goMigrations[i]
+	}
+
+	var migrations []Migration
+	for _, m := range byVersion {
+		if m.Up == nil || m.Down == nil {
+			return nil, fmt.Errorf("migration %d (%s) needs an up and a down migration", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,157 @@
+package migrations
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// The version of the migration which creates the schema of AutoMigrate()
+const baselineVersion = 1
+
+// A row of the schema_migrations table
+type SchemaMigration struct {
+	Version   int       `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name;size:255"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Whether a migration is applied to the database
+type Status struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	DB         *gorm.DB
+	Migrations []Migration
+	// Brings a database which was created by AutoMigrate() to the schema of the baseline,
+	// returns false if the database is empty and the baseline has to be applied instead
+	Adopt func(tx *gorm.DB) (bool, error)
+}
+
+// Returns a migrator with the migrations of the dialect
+func NewMigrator(db *gorm.DB, dialect string, adopt func(tx *gorm.DB) (bool, error)) (*Migrator, error) {
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{DB: db, Migrations: migrations, Adopt: adopt}, nil
+}
+
+// Creates the schema_migrations table if it doesn't exist and returns the applied migrations
+func (m *Migrator) applied() (map[int]SchemaMigration, error) {
+	if !m.DB.Migrator().HasTable(&SchemaMigration{}) {
+		if err := m.DB.Migrator().CreateTable(&SchemaMigration{}); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []SchemaMigration
+	if err := m.DB.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int]SchemaMigration)
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
+
+// Applies all pending migrations, returns the number of applied migrations
+func (m *Migrator) Up() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.DB.Transaction(func(tx *gorm.DB) error {
+			adopted := false
+			if migration.Version == baselineVersion && len(applied) == 0 && m.Adopt != nil {
+				var err error
+				if adopted, err = m.Adopt(tx); err != nil {
+					return err
+				}
+			}
+
+			if !adopted {
+				if err := migration.Up(tx); err != nil {
+					return err
+				}
+			}
+
+			return tx.Create(&SchemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+		})
+		if err != nil {
+			return count, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Reverts the given number of applied migrations, newest first, returns the number of reverted migrations
+func (m *Migrator) Down(steps int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.Migrations) - 1; i >= 0 && count < steps; i-- {
+		migration := m.Migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := m.DB.Transaction(func(tx *gorm.DB) error {
+			if err := migration.Down(tx); err != nil {
+				return err
+			}
+
+			return tx.Delete(&SchemaMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return count, fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Returns all migrations and whether they are applied
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.Migrations {
+		row, ok := applied[migration.Version]
+		statuses = append(statuses, Status{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			AppliedAt: row.AppliedAt,
+		})
+	}
+	return statuses, nil
+}
@@ -0,0 +1,18 @@
+DROP TABLE `send_failures`;
+DROP TABLE `job_runs`;
+DROP TABLE `notification_outbox`;
+DROP TABLE `notification_preferences`;
+DROP TABLE `notification_settings`;
+DROP TABLE `moodle_calendar_events`;
+DROP TABLE `calendar_tokens`;
+DROP TABLE `moodle_grades`;
+DROP TABLE `moodle_grade_updaters`;
+DROP TABLE `moodle_discussions`;
+DROP TABLE `moodle_forum_updaters`;
+DROP TABLE `moodle_course_settings`;
+DROP TABLE `moodle_reminders`;
+DROP TABLE `moodle_assignments`;
+DROP TABLE `moodle_user_assignments`;
+DROP TABLE `substitutions`;
+DROP TABLE `account_infos`;
+DROP TABLE `accounts`;
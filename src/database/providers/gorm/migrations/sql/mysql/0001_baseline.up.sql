@@ -0,0 +1,241 @@
+-- The schema as created by AutoMigrate() before the versioned migrations were introduced.
+-- Databases which were created by AutoMigrate() are adopted instead of running this migration.
+
+CREATE TABLE `accounts` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `auth_id` varchar(191),
+    `auth_pw` longtext,
+    `login_pw_hash` longtext,
+    `role` varchar(255) DEFAULT 'user',
+    `disabled` boolean DEFAULT false,
+    `moodle_token` longtext,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_accounts_username` (`auth_id`)
+);
+
+CREATE TABLE `account_infos` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `phone_number` varchar(255),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_account_infos_account_id` (`account_id`),
+    CONSTRAINT `fk_account_infos_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `substitutions` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `auth_id` varchar(255),
+    `auth_pw` longtext,
+    `entries` longtext DEFAULT '[]',
+    `not_set_yet` boolean,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_substitutions_account_id` (`account_id`),
+    CONSTRAINT `fk_substitutions_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_user_assignments` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `assignment_ids` longtext DEFAULT '[]',
+    `not_set_yet` boolean,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_user_assignments_account_id` (`account_id`),
+    CONSTRAINT `fk_moodle_user_assignments_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_assignments` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `assignment_id` bigint,
+    `course_id` bigint,
+    `course_name` varchar(255),
+    `name` longtext,
+    `intro` longtext,
+    `due_date` datetime(3),
+    `cutoff_date` datetime(3),
+    `submission_status` varchar(255),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_assignments_account_assignment` (`account_id`, `assignment_id`),
+    CONSTRAINT `fk_moodle_assignments_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_reminders` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `assignment_id` bigint,
+    `offset_seconds` bigint,
+    `sent_at` datetime(3),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_reminders_account_assignment_offset` (`account_id`, `assignment_id`, `offset_seconds`),
+    CONSTRAINT `fk_moodle_reminders_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_course_settings` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `course_id` bigint,
+    `reminders_enabled` boolean,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_course_settings_account_course` (`account_id`, `course_id`),
+    CONSTRAINT `fk_moodle_course_settings_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_forum_updaters` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `not_set_yet` boolean,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_forum_updaters_account_id` (`account_id`),
+    CONSTRAINT `fk_moodle_forum_updaters_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_discussions` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `discussion_id` bigint,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_discussions_account_discussion` (`account_id`, `discussion_id`),
+    CONSTRAINT `fk_moodle_discussions_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_grade_updaters` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `not_set_yet` boolean,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_grade_updaters_account_id` (`account_id`),
+    CONSTRAINT `fk_moodle_grade_updaters_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_grades` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `course_id` bigint,
+    `course_name` varchar(255),
+    `item_id` bigint,
+    `item_name` varchar(255),
+    `grade` varchar(255),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_grades_account_item` (`account_id`, `item_id`),
+    CONSTRAINT `fk_moodle_grades_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `calendar_tokens` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `token` varchar(64),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_calendar_tokens_token` (`token`),
+    UNIQUE INDEX `idx_calendar_tokens_account_id` (`account_id`),
+    CONSTRAINT `fk_calendar_tokens_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `moodle_calendar_events` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `event_id` bigint,
+    `name` longtext,
+    `course_name` varchar(255),
+    `module_name` varchar(255),
+    `instance` bigint,
+    `start` datetime(3),
+    `url` longtext,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_moodle_calendar_events_account_event` (`account_id`, `event_id`),
+    CONSTRAINT `fk_moodle_calendar_events_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `notification_settings` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `channel` varchar(191),
+    `recipient` varchar(255),
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_notification_settings_account_channel` (`account_id`, `channel`),
+    CONSTRAINT `fk_notification_settings_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `notification_preferences` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `paused` boolean DEFAULT false,
+    `paused_until` datetime(3),
+    `opted_out` boolean DEFAULT false,
+    `quiet_hours_start` varchar(5),
+    `quiet_hours_end` varchar(5),
+    `digest` boolean DEFAULT false,
+    PRIMARY KEY (`id`),
+    UNIQUE INDEX `idx_notification_preferences_account_id` (`account_id`),
+    CONSTRAINT `fk_notification_preferences_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `notification_outbox` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `phone_number` varchar(255),
+    `message` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_notification_outbox_account_id` (`account_id`),
+    CONSTRAINT `fk_notification_outbox_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+
+CREATE TABLE `job_runs` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `job` varchar(191),
+    `started_at` datetime(3),
+    `finished_at` datetime(3),
+    `accounts_processed` bigint,
+    `errors` bigint,
+    `messages_sent` bigint,
+    `error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_job_runs_started_at` (`started_at`),
+    INDEX `idx_job_runs_job` (`job`)
+);
+
+CREATE TABLE `send_failures` (
+    `id` varchar(36),
+    `created_at` datetime(3),
+    `updated_at` datetime(3),
+    `account_id` varchar(191),
+    `channel` varchar(255),
+    `error` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_send_failures_account_id` (`account_id`),
+    CONSTRAINT `fk_send_failures_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
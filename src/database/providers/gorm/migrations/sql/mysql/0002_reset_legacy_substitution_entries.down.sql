@@ -0,0 +1 @@
+-- The entries in the old format are gone, there is nothing to restore.
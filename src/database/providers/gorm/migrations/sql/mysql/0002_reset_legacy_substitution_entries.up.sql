@@ -0,0 +1,3 @@
+-- Entries stored in the old format (weekday -> joined lines) can't be compared with the current plan,
+-- they are replaced by the next update without sending a notification.
+UPDATE substitutions SET `entries` = '[]', not_set_yet = true WHERE `entries` IS NULL OR `entries` NOT LIKE '[%';
@@ -0,0 +1,241 @@
+-- The schema as created by AutoMigrate() before the versioned migrations were introduced.
+-- Databases which were created by AutoMigrate() are adopted instead of running this migration.
+
+CREATE TABLE `accounts` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `auth_id` text,
+    `auth_pw` text,
+    `login_pw_hash` text,
+    `role` text DEFAULT 'user',
+    `disabled` numeric DEFAULT false,
+    `moodle_token` text,
+    PRIMARY KEY (`id`)
+);
+CREATE UNIQUE INDEX `idx_accounts_username` ON `accounts` (`auth_id`);
+
+CREATE TABLE `account_infos` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `phone_number` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_account_infos_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_account_infos_account_id` ON `account_infos` (`account_id`);
+
+CREATE TABLE `substitutions` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `auth_id` text,
+    `auth_pw` text,
+    `entries` text DEFAULT '[]',
+    `not_set_yet` numeric,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_substitutions_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_substitutions_account_id` ON `substitutions` (`account_id`);
+
+CREATE TABLE `moodle_user_assignments` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `assignment_ids` text DEFAULT '[]',
+    `not_set_yet` numeric,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_user_assignments_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_user_assignments_account_id` ON `moodle_user_assignments` (`account_id`);
+
+CREATE TABLE `moodle_assignments` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `assignment_id` integer,
+    `course_id` integer,
+    `course_name` text,
+    `name` text,
+    `intro` text,
+    `due_date` datetime,
+    `cutoff_date` datetime,
+    `submission_status` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_assignments_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_assignments_account_assignment` ON `moodle_assignments` (`account_id`, `assignment_id`);
+
+CREATE TABLE `moodle_reminders` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `assignment_id` integer,
+    `offset_seconds` integer,
+    `sent_at` datetime,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_reminders_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_reminders_account_assignment_offset` ON `moodle_reminders` (`account_id`, `assignment_id`, `offset_seconds`);
+
+CREATE TABLE `moodle_course_settings` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `course_id` integer,
+    `reminders_enabled` numeric,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_course_settings_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_course_settings_account_course` ON `moodle_course_settings` (`account_id`, `course_id`);
+
+CREATE TABLE `moodle_forum_updaters` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `not_set_yet` numeric,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_forum_updaters_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_forum_updaters_account_id` ON `moodle_forum_updaters` (`account_id`);
+
+CREATE TABLE `moodle_discussions` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `discussion_id` integer,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_discussions_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_discussions_account_discussion` ON `moodle_discussions` (`account_id`, `discussion_id`);
+
+CREATE TABLE `moodle_grade_updaters` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `not_set_yet` numeric,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_grade_updaters_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_grade_updaters_account_id` ON `moodle_grade_updaters` (`account_id`);
+
+CREATE TABLE `moodle_grades` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `course_id` integer,
+    `course_name` text,
+    `item_id` integer,
+    `item_name` text,
+    `grade` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_grades_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_grades_account_item` ON `moodle_grades` (`account_id`, `item_id`);
+
+CREATE TABLE `calendar_tokens` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `token` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_calendar_tokens_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_calendar_tokens_token` ON `calendar_tokens` (`token`);
+CREATE UNIQUE INDEX `idx_calendar_tokens_account_id` ON `calendar_tokens` (`account_id`);
+
+CREATE TABLE `moodle_calendar_events` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `event_id` integer,
+    `name` text,
+    `course_name` text,
+    `module_name` text,
+    `instance` integer,
+    `start` datetime,
+    `url` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_moodle_calendar_events_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_moodle_calendar_events_account_event` ON `moodle_calendar_events` (`account_id`, `event_id`);
+
+CREATE TABLE `notification_settings` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `channel` text,
+    `recipient` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_notification_settings_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_notification_settings_account_channel` ON `notification_settings` (`account_id`, `channel`);
+
+CREATE TABLE `notification_preferences` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `paused` numeric DEFAULT false,
+    `paused_until` datetime,
+    `opted_out` numeric DEFAULT false,
+    `quiet_hours_start` text,
+    `quiet_hours_end` text,
+    `digest` numeric DEFAULT false,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_notification_preferences_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE UNIQUE INDEX `idx_notification_preferences_account_id` ON `notification_preferences` (`account_id`);
+
+CREATE TABLE `notification_outbox` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `phone_number` text,
+    `message` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_notification_outbox_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE INDEX `idx_notification_outbox_account_id` ON `notification_outbox` (`account_id`);
+
+CREATE TABLE `job_runs` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `job` text,
+    `started_at` datetime,
+    `finished_at` datetime,
+    `accounts_processed` integer,
+    `errors` integer,
+    `messages_sent` integer,
+    `error` text,
+    PRIMARY KEY (`id`)
+);
+CREATE INDEX `idx_job_runs_started_at` ON `job_runs` (`started_at`);
+CREATE INDEX `idx_job_runs_job` ON `job_runs` (`job`);
+
+CREATE TABLE `send_failures` (
+    `id` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    `account_id` text,
+    `channel` text,
+    `error` text,
+    PRIMARY KEY (`id`),
+    CONSTRAINT `fk_send_failures_account_db` FOREIGN KEY (`account_id`) REFERENCES `accounts` (`id`)
+);
+CREATE INDEX `idx_send_failures_account_id` ON `send_failures` (`account_id`);
@@ -2,13 +2,18 @@ package models
 
 import (
 	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
 	"gorm.io/gorm"
 )
 
 type AccountDB struct {
 	Model
-	Username string `gorm:"column:auth_id;uniqueIndex"`
-	Password string `gorm:"column:auth_pw"`
+	Username     string          `gorm:"column:auth_id;uniqueIndex"`
+	Password     EncryptedString `gorm:"column:auth_pw"`
+	PasswordHash string          `gorm:"column:login_pw_hash"`
+	Role         string          `gorm:"column:role;default:user"`
+	Disabled     bool            `gorm:"column:disabled;default:false"`
+	MoodleToken  EncryptedString `gorm:"column:moodle_token"` // Cached moodle webservice token
 }
 
 func (AccountDB) TableName() string {
@@ -24,13 +29,73 @@ func (a *AccountDB) BeforeDelete(tx *gorm.DB) error {
 		return err
 	}
 
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleAssignmentDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleReminderDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleCourseSettingDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleForumUpdaterDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleDiscussionDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleGradeUpdaterDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleGradeDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&CalendarTokenDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&MoodleCalendarEventDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&NotificationSettingDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&NotificationPreferenceDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&OutboxMessageDB{}).Error; err != nil {
+		return err
+	}
+
+	if err := tx.Where("account_id = ?", a.Id).Delete(&SendFailureDB{}).Error; err != nil {
+		return err
+	}
+
 	return tx.Where("account_id = ?", a.Id).Delete(&AccountInfoDB{}).Error
 }
 
-func (a AccountDB) ToAccount() app_models.Account {
-	return app_models.Account{
-		Id:       a.Id,
-		Username: a.Username,
-		Password: a.Password,
+func (a AccountDB) ToAccount(c *encryption.Cipher) (app_models.Account, error) {
+	password, err := a.Password.Decrypt(c)
+	if err != nil {
+		return app_models.Account{}, err
 	}
+
+	return app_models.Account{
+		Id:           a.Id,
+		Username:     a.Username,
+		Password:     password,
+		PasswordHash: a.PasswordHash,
+		Role:         a.Role,
+		Disabled:     a.Disabled,
+	}, nil
 }
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
+)
+
+// The secret token of the calendar feed of an account
+type CalendarTokenDB struct {
+	Model
+	AccountId string    `gorm:"column:account_id;uniqueIndex"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	Token     string    `gorm:"column:token;uniqueIndex;size:64"`
+}
+
+func (CalendarTokenDB) TableName() string {
+	return "calendar_tokens"
+}
+
+type MoodleCalendarEventDB struct {
+	Model
+	AccountId  string    `gorm:"column:account_id;uniqueIndex:idx_moodle_calendar_events_account_event"`
+	AccountDB  AccountDB `gorm:"foreignKey:account_id"`
+	EventId    int       `gorm:"column:event_id;uniqueIndex:idx_moodle_calendar_events_account_event"`
+	Name       string    `gorm:"column:name"`
+	CourseName string    `gorm:"column:course_name"`
+	ModuleName string    `gorm:"column:module_name"`
+	Instance   int       `gorm:"column:instance"`
+	Start      time.Time `gorm:"column:start"`
+	Url        string    `gorm:"column:url"`
+}
+
+func (MoodleCalendarEventDB) TableName() string {
+	return "moodle_calendar_events"
+}
+
+func NewMoodleCalendarEventDB(accountId string, e app_models.MoodleCalendarEvent) MoodleCalendarEventDB {
+	return MoodleCalendarEventDB{
+		AccountId:  accountId,
+		EventId:    e.Id,
+		Name:       e.Name,
+		CourseName: e.CourseName,
+		ModuleName: e.ModuleName,
+		Instance:   e.Instance,
+		Start:      e.Start,
+		Url:        e.Url,
+	}
+}
+
+func (m MoodleCalendarEventDB) ToMoodleCalendarEvent() app_models.MoodleCalendarEvent {
+	return app_models.MoodleCalendarEvent{
+		Id:         m.EventId,
+		Name:       m.Name,
+		CourseName: m.CourseName,
+		ModuleName: m.ModuleName,
+		Instance:   m.Instance,
+		Start:      m.Start,
+		Url:        m.Url,
+	}
+}
+
+type MoodleCalendarInfoDB struct {
+	AuthId      string          `gorm:"column:auth_id"`
+	AuthPw      EncryptedString `gorm:"column:auth_pw"`
+	MoodleToken EncryptedString `gorm:"column:moodle_token"`
+	AccountId   string          `gorm:"column:account_id"`
+}
+
+func (a MoodleCalendarInfoDB) ToMoodleCalendarInfo(c *encryption.Cipher) (app_models.MoodleCalendarInfo, error) {
+	authPw, err := a.AuthPw.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleCalendarInfo{}, err
+	}
+
+	moodleToken, err := a.MoodleToken.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleCalendarInfo{}, err
+	}
+
+	return app_models.MoodleCalendarInfo{
+		AuthId:      a.AuthId,
+		AuthPw:      authPw,
+		MoodleToken: moodleToken,
+		AccountId:   a.AccountId,
+	}, nil
+}
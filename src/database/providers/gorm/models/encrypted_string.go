@@ -0,0 +1,32 @@
+package models
+
+import (
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
+)
+
+// A value as it's stored in the database, encrypted by the cipher of the provider.
+// Values written before the encryption was introduced are still plaintext
+type EncryptedString string
+
+// Encrypts the plaintext with the given cipher, an empty plaintext stays empty
+func NewEncryptedString(c *encryption.Cipher, plaintext string) (EncryptedString, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	value, err := c.Encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	return EncryptedString(value), nil
+}
+
+// Decrypts the value with the given cipher, plaintext values are returned as they are
+func (s EncryptedString) Decrypt(c *encryption.Cipher) (string, error) {
+	if !encryption.IsEncrypted(string(s)) {
+		return string(s), nil
+	}
+
+	return c.Decrypt(string(s))
+}
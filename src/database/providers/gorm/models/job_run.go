@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type JobRunDB struct {
+	Model
+	Job               string    `gorm:"column:job;index"`
+	StartedAt         time.Time `gorm:"column:started_at;index"`
+	FinishedAt        time.Time `gorm:"column:finished_at"`
+	AccountsProcessed int       `gorm:"column:accounts_processed"`
+	Errors            int       `gorm:"column:errors"`
+	MessagesSent      int       `gorm:"column:messages_sent"`
+	Error             string    `gorm:"column:error"`
+}
+
+func (JobRunDB) TableName() string {
+	return "job_runs"
+}
+
+func (j JobRunDB) ToJobRun() app_models.JobRun {
+	return app_models.JobRun{
+		Id:                j.Id,
+		Job:               j.Job,
+		StartedAt:         j.StartedAt,
+		FinishedAt:        j.FinishedAt,
+		AccountsProcessed: j.AccountsProcessed,
+		Errors:            j.Errors,
+		MessagesSent:      j.MessagesSent,
+		Error:             j.Error,
+	}
+}
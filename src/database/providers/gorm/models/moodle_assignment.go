@@ -0,0 +1,70 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type MoodleAssignmentDB struct {
+	Model
+	AccountId    string     `gorm:"column:account_id;uniqueIndex:idx_moodle_assignments_account_assignment"`
+	AccountDB    AccountDB  `gorm:"foreignKey:account_id"`
+	AssignmentId int        `gorm:"column:assignment_id;uniqueIndex:idx_moodle_assignments_account_assignment"`
+	CourseId     int        `gorm:"column:course_id"`
+	CourseName   string     `gorm:"column:course_name"`
+	Name         string     `gorm:"column:name"`
+	Intro        string     `gorm:"column:intro"`
+	DueDate      *time.Time `gorm:"column:due_date"`
+	CutoffDate   *time.Time `gorm:"column:cutoff_date"`
+	// One of the app_models.MoodleSubmissionStatus constants, empty if unknown
+	SubmissionStatus string `gorm:"column:submission_status"`
+}
+
+func (MoodleAssignmentDB) TableName() string {
+	return "moodle_assignments"
+}
+
+// Returns nil for the zero time, so that no date is stored
+func timeToNullable(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func nullableToTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func NewMoodleAssignmentDB(accountId string, a app_models.MoodleAssignment) MoodleAssignmentDB {
+	return MoodleAssignmentDB{
+		AccountId:    accountId,
+		AssignmentId: a.Id,
+		CourseId:     a.CourseId,
+		CourseName:   a.CourseName,
+		Name:         a.Name,
+		Intro:        a.Intro,
+		DueDate:      timeToNullable(a.DueDate),
+		CutoffDate:   timeToNullable(a.CutoffDate),
+
+		SubmissionStatus: a.SubmissionStatus,
+	}
+}
+
+func (m MoodleAssignmentDB) ToMoodleAssignment() app_models.MoodleAssignment {
+	return app_models.MoodleAssignment{
+		Id:         m.AssignmentId,
+		CourseId:   m.CourseId,
+		CourseName: m.CourseName,
+		Name:       m.Name,
+		Intro:      m.Intro,
+		DueDate:    nullableToTime(m.DueDate),
+		CutoffDate: nullableToTime(m.CutoffDate),
+
+		SubmissionStatus: m.SubmissionStatus,
+	}
+}
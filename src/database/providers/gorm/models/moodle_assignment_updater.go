@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
 )
 
 type AssignmentIds []int
@@ -36,8 +37,9 @@ func (s *AssignmentIds) Value() (driver.Value, error) {
 
 type MoodleUserAssignmentsDB struct {
 	Model
-	AccountId     string         `gorm:"column:account_id;uniqueIndex"`
-	AccountDB     AccountDB      `gorm:"foreignKey:account_id"`
+	AccountId string    `gorm:"column:account_id;uniqueIndex"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	// Only used by rows written before the assignments were stored in moodle_assignments
 	AssignmentIds *AssignmentIds `gorm:"assignment_ids;default:[]"`
 	NotSetYet     bool           `gorm:"column:not_set_yet"`
 }
@@ -60,32 +62,76 @@ func (s *MoodleUserAssignmentsDB) Value() (driver.Value, error) {
 	return json.Marshal(s)
 }
 
-func (s MoodleUserAssignmentsDB) ToMoodleAssignments() app_models.MoodleAssignments {
+// Returns placeholders for the legacy assignment ids, so that they aren't reported as new
+func (a *AssignmentIds) ToMoodleAssignments() []app_models.MoodleAssignment {
+	if a == nil {
+		return nil
+	}
+
+	var assignments []app_models.MoodleAssignment
+	for _, id := range *a {
+		assignments = append(assignments, app_models.MoodleAssignment{Id: id})
+	}
+	return assignments
+}
+
+func (s MoodleUserAssignmentsDB) ToMoodleAssignments(assignments []MoodleAssignmentDB) app_models.MoodleAssignments {
 	// thats a really long method name, but everybody knows what if means ...
-	return app_models.MoodleAssignments{
-		AccountId:   s.AccountId,
-		Assignments: *s.AssignmentIds,
+	m := app_models.MoodleAssignments{
+		AccountId: s.AccountId,
+		UpdatedAt: s.UpdatedAt,
+	}
+
+	for _, a := range assignments {
+		m.Assignments = append(m.Assignments, a.ToMoodleAssignment())
+	}
+
+	if len(m.Assignments) == 0 {
+		m.Assignments = s.AssignmentIds.ToMoodleAssignments()
 	}
+
+	return m
 }
 
 type MoodleAssignmentInfoDB struct {
-	AuthId                  string         `gorm:"column:auth_id"`
-	AuthPw                  string         `gorm:"column:auth_pw"`
-	PhoneNumber             string         `gorm:"column:phone_number"`
-	AccountId               string         `gorm:"column:account_id"`
-	MoodleUserAssignmentsId string         `gorm:"column:moodle_user_assignment_id"`
-	AssignmentIds           *AssignmentIds `gorm:"column:assignment_ids"`
-	NotSetYet               bool           `gorm:"column:not_set_yet"`
+	AuthId                  string          `gorm:"column:auth_id"`
+	AuthPw                  EncryptedString `gorm:"column:auth_pw"`
+	MoodleToken             EncryptedString `gorm:"column:moodle_token"`
+	PhoneNumber             string          `gorm:"column:phone_number"`
+	AccountId               string          `gorm:"column:account_id"`
+	MoodleUserAssignmentsId string          `gorm:"column:moodle_user_assignment_id"`
+	AssignmentIds           *AssignmentIds  `gorm:"column:assignment_ids"`
+	NotSetYet               bool            `gorm:"column:not_set_yet"`
 }
 
-func (a MoodleAssignmentInfoDB) ToMoodleAssignmentInfo() app_models.MoodleAssignmentInfo {
-	return app_models.MoodleAssignmentInfo{
+func (a MoodleAssignmentInfoDB) ToMoodleAssignmentInfo(assignments []MoodleAssignmentDB, c *encryption.Cipher) (app_models.MoodleAssignmentInfo, error) {
+	authPw, err := a.AuthPw.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleAssignmentInfo{}, err
+	}
+
+	moodleToken, err := a.MoodleToken.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleAssignmentInfo{}, err
+	}
+
+	m := app_models.MoodleAssignmentInfo{
 		AuthId:                  a.AuthId,
-		AuthPw:                  a.AuthPw,
+		AuthPw:                  authPw,
+		MoodleToken:             moodleToken,
 		PhoneNumber:             a.PhoneNumber,
 		AccountId:               a.AccountId,
 		MoodleUserAssignmentsId: a.MoodleUserAssignmentsId,
-		AssignmentIds:           *a.AssignmentIds,
 		NotSetYet:               a.NotSetYet,
 	}
+
+	for _, assignment := range assignments {
+		m.Assignments = append(m.Assignments, assignment.ToMoodleAssignment())
+	}
+
+	if len(m.Assignments) == 0 {
+		m.Assignments = a.AssignmentIds.ToMoodleAssignments()
+	}
+
+	return m, nil
 }
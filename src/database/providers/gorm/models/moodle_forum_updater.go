@@ -0,0 +1,63 @@
+package models
+
+import (
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
+)
+
+type MoodleForumUpdaterDB struct {
+	Model
+	AccountId string    `gorm:"column:account_id;uniqueIndex"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	NotSetYet bool      `gorm:"column:not_set_yet"`
+}
+
+func (MoodleForumUpdaterDB) TableName() string {
+	return "moodle_forum_updaters"
+}
+
+type MoodleDiscussionDB struct {
+	Model
+	AccountId    string    `gorm:"column:account_id;uniqueIndex:idx_moodle_discussions_account_discussion"`
+	AccountDB    AccountDB `gorm:"foreignKey:account_id"`
+	DiscussionId int       `gorm:"column:discussion_id;uniqueIndex:idx_moodle_discussions_account_discussion"`
+}
+
+func (MoodleDiscussionDB) TableName() string {
+	return "moodle_discussions"
+}
+
+type MoodleForumInfoDB struct {
+	AuthId      string          `gorm:"column:auth_id"`
+	AuthPw      EncryptedString `gorm:"column:auth_pw"`
+	MoodleToken EncryptedString `gorm:"column:moodle_token"`
+	PhoneNumber string          `gorm:"column:phone_number"`
+	AccountId   string          `gorm:"column:account_id"`
+	NotSetYet   bool            `gorm:"column:not_set_yet"`
+}
+
+func (a MoodleForumInfoDB) ToMoodleForumInfo(discussions []MoodleDiscussionDB, c *encryption.Cipher) (app_models.MoodleForumInfo, error) {
+	authPw, err := a.AuthPw.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleForumInfo{}, err
+	}
+
+	moodleToken, err := a.MoodleToken.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleForumInfo{}, err
+	}
+
+	m := app_models.MoodleForumInfo{
+		AuthId:      a.AuthId,
+		AuthPw:      authPw,
+		MoodleToken: moodleToken,
+		PhoneNumber: a.PhoneNumber,
+		AccountId:   a.AccountId,
+		NotSetYet:   a.NotSetYet,
+	}
+
+	for _, d := range discussions {
+		m.DiscussionIds = append(m.DiscussionIds, d.DiscussionId)
+	}
+	return m, nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
+)
+
+type MoodleGradeUpdaterDB struct {
+	Model
+	AccountId string    `gorm:"column:account_id;uniqueIndex"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	NotSetYet bool      `gorm:"column:not_set_yet"`
+}
+
+func (MoodleGradeUpdaterDB) TableName() string {
+	return "moodle_grade_updaters"
+}
+
+type MoodleGradeDB struct {
+	Model
+	AccountId  string    `gorm:"column:account_id;uniqueIndex:idx_moodle_grades_account_item"`
+	AccountDB  AccountDB `gorm:"foreignKey:account_id"`
+	CourseId   int       `gorm:"column:course_id"`
+	CourseName string    `gorm:"column:course_name"`
+	ItemId     int       `gorm:"column:item_id;uniqueIndex:idx_moodle_grades_account_item"`
+	ItemName   string    `gorm:"column:item_name"`
+	Grade      string    `gorm:"column:grade"`
+}
+
+func (MoodleGradeDB) TableName() string {
+	return "moodle_grades"
+}
+
+func NewMoodleGradeDB(accountId string, g app_models.MoodleGrade) MoodleGradeDB {
+	return MoodleGradeDB{
+		AccountId:  accountId,
+		CourseId:   g.CourseId,
+		CourseName: g.CourseName,
+		ItemId:     g.ItemId,
+		ItemName:   g.ItemName,
+		Grade:      g.Grade,
+	}
+}
+
+func (m MoodleGradeDB) ToMoodleGrade() app_models.MoodleGrade {
+	return app_models.MoodleGrade{
+		CourseId:   m.CourseId,
+		CourseName: m.CourseName,
+		ItemId:     m.ItemId,
+		ItemName:   m.ItemName,
+		Grade:      m.Grade,
+	}
+}
+
+type MoodleGradeInfoDB struct {
+	AuthId      string          `gorm:"column:auth_id"`
+	AuthPw      EncryptedString `gorm:"column:auth_pw"`
+	MoodleToken EncryptedString `gorm:"column:moodle_token"`
+	PhoneNumber string          `gorm:"column:phone_number"`
+	AccountId   string          `gorm:"column:account_id"`
+	NotSetYet   bool            `gorm:"column:not_set_yet"`
+}
+
+func (a MoodleGradeInfoDB) ToMoodleGradeInfo(grades []MoodleGradeDB, c *encryption.Cipher) (app_models.MoodleGradeInfo, error) {
+	authPw, err := a.AuthPw.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleGradeInfo{}, err
+	}
+
+	moodleToken, err := a.MoodleToken.Decrypt(c)
+	if err != nil {
+		return app_models.MoodleGradeInfo{}, err
+	}
+
+	m := app_models.MoodleGradeInfo{
+		AuthId:      a.AuthId,
+		AuthPw:      authPw,
+		MoodleToken: moodleToken,
+		PhoneNumber: a.PhoneNumber,
+		AccountId:   a.AccountId,
+		NotSetYet:   a.NotSetYet,
+	}
+
+	for _, g := range grades {
+		m.Grades = append(m.Grades, g.ToMoodleGrade())
+	}
+	return m, nil
+}
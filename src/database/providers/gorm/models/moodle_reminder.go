@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type MoodleReminderDB struct {
+	Model
+	AccountId     string    `gorm:"column:account_id;uniqueIndex:idx_moodle_reminders_account_assignment_offset"`
+	AccountDB     AccountDB `gorm:"foreignKey:account_id"`
+	AssignmentId  int       `gorm:"column:assignment_id;uniqueIndex:idx_moodle_reminders_account_assignment_offset"`
+	OffsetSeconds int64     `gorm:"column:offset_seconds;uniqueIndex:idx_moodle_reminders_account_assignment_offset"`
+	SentAt        time.Time `gorm:"column:sent_at"`
+}
+
+func (MoodleReminderDB) TableName() string {
+	return "moodle_reminders"
+}
+
+func (m MoodleReminderDB) ToMoodleReminder() app_models.MoodleReminder {
+	return app_models.MoodleReminder{
+		AccountId:    m.AccountId,
+		AssignmentId: m.AssignmentId,
+		Offset:       time.Duration(m.OffsetSeconds) * time.Second,
+		SentAt:       m.SentAt,
+	}
+}
+
+type MoodleCourseSettingDB struct {
+	Model
+	AccountId        string    `gorm:"column:account_id;uniqueIndex:idx_moodle_course_settings_account_course"`
+	AccountDB        AccountDB `gorm:"foreignKey:account_id"`
+	CourseId         int       `gorm:"column:course_id;uniqueIndex:idx_moodle_course_settings_account_course"`
+	RemindersEnabled bool      `gorm:"column:reminders_enabled"`
+}
+
+func (MoodleCourseSettingDB) TableName() string {
+	return "moodle_course_settings"
+}
+
+func (m MoodleCourseSettingDB) ToMoodleCourseSetting() app_models.MoodleCourseSetting {
+	return app_models.MoodleCourseSetting{
+		AccountId:        m.AccountId,
+		CourseId:         m.CourseId,
+		RemindersEnabled: m.RemindersEnabled,
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type NotificationPreferenceDB struct {
+	Model
+	AccountId       string     `gorm:"column:account_id;uniqueIndex"`
+	AccountDB       AccountDB  `gorm:"foreignKey:account_id"`
+	Paused          bool       `gorm:"column:paused;default:false"`
+	PausedUntil     *time.Time `gorm:"column:paused_until"`
+	OptedOut        bool       `gorm:"column:opted_out;default:false"`
+	QuietHoursStart string     `gorm:"column:quiet_hours_start;size:5"`
+	QuietHoursEnd   string     `gorm:"column:quiet_hours_end;size:5"`
+	Digest          bool       `gorm:"column:digest;default:false"`
+}
+
+func (NotificationPreferenceDB) TableName() string {
+	return "notification_preferences"
+}
+
+func (n NotificationPreferenceDB) ToNotificationPreference() app_models.NotificationPreference {
+	return app_models.NotificationPreference{
+		AccountId:       n.AccountId,
+		Paused:          n.Paused,
+		PausedUntil:     nullableToTime(n.PausedUntil),
+		OptedOut:        n.OptedOut,
+		QuietHoursStart: n.QuietHoursStart,
+		QuietHoursEnd:   n.QuietHoursEnd,
+		Digest:          n.Digest,
+	}
+}
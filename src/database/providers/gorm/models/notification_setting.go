@@ -0,0 +1,26 @@
+package models
+
+import (
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type NotificationSettingDB struct {
+	Model
+	AccountId string    `gorm:"column:account_id;uniqueIndex:idx_notification_settings_account_channel"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	Channel   string    `gorm:"column:channel;uniqueIndex:idx_notification_settings_account_channel"`
+	Recipient string    `gorm:"column:recipient"`
+}
+
+func (NotificationSettingDB) TableName() string {
+	return "notification_settings"
+}
+
+func (n NotificationSettingDB) ToNotificationSetting() app_models.NotificationSetting {
+	return app_models.NotificationSetting{
+		Id:        n.Id,
+		AccountId: n.AccountId,
+		Channel:   n.Channel,
+		Recipient: n.Recipient,
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type OutboxMessageDB struct {
+	Model
+	AccountId   string    `gorm:"column:account_id;index"`
+	AccountDB   AccountDB `gorm:"foreignKey:account_id"`
+	PhoneNumber string    `gorm:"column:phone_number"`
+	Message     string    `gorm:"column:message"`
+}
+
+func (OutboxMessageDB) TableName() string {
+	return "notification_outbox"
+}
+
+func (o OutboxMessageDB) ToOutboxMessage() app_models.OutboxMessage {
+	return app_models.OutboxMessage{
+		Id:          o.Id,
+		AccountId:   o.AccountId,
+		PhoneNumber: o.PhoneNumber,
+		Message:     o.Message,
+		CreatedAt:   o.CreatedAt,
+	}
+}
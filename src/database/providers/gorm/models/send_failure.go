@@ -0,0 +1,27 @@
+package models
+
+import (
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type SendFailureDB struct {
+	Model
+	AccountId string    `gorm:"column:account_id;index"`
+	AccountDB AccountDB `gorm:"foreignKey:account_id"`
+	Channel   string    `gorm:"column:channel"`
+	Error     string    `gorm:"column:error"`
+}
+
+func (SendFailureDB) TableName() string {
+	return "send_failures"
+}
+
+func (s SendFailureDB) ToSendFailure() app_models.SendFailure {
+	return app_models.SendFailure{
+		Id:        s.Id,
+		AccountId: s.AccountId,
+		Channel:   s.Channel,
+		Error:     s.Error,
+		CreatedAt: s.CreatedAt,
+	}
+}
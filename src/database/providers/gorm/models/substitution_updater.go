@@ -6,21 +6,27 @@ import (
 	"fmt"
 
 	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/encryption"
 )
 
-type Entries map[string][]string
+type Entries []app_models.Substitution
 
+// Entries stored in the old format (weekday -> joined lines) can't be decoded and are left nil
 func (s *Entries) Scan(val interface{}) error {
+	var data []byte
 	switch v := val.(type) {
 	case []byte:
-		json.Unmarshal(v, &s)
-		return nil
+		data = v
 	case string:
-		json.Unmarshal([]byte(v), &s)
-		return nil
+		data = []byte(v)
 	default:
 		return fmt.Errorf("unsupported type: %T", v)
 	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		*s = nil
+	}
+	return nil
 }
 
 func (s *Entries) Value() (driver.Value, error) {
@@ -36,12 +42,12 @@ func (s *Entries) Value() (driver.Value, error) {
 
 type SubstitutionDB struct {
 	Model
-	AccountId string    `gorm:"column:account_id;uniqueIndex"`
-	AuthId    string    `gorm:"column:auth_id"`
-	AuthPw    string    `gorm:"column:auth_pw"`
-	AccountDB AccountDB `gorm:"foreignKey:account_id"`
-	Entries   *Entries  `gorm:"entries;default:{}"`
-	NotSetYet bool      `gorm:"column:not_set_yet"`
+	AccountId string          `gorm:"column:account_id;uniqueIndex"`
+	AuthId    string          `gorm:"column:auth_id"`
+	AuthPw    EncryptedString `gorm:"column:auth_pw"`
+	AccountDB AccountDB       `gorm:"foreignKey:account_id"`
+	Entries   *Entries        `gorm:"entries;default:[]"`
+	NotSetYet bool            `gorm:"column:not_set_yet"`
 }
 
 func (SubstitutionDB) TableName() string {
@@ -66,27 +72,34 @@ func (s SubstitutionDB) ToSubstitutions() app_models.Substitutions {
 	return app_models.Substitutions{
 		AccountId: s.AccountId,
 		Entries:   *s.Entries,
+		UpdatedAt: s.UpdatedAt,
 	}
 }
 
 type SubstitutionInfoDB struct {
-	AuthId          string   `gorm:"column:auth_id"`
-	AuthPw          string   `gorm:"column:auth_pw"`
-	PhoneNumber     string   `gorm:"column:phone_number"`
-	AccountId       string   `gorm:"column:account_id"`
-	SubstitutionsId string   `gorm:"column:substitutions_id"`
-	Entries         *Entries `gorm:"column:entries"`
-	NotSetYet       bool     `gorm:"column:not_set_yet"`
+	AuthId          string          `gorm:"column:auth_id"`
+	AuthPw          EncryptedString `gorm:"column:auth_pw"`
+	PhoneNumber     string          `gorm:"column:phone_number"`
+	AccountId       string          `gorm:"column:account_id"`
+	SubstitutionsId string          `gorm:"column:substitutions_id"`
+	Entries         *Entries        `gorm:"column:entries"`
+	NotSetYet       bool            `gorm:"column:not_set_yet"`
 }
 
-func (a SubstitutionInfoDB) ToSubstitutionInfo() app_models.SubstitutionInfo {
+func (a SubstitutionInfoDB) ToSubstitutionInfo(c *encryption.Cipher) (app_models.SubstitutionInfo, error) {
+	authPw, err := a.AuthPw.Decrypt(c)
+	if err != nil {
+		return app_models.SubstitutionInfo{}, err
+	}
+
 	return app_models.SubstitutionInfo{
 		AuthId:          a.AuthId,
-		AuthPw:          a.AuthPw,
+		AuthPw:          authPw,
 		PhoneNumber:     a.PhoneNumber,
 		AccountId:       a.AccountId,
 		SubstitutionsId: a.SubstitutionsId,
 		Entries:         *a.Entries,
-		NotSetYet:       a.NotSetYet,
-	}
+		// Entries in the old format can't be compared, so they are replaced without notifying
+		NotSetYet: a.NotSetYet || *a.Entries == nil,
+	}, nil
 }
@@ -0,0 +1,972 @@
+package memory
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	app_models "github.com/dattito/purrmannplus-backend/app/models"
+	db_errors "github.com/dattito/purrmannplus-backend/database/errors"
+	"github.com/gofrs/uuid"
+)
+
+type accountInfo struct {
+	PhoneNumber string
+	UpdatedAt   time.Time
+}
+
+type substitutions struct {
+	Id        string
+	AuthId    string
+	AuthPw    string
+	Entries   []app_models.Substitution
+	NotSetYet bool
+	UpdatedAt time.Time
+}
+
+type moodleAssignments struct {
+	Id          string
+	Assignments []app_models.MoodleAssignment
+	NotSetYet   bool
+	UpdatedAt   time.Time
+}
+
+type moodleForum struct {
+	DiscussionIds []int
+	NotSetYet     bool
+}
+
+type moodleGrades struct {
+	Grades    []app_models.MoodleGrade
+	NotSetYet bool
+}
+
+// Keeps all data in maps, nothing is persisted. Used by tests and demos (DATABASE_TYPE=MEMORY).
+type MemoryProvider struct {
+	mu sync.Mutex
+
+	accounts                map[string]app_models.Account
+	moodleTokens            map[string]string
+	accountInfos            map[string]accountInfo
+	notificationSettings    map[string]map[string]app_models.NotificationSetting
+	notificationPreferences map[string]app_models.NotificationPreference
+	outbox                  map[string]app_models.OutboxMessage
+	sendFailures            map[string]app_models.SendFailure
+	substitutions           map[string]*substitutions
+	moodleAssignments       map[string]*moodleAssignments
+	moodleReminders         map[string][]app_models.MoodleReminder
+	moodleCourseSettings    map[string]map[int]app_models.MoodleCourseSetting
+	moodleForums            map[string]*moodleForum
+	moodleGrades            map[string]*moodleGrades
+	calendarTokens          map[string]string
+	moodleCalendarEvents    map[string][]app_models.MoodleCalendarEvent
+	jobRuns                 map[string]app_models.JobRun
+}
+
+// Returns an empty MemoryProvider
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{
+		accounts:                map[string]app_models.Account{},
+		moodleTokens:            map[string]string{},
+		accountInfos:            map[string]accountInfo{},
+		notificationSettings:    map[string]map[string]app_models.NotificationSetting{},
+		notificationPreferences: map[string]app_models.NotificationPreference{},
+		outbox:                  map[string]app_models.OutboxMessage{},
+		sendFailures:            map[string]app_models.SendFailure{},
+		substitutions:           map[string]*substitutions{},
+		moodleAssignments:       map[string]*moodleAssignments{},
+		moodleReminders:         map[string][]app_models.MoodleReminder{},
+		moodleCourseSettings:    map[string]map[int]app_models.MoodleCourseSetting{},
+		moodleForums:            map[string]*moodleForum{},
+		moodleGrades:            map[string]*moodleGrades{},
+		calendarTokens:          map[string]string{},
+		moodleCalendarEvents:    map[string][]app_models.MoodleCalendarEvent{},
+		jobRuns:                 map[string]app_models.JobRun{},
+	}
+}
+
+// Returns a new random id like the ids of the gorm provider
+func newId() string {
+	id, err := uuid.NewV4()
+	if err != nil {
+		panic(err)
+	}
+	return id.String()
+}
+
+// There are no tables, everything is created on demand
+func (m *MemoryProvider) CreateTables() error {
+	return nil
+}
+
+func (m *MemoryProvider) MigrateUp() (int, error) {
+	return 0, nil
+}
+
+func (m *MemoryProvider) MigrateDown(steps int) (int, error) {
+	return 0, nil
+}
+
+func (m *MemoryProvider) GetMigrationStatus() ([]app_models.MigrationStatus, error) {
+	return []app_models.MigrationStatus{}, nil
+}
+
+// Nothing is written to disk, so there is nothing to encrypt
+func (m *MemoryProvider) EncryptCredentials() (int, error) {
+	return 0, nil
+}
+
+func (m *MemoryProvider) CloseDB() error {
+	return nil
+}
+
+func (m *MemoryProvider) AddAccount(username, password, passwordHash string) (app_models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range m.accounts {
+		if a.Username == username {
+			return app_models.Account{}, errors.New("username already exists")
+		}
+	}
+
+	a := app_models.Account{
+		Id:           newId(),
+		Username:     username,
+		Password:     password,
+		PasswordHash: passwordHash,
+		Role:         app_models.RoleUser,
+	}
+	m.accounts[a.Id] = a
+	return a, nil
+}
+
+func (m *MemoryProvider) GetAccount(id string) (app_models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, ok := m.accounts[id]
+	if !ok {
+		return app_models.Account{}, &db_errors.ErrRecordNotFound
+	}
+	return a, nil
+}
+
+func (m *MemoryProvider) GetAccountByUsername(username string) (app_models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, a := range m.accounts {
+		if a.Username == username {
+			return a, nil
+		}
+	}
+	return app_models.Account{}, &db_errors.ErrRecordNotFound
+}
+
+// Changes an account with the given function, does nothing if the account doesn't exist like an UPDATE
+func (m *MemoryProvider) updateAccount(accountId string, update func(a *app_models.Account)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if a, ok := m.accounts[accountId]; ok {
+		update(&a)
+		m.accounts[accountId] = a
+	}
+	return nil
+}
+
+func (m *MemoryProvider) SetAccountPassword(accountId, password string) error {
+	return m.updateAccount(accountId, func(a *app_models.Account) { a.Password = password })
+}
+
+func (m *MemoryProvider) SetAccountPasswordHash(accountId, passwordHash string) error {
+	return m.updateAccount(accountId, func(a *app_models.Account) { a.PasswordHash = passwordHash })
+}
+
+func (m *MemoryProvider) SetAccountMoodleToken(accountId, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accounts[accountId]; ok {
+		m.moodleTokens[accountId] = token
+	}
+	return nil
+}
+
+func (m *MemoryProvider) SetAccountRole(accountId, role string) error {
+	return m.updateAccount(accountId, func(a *app_models.Account) { a.Role = role })
+}
+
+func (m *MemoryProvider) SetAccountDisabled(accountId string, disabled bool) error {
+	return m.updateAccount(accountId, func(a *app_models.Account) { a.Disabled = disabled })
+}
+
+// Returns all accounts ordered by their username
+func (m *MemoryProvider) GetAccounts() ([]app_models.Account, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var accounts []app_models.Account
+	for _, a := range m.accounts {
+		accounts = append(accounts, a)
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Username < accounts[j].Username
+	})
+	return accounts, nil
+}
+
+// Deletes the account and all of its data
+func (m *MemoryProvider) DeleteAccount(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.accounts, id)
+	delete(m.moodleTokens, id)
+	delete(m.accountInfos, id)
+	delete(m.notificationSettings, id)
+	delete(m.notificationPreferences, id)
+	delete(m.substitutions, id)
+	delete(m.moodleAssignments, id)
+	delete(m.moodleReminders, id)
+	delete(m.moodleCourseSettings, id)
+	delete(m.moodleForums, id)
+	delete(m.moodleGrades, id)
+	delete(m.calendarTokens, id)
+	delete(m.moodleCalendarEvents, id)
+
+	for messageId, message := range m.outbox {
+		if message.AccountId == id {
+			delete(m.outbox, messageId)
+		}
+	}
+
+	for failureId, failure := range m.sendFailures {
+		if failure.AccountId == id {
+			delete(m.sendFailures, failureId)
+		}
+	}
+
+	return nil
+}
+
+func (m *MemoryProvider) AddAccountInfo(accountId, phoneNumber string) (app_models.AccountInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.accountInfos[accountId]; ok {
+		return app_models.AccountInfo{}, errors.New("account info already exists")
+	}
+
+	m.accountInfos[accountId] = accountInfo{PhoneNumber: phoneNumber, UpdatedAt: time.Now()}
+	return app_models.AccountInfo{Account: app_models.Account{Id: accountId}, PhoneNumber: phoneNumber}, nil
+}
+
+func (m *MemoryProvider) GetAccountInfo(accountId string) (app_models.AccountInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ai, ok := m.accountInfos[accountId]
+	if !ok {
+		return app_models.AccountInfo{}, &db_errors.ErrRecordNotFound
+	}
+	return app_models.AccountInfo{Account: app_models.Account{Id: accountId}, PhoneNumber: ai.PhoneNumber}, nil
+}
+
+// Returns the ids of the accounts the phone number belongs to, the account the number was added to last comes first
+func (m *MemoryProvider) GetAccountIdsByPhoneNumber(phoneNumber string) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var accountIds []string
+	for id, ai := range m.accountInfos {
+		if ai.PhoneNumber == phoneNumber {
+			accountIds = append(accountIds, id)
+		}
+	}
+
+	if len(accountIds) == 0 {
+		return nil, &db_errors.ErrRecordNotFound
+	}
+
+	sort.Slice(accountIds, func(i, j int) bool {
+		return m.accountInfos[accountIds[i]].UpdatedAt.After(m.accountInfos[accountIds[j]].UpdatedAt)
+	})
+	return accountIds, nil
+}
+
+func (m *MemoryProvider) SetNotificationSetting(accountId, channel, recipient string) (app_models.NotificationSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.notificationSettings[accountId] == nil {
+		m.notificationSettings[accountId] = map[string]app_models.NotificationSetting{}
+	}
+
+	n, ok := m.notificationSettings[accountId][channel]
+	if !ok {
+		n = app_models.NotificationSetting{Id: newId(), AccountId: accountId, Channel: channel}
+	}
+	n.Recipient = recipient
+
+	m.notificationSettings[accountId][channel] = n
+	return n, nil
+}
+
+// Returns the notification channels of an account ordered by their name
+func (m *MemoryProvider) GetNotificationSettings(accountId string) ([]app_models.NotificationSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	settings := []app_models.NotificationSetting{}
+	for _, n := range m.notificationSettings[accountId] {
+		settings = append(settings, n)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].Channel < settings[j].Channel
+	})
+	return settings, nil
+}
+
+func (m *MemoryProvider) RemoveNotificationSetting(accountId, channel string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.notificationSettings[accountId], channel)
+	return nil
+}
+
+// Returns the notification preference of an account, accounts without a stored preference aren't paused
+func (m *MemoryProvider) GetNotificationPreference(accountId string) (app_models.NotificationPreference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.notificationPreferences[accountId]
+	if !ok {
+		return app_models.NotificationPreference{AccountId: accountId}, nil
+	}
+	return p, nil
+}
+
+func (m *MemoryProvider) SetNotificationPreference(p app_models.NotificationPreference) (app_models.NotificationPreference, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.notificationPreferences[p.AccountId] = p
+	return p, nil
+}
+
+func (m *MemoryProvider) AddOutboxMessage(accountId, phoneNumber, message string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o := app_models.OutboxMessage{
+		Id:          newId(),
+		AccountId:   accountId,
+		PhoneNumber: phoneNumber,
+		Message:     message,
+		CreatedAt:   time.Now(),
+	}
+	m.outbox[o.Id] = o
+	return nil
+}
+
+// Returns all queued notifications, oldest first
+func (m *MemoryProvider) GetOutboxMessages() ([]app_models.OutboxMessage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	messages := []app_models.OutboxMessage{}
+	for _, o := range m.outbox {
+		messages = append(messages, o)
+	}
+
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+	return messages, nil
+}
+
+func (m *MemoryProvider) RemoveOutboxMessages(ids []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		delete(m.outbox, id)
+	}
+	return nil
+}
+
+func (m *MemoryProvider) AddSendFailure(accountId, channel, sendError string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := app_models.SendFailure{
+		Id:        newId(),
+		AccountId: accountId,
+		Channel:   channel,
+		Error:     sendError,
+		CreatedAt: time.Now(),
+	}
+	m.sendFailures[s.Id] = s
+	return nil
+}
+
+// Returns the latest notifications which couldn't be sent, newest first
+func (m *MemoryProvider) GetSendFailures(limit int) ([]app_models.SendFailure, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	failures := []app_models.SendFailure{}
+	for _, s := range m.sendFailures {
+		failures = append(failures, s)
+	}
+
+	sort.SliceStable(failures, func(i, j int) bool {
+		return failures[i].CreatedAt.After(failures[j].CreatedAt)
+	})
+
+	if limit >= 0 && len(failures) > limit {
+		failures = failures[:limit]
+	}
+	return failures, nil
+}
+
+func (m *MemoryProvider) AddAccountToSubstitution(accountId, authId, authPw string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.substitutions[accountId]; ok {
+		return errors.New("account already registered in substitution updater")
+	}
+
+	m.substitutions[accountId] = &substitutions{
+		Id:        newId(),
+		AuthId:    authId,
+		AuthPw:    authPw,
+		Entries:   []app_models.Substitution{},
+		NotSetYet: true,
+		UpdatedAt: time.Now(),
+	}
+	return nil
+}
+
+func (m *MemoryProvider) SetSubstitutions(accountId string, entries []app_models.Substitution, notSetYet bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.substitutions[accountId]
+	if !ok {
+		s = &substitutions{Id: newId()}
+		m.substitutions[accountId] = s
+	}
+
+	s.Entries = append([]app_models.Substitution{}, entries...)
+	s.NotSetYet = notSetYet
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryProvider) RemoveAccountFromSubstitutionUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.substitutions, accountId)
+	return nil
+}
+
+func (m *MemoryProvider) GetSubstitutions(accountId string) (app_models.Substitutions, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.substitutions[accountId]
+	if !ok {
+		return app_models.Substitutions{}, &db_errors.ErrRecordNotFound
+	}
+
+	return app_models.Substitutions{
+		AccountId: accountId,
+		Entries:   append([]app_models.Substitution{}, s.Entries...),
+		UpdatedAt: s.UpdatedAt,
+	}, nil
+}
+
+func (m *MemoryProvider) substitutionInfo(accountId string, s *substitutions) app_models.SubstitutionInfo {
+	return app_models.SubstitutionInfo{
+		AuthId:          s.AuthId,
+		AuthPw:          s.AuthPw,
+		PhoneNumber:     m.accountInfos[accountId].PhoneNumber,
+		AccountId:       accountId,
+		SubstitutionsId: s.Id,
+		Entries:         append([]app_models.Substitution{}, s.Entries...),
+		NotSetYet:       s.NotSetYet,
+	}
+}
+
+// Returns the ids of the accounts which aren't disabled and are in the given updater, ordered by their id
+func (m *MemoryProvider) enabledAccountIds(inUpdater func(accountId string) bool) []string {
+	var accountIds []string
+	for id, a := range m.accounts {
+		if !a.Disabled && inUpdater(id) {
+			accountIds = append(accountIds, id)
+		}
+	}
+	sort.Strings(accountIds)
+	return accountIds
+}
+
+func (m *MemoryProvider) GetAllSubstitutionInfos() ([]app_models.SubstitutionInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []app_models.SubstitutionInfo
+	for _, id := range m.enabledAccountIds(func(id string) bool { return m.substitutions[id] != nil }) {
+		infos = append(infos, m.substitutionInfo(id, m.substitutions[id]))
+	}
+	return infos, nil
+}
+
+func (m *MemoryProvider) GetSubstitutionInfos(accountId string) (app_models.SubstitutionInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s, ok := m.substitutions[accountId]
+	if _, exists := m.accounts[accountId]; !ok || !exists {
+		return app_models.SubstitutionInfo{}, &db_errors.ErrRecordNotFound
+	}
+	return m.substitutionInfo(accountId, s), nil
+}
+
+func (m *MemoryProvider) AddAccountToMoodleAssignmentUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.moodleAssignments[accountId]; ok {
+		return errors.New("account already exists in MoodleAssignmentUpdater")
+	}
+
+	m.moodleAssignments[accountId] = &moodleAssignments{Id: newId(), NotSetYet: true, UpdatedAt: time.Now()}
+	return nil
+}
+
+// Replaces the stored moodle assignments of an account, they are kept ordered by their id
+func (m *MemoryProvider) SetMoodleAssignments(accountId string, assignments []app_models.MoodleAssignment, notSetYet bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ma, ok := m.moodleAssignments[accountId]
+	if !ok {
+		ma = &moodleAssignments{Id: newId()}
+		m.moodleAssignments[accountId] = ma
+	}
+
+	ma.Assignments = append([]app_models.MoodleAssignment{}, assignments...)
+	sort.SliceStable(ma.Assignments, func(i, j int) bool {
+		return ma.Assignments[i].Id < ma.Assignments[j].Id
+	})
+	ma.NotSetYet = notSetYet
+	ma.UpdatedAt = time.Now()
+	return nil
+}
+
+func (m *MemoryProvider) RemoveAccountFromMoodleAssignmentUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.moodleAssignments, accountId)
+	return nil
+}
+
+func (m *MemoryProvider) GetMoodleAssignments(accountId string) (app_models.MoodleAssignments, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ma, ok := m.moodleAssignments[accountId]
+	if !ok {
+		return app_models.MoodleAssignments{}, &db_errors.ErrRecordNotFound
+	}
+
+	return app_models.MoodleAssignments{
+		AccountId:   accountId,
+		Assignments: append([]app_models.MoodleAssignment(nil), ma.Assignments...),
+		UpdatedAt:   ma.UpdatedAt,
+	}, nil
+}
+
+func (m *MemoryProvider) moodleAssignmentInfo(accountId string, ma *moodleAssignments) app_models.MoodleAssignmentInfo {
+	a := m.accounts[accountId]
+	return app_models.MoodleAssignmentInfo{
+		AuthId:                  a.Username,
+		AuthPw:                  a.Password,
+		MoodleToken:             m.moodleTokens[accountId],
+		PhoneNumber:             m.accountInfos[accountId].PhoneNumber,
+		AccountId:               accountId,
+		MoodleUserAssignmentsId: ma.Id,
+		Assignments:             append([]app_models.MoodleAssignment(nil), ma.Assignments...),
+		NotSetYet:               ma.NotSetYet,
+	}
+}
+
+func (m *MemoryProvider) GetAllMoodleAssignmentInfos() ([]app_models.MoodleAssignmentInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []app_models.MoodleAssignmentInfo
+	for _, id := range m.enabledAccountIds(func(id string) bool { return m.moodleAssignments[id] != nil }) {
+		infos = append(infos, m.moodleAssignmentInfo(id, m.moodleAssignments[id]))
+	}
+	return infos, nil
+}
+
+func (m *MemoryProvider) GetMoodleAssignmentInfos(accountId string) (app_models.MoodleAssignmentInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ma, ok := m.moodleAssignments[accountId]
+	if _, exists := m.accounts[accountId]; !ok || !exists {
+		return app_models.MoodleAssignmentInfo{}, &db_errors.ErrRecordNotFound
+	}
+	return m.moodleAssignmentInfo(accountId, ma), nil
+}
+
+// Stores the sent reminders, a reminder can only be stored once
+func (m *MemoryProvider) AddMoodleReminders(reminders []app_models.MoodleReminder) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range reminders {
+		for _, stored := range m.moodleReminders[r.AccountId] {
+			if stored.AssignmentId == r.AssignmentId && stored.Offset == r.Offset {
+				return errors.New("reminder already exists")
+			}
+		}
+	}
+
+	for _, r := range reminders {
+		r.Offset = r.Offset.Truncate(time.Second)
+		m.moodleReminders[r.AccountId] = append(m.moodleReminders[r.AccountId], r)
+	}
+	return nil
+}
+
+func (m *MemoryProvider) GetMoodleReminders(accountId string) ([]app_models.MoodleReminder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]app_models.MoodleReminder(nil), m.moodleReminders[accountId]...), nil
+}
+
+func (m *MemoryProvider) SetMoodleCourseSetting(accountId string, courseId int, remindersEnabled bool) (app_models.MoodleCourseSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.moodleCourseSettings[accountId] == nil {
+		m.moodleCourseSettings[accountId] = map[int]app_models.MoodleCourseSetting{}
+	}
+
+	s := app_models.MoodleCourseSetting{AccountId: accountId, CourseId: courseId, RemindersEnabled: remindersEnabled}
+	m.moodleCourseSettings[accountId][courseId] = s
+	return s, nil
+}
+
+// Returns the stored settings of an account for its moodle courses, ordered by the course id
+func (m *MemoryProvider) GetMoodleCourseSettings(accountId string) ([]app_models.MoodleCourseSetting, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var settings []app_models.MoodleCourseSetting
+	for _, s := range m.moodleCourseSettings[accountId] {
+		settings = append(settings, s)
+	}
+
+	sort.Slice(settings, func(i, j int) bool {
+		return settings[i].CourseId < settings[j].CourseId
+	})
+	return settings, nil
+}
+
+func (m *MemoryProvider) AddAccountToMoodleForumUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.moodleForums[accountId]; ok {
+		return errors.New("account already exists in MoodleForumUpdater")
+	}
+
+	m.moodleForums[accountId] = &moodleForum{NotSetYet: true}
+	return nil
+}
+
+// Replaces the known discussions of an account, they are kept ordered by their id
+func (m *MemoryProvider) SetMoodleDiscussions(accountId string, discussionIds []int, notSetYet bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.moodleForums[accountId]
+	if !ok {
+		f = &moodleForum{}
+		m.moodleForums[accountId] = f
+	}
+
+	f.DiscussionIds = append([]int(nil), discussionIds...)
+	sort.Ints(f.DiscussionIds)
+	f.NotSetYet = notSetYet
+	return nil
+}
+
+func (m *MemoryProvider) RemoveAccountFromMoodleForumUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.moodleForums, accountId)
+	return nil
+}
+
+func (m *MemoryProvider) moodleForumInfo(accountId string, f *moodleForum) app_models.MoodleForumInfo {
+	a := m.accounts[accountId]
+	return app_models.MoodleForumInfo{
+		AuthId:        a.Username,
+		AuthPw:        a.Password,
+		MoodleToken:   m.moodleTokens[accountId],
+		PhoneNumber:   m.accountInfos[accountId].PhoneNumber,
+		AccountId:     accountId,
+		DiscussionIds: append([]int(nil), f.DiscussionIds...),
+		NotSetYet:     f.NotSetYet,
+	}
+}
+
+func (m *MemoryProvider) GetAllMoodleForumInfos() ([]app_models.MoodleForumInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []app_models.MoodleForumInfo
+	for _, id := range m.enabledAccountIds(func(id string) bool { return m.moodleForums[id] != nil }) {
+		infos = append(infos, m.moodleForumInfo(id, m.moodleForums[id]))
+	}
+	return infos, nil
+}
+
+func (m *MemoryProvider) GetMoodleForumInfos(accountId string) (app_models.MoodleForumInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, ok := m.moodleForums[accountId]
+	if _, exists := m.accounts[accountId]; !ok || !exists {
+		return app_models.MoodleForumInfo{}, &db_errors.ErrRecordNotFound
+	}
+	return m.moodleForumInfo(accountId, f), nil
+}
+
+func (m *MemoryProvider) AddAccountToMoodleGradeUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.moodleGrades[accountId]; ok {
+		return errors.New("account already exists in MoodleGradeUpdater")
+	}
+
+	m.moodleGrades[accountId] = &moodleGrades{NotSetYet: true}
+	return nil
+}
+
+// Replaces the grade snapshot of an account, the grades are kept ordered by course and item
+func (m *MemoryProvider) SetMoodleGrades(accountId string, grades []app_models.MoodleGrade, notSetYet bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.moodleGrades[accountId]
+	if !ok {
+		g = &moodleGrades{}
+		m.moodleGrades[accountId] = g
+	}
+
+	g.Grades = append([]app_models.MoodleGrade(nil), grades...)
+	sort.SliceStable(g.Grades, func(i, j int) bool {
+		if g.Grades[i].CourseId != g.Grades[j].CourseId {
+			return g.Grades[i].CourseId < g.Grades[j].CourseId
+		}
+		return g.Grades[i].ItemId < g.Grades[j].ItemId
+	})
+	g.NotSetYet = notSetYet
+	return nil
+}
+
+func (m *MemoryProvider) RemoveAccountFromMoodleGradeUpdater(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.moodleGrades, accountId)
+	return nil
+}
+
+func (m *MemoryProvider) moodleGradeInfo(accountId string, g *moodleGrades) app_models.MoodleGradeInfo {
+	a := m.accounts[accountId]
+	return app_models.MoodleGradeInfo{
+		AuthId:      a.Username,
+		AuthPw:      a.Password,
+		MoodleToken: m.moodleTokens[accountId],
+		PhoneNumber: m.accountInfos[accountId].PhoneNumber,
+		AccountId:   accountId,
+		Grades:      append([]app_models.MoodleGrade(nil), g.Grades...),
+		NotSetYet:   g.NotSetYet,
+	}
+}
+
+func (m *MemoryProvider) GetAllMoodleGradeInfos() ([]app_models.MoodleGradeInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []app_models.MoodleGradeInfo
+	for _, id := range m.enabledAccountIds(func(id string) bool { return m.moodleGrades[id] != nil }) {
+		infos = append(infos, m.moodleGradeInfo(id, m.moodleGrades[id]))
+	}
+	return infos, nil
+}
+
+func (m *MemoryProvider) GetMoodleGradeInfos(accountId string) (app_models.MoodleGradeInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	g, ok := m.moodleGrades[accountId]
+	if _, exists := m.accounts[accountId]; !ok || !exists {
+		return app_models.MoodleGradeInfo{}, &db_errors.ErrRecordNotFound
+	}
+	return m.moodleGradeInfo(accountId, g), nil
+}
+
+func (m *MemoryProvider) GetCalendarToken(accountId string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	token, ok := m.calendarTokens[accountId]
+	if !ok {
+		return "", &db_errors.ErrRecordNotFound
+	}
+	return token, nil
+}
+
+// Creates or replaces the calendar token of an account, a token can only belong to one account
+func (m *MemoryProvider) SetCalendarToken(accountId, token string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for id, t := range m.calendarTokens {
+		if t == token && id != accountId {
+			return errors.New("calendar token already exists")
+		}
+	}
+
+	m.calendarTokens[accountId] = token
+	return nil
+}
+
+func (m *MemoryProvider) RemoveCalendarToken(accountId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.calendarTokens, accountId)
+	delete(m.moodleCalendarEvents, accountId)
+	return nil
+}
+
+func (m *MemoryProvider) GetAccountIdByCalendarToken(token string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for accountId, t := range m.calendarTokens {
+		if t == token {
+			return accountId, nil
+		}
+	}
+	return "", &db_errors.ErrRecordNotFound
+}
+
+// Replaces the synced moodle calendar events of an account, they are kept ordered by their start
+func (m *MemoryProvider) SetMoodleCalendarEvents(accountId string, events []app_models.MoodleCalendarEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	es := append([]app_models.MoodleCalendarEvent(nil), events...)
+	sort.SliceStable(es, func(i, j int) bool {
+		return es[i].Start.Before(es[j].Start)
+	})
+
+	m.moodleCalendarEvents[accountId] = es
+	return nil
+}
+
+func (m *MemoryProvider) GetMoodleCalendarEvents(accountId string) ([]app_models.MoodleCalendarEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]app_models.MoodleCalendarEvent(nil), m.moodleCalendarEvents[accountId]...), nil
+}
+
+func (m *MemoryProvider) GetAllMoodleCalendarInfos() ([]app_models.MoodleCalendarInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var infos []app_models.MoodleCalendarInfo
+	for _, id := range m.enabledAccountIds(func(id string) bool { _, ok := m.calendarTokens[id]; return ok }) {
+		a := m.accounts[id]
+		infos = append(infos, app_models.MoodleCalendarInfo{
+			AuthId:      a.Username,
+			AuthPw:      a.Password,
+			MoodleToken: m.moodleTokens[id],
+			AccountId:   id,
+		})
+	}
+	return infos, nil
+}
+
+func (m *MemoryProvider) GetMoodleCalendarInfos(accountId string) (app_models.MoodleCalendarInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	a, exists := m.accounts[accountId]
+	if _, ok := m.calendarTokens[accountId]; !ok || !exists {
+		return app_models.MoodleCalendarInfo{}, &db_errors.ErrRecordNotFound
+	}
+
+	return app_models.MoodleCalendarInfo{
+		AuthId:      a.Username,
+		AuthPw:      a.Password,
+		MoodleToken: m.moodleTokens[accountId],
+		AccountId:   accountId,
+	}, nil
+}
+
+func (m *MemoryProvider) AddJobRun(jobRun app_models.JobRun) (app_models.JobRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobRun.Id = newId()
+	m.jobRuns[jobRun.Id] = jobRun
+	return jobRun, nil
+}
+
+// Returns the latest runs of a job, newest first. If job is empty, the runs of all jobs are returned
+func (m *MemoryProvider) GetJobRuns(job string, limit int) ([]app_models.JobRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runs := []app_models.JobRun{}
+	for _, j := range m.jobRuns {
+		if job == "" || j.Job == job {
+			runs = append(runs, j)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+
+	if limit >= 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
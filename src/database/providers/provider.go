@@ -2,37 +2,92 @@ package provider
 
 import (
 	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/config"
 	"github.com/dattito/purrmannplus-backend/database/providers/gorm"
+	"github.com/dattito/purrmannplus-backend/database/providers/memory"
 )
 
 type Provider interface {
 	CreateTables() error
+	MigrateUp() (int, error)
+	MigrateDown(steps int) (int, error)
+	GetMigrationStatus() ([]models.MigrationStatus, error)
+	EncryptCredentials() (int, error)
 	CloseDB() error
 
-	AddAccount(username, password string) (models.Account, error)
+	AddAccount(username, password, passwordHash string) (models.Account, error)
 	GetAccount(id string) (models.Account, error)
-	GetAccountByCredentials(username, password string) (models.Account, error)
+	GetAccountByUsername(username string) (models.Account, error)
+	SetAccountPassword(accountId, password string) error
+	SetAccountPasswordHash(accountId, passwordHash string) error
+	SetAccountMoodleToken(accountId, token string) error
+	SetAccountRole(accountId, role string) error
+	SetAccountDisabled(accountId string, disabled bool) error
 	GetAccounts() ([]models.Account, error)
 	DeleteAccount(id string) error
 	AddAccountInfo(accountId, phoneNumber string) (models.AccountInfo, error)
 	GetAccountInfo(accountId string) (models.AccountInfo, error)
+	GetAccountIdsByPhoneNumber(phoneNumber string) ([]string, error)
+
+	SetNotificationSetting(accountId, channel, recipient string) (models.NotificationSetting, error)
+	GetNotificationSettings(accountId string) ([]models.NotificationSetting, error)
+	RemoveNotificationSetting(accountId, channel string) error
+	GetNotificationPreference(accountId string) (models.NotificationPreference, error)
+	SetNotificationPreference(preference models.NotificationPreference) (models.NotificationPreference, error)
+	AddOutboxMessage(accountId, phoneNumber, message string) error
+	GetOutboxMessages() ([]models.OutboxMessage, error)
+	RemoveOutboxMessages(ids []string) error
+	AddSendFailure(accountId, channel, sendError string) error
+	GetSendFailures(limit int) ([]models.SendFailure, error)
 
 	AddAccountToSubstitution(accountId, authId, authPw string) error
-	SetSubstitutions(accountId string, substitutions map[string][]string, notSetYet bool) error
+	SetSubstitutions(accountId string, substitutions []models.Substitution, notSetYet bool) error
 	RemoveAccountFromSubstitutionUpdater(accountId string) error
 	GetSubstitutions(accountId string) (models.Substitutions, error)
 	GetAllSubstitutionInfos() ([]models.SubstitutionInfo, error)
 	GetSubstitutionInfos(accountId string) (models.SubstitutionInfo, error)
 
 	AddAccountToMoodleAssignmentUpdater(accountId string) error
-	SetMoodleAssignments(accountId string, assignmentIds []int, notSetYet bool) error
+	SetMoodleAssignments(accountId string, assignments []models.MoodleAssignment, notSetYet bool) error
 	RemoveAccountFromMoodleAssignmentUpdater(accountId string) error
 	GetMoodleAssignments(accountId string) (models.MoodleAssignments, error)
 	GetAllMoodleAssignmentInfos() ([]models.MoodleAssignmentInfo, error)
 	GetMoodleAssignmentInfos(accountId string) (models.MoodleAssignmentInfo, error)
+	AddMoodleReminders(reminders []models.MoodleReminder) error
+	GetMoodleReminders(accountId string) ([]models.MoodleReminder, error)
+	SetMoodleCourseSetting(accountId string, courseId int, remindersEnabled bool) (models.MoodleCourseSetting, error)
+	GetMoodleCourseSettings(accountId string) ([]models.MoodleCourseSetting, error)
+
+	AddAccountToMoodleForumUpdater(accountId string) error
+	SetMoodleDiscussions(accountId string, discussionIds []int, notSetYet bool) error
+	RemoveAccountFromMoodleForumUpdater(accountId string) error
+	GetAllMoodleForumInfos() ([]models.MoodleForumInfo, error)
+	GetMoodleForumInfos(accountId string) (models.MoodleForumInfo, error)
+
+	AddAccountToMoodleGradeUpdater(accountId string) error
+	SetMoodleGrades(accountId string, grades []models.MoodleGrade, notSetYet bool) error
+	RemoveAccountFromMoodleGradeUpdater(accountId string) error
+	GetAllMoodleGradeInfos() ([]models.MoodleGradeInfo, error)
+	GetMoodleGradeInfos(accountId string) (models.MoodleGradeInfo, error)
+
+	GetCalendarToken(accountId string) (string, error)
+	SetCalendarToken(accountId, token string) error
+	RemoveCalendarToken(accountId string) error
+	GetAccountIdByCalendarToken(token string) (string, error)
+	SetMoodleCalendarEvents(accountId string, events []models.MoodleCalendarEvent) error
+	GetMoodleCalendarEvents(accountId string) ([]models.MoodleCalendarEvent, error)
+	GetAllMoodleCalendarInfos() ([]models.MoodleCalendarInfo, error)
+	GetMoodleCalendarInfos(accountId string) (models.MoodleCalendarInfo, error)
+
+	AddJobRun(jobRun models.JobRun) (models.JobRun, error)
+	GetJobRuns(job string, limit int) ([]models.JobRun, error)
 }
 
-func GetProvider() (Provider, error) {
+func GetProvider(cfg *config.Config) (Provider, error) {
 	// * Add / Change Provider here
-	return gorm.NewGormProvider()
+	if cfg.DATABASE_TYPE == "MEMORY" {
+		return memory.NewMemoryProvider(), nil
+	}
+
+	return gorm.NewGormProvider(cfg.DATABASE_TYPE, cfg.DATABASE_URI, cfg.DATABASE_LOG_LEVEL, cfg.DATABASE_ENCRYPTION_KEY)
 }
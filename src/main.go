@@ -2,11 +2,15 @@ package main
 
 import (
 	"log"
+	"net/http"
+	"os"
 
 	"github.com/dattito/purrmannplus-backend/api"
 	"github.com/dattito/purrmannplus-backend/app"
+	"github.com/dattito/purrmannplus-backend/app/commands"
 	"github.com/dattito/purrmannplus-backend/config"
 	"github.com/dattito/purrmannplus-backend/database"
+	"github.com/dattito/purrmannplus-backend/services/notifier"
 	"github.com/dattito/purrmannplus-backend/services/scheduler"
 	"github.com/dattito/purrmannplus-backend/services/signal_message_sender"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
@@ -15,46 +19,66 @@ import (
 func main() {
 
 	// Load configuration
-	if err := config.Init(); err != nil {
+	cfg, err := config.Load()
+	if err != nil {
 		log.Fatalf("Failed to load configuration: %s", err)
 	}
 
-	if !config.ENABLE_API && !config.ENABLE_SUBSTITUTIONS_SCHEDULER {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrateCommand(cfg, os.Args[2:])
+		return
+	}
+
+	if !cfg.ENABLE_API && !cfg.ENABLE_SUBSTITUTIONS_SCHEDULER {
 		log.Fatal("No API or scheduler enabled. Exiting.")
 	}
 
-	if err := logging.Init(); err != nil {
+	if err := logging.Init(cfg.LOG_LEVEL, cfg.LOGGING_FILE); err != nil {
 		log.Fatalf("Failed to initialize logging: %s", err)
 	}
 
-	scheduler.Init()
+	s := scheduler.New()
 
-	if err := signal_message_sender.Init(); err != nil {
+	signal, err := signal_message_sender.New(cfg.SIGNAL_SENDER_PHONENUMBER, cfg.SIGNAL_CLI_GRPC_API_URL)
+	if err != nil {
 		log.Fatalf("Failed to initialize signal message sender: %s", err)
 	}
 
-	if err := database.Init(); err != nil {
+	db, err := database.Init(cfg)
+	if err != nil {
 		log.Fatalf("Failed to initialize database: %s", err)
 	}
 
-	app.Init()
+	httpClient := &http.Client{}
+
+	a, err := commands.New(cfg, db, signal, notifier.New(cfg, signal, httpClient), s, httpClient)
+	if err != nil {
+		log.Fatalf("Failed to initialize app: %s", err)
+	}
 
-	api.Init()
+	if err := app.Init(a); err != nil {
+		log.Fatalf("Failed to schedule the updaters: %s", err)
+	}
+
+	server, err := api.New(a)
+	if err != nil {
+		log.Fatalf("Failed to initialize api: %s", err)
+	}
 
 	logging.Infof("Starting PurrmannPlus-Backend")
 
-	if config.ENABLE_API && config.ENABLE_SUBSTITUTIONS_SCHEDULER {
+	if cfg.ENABLE_API && cfg.ENABLE_SUBSTITUTIONS_SCHEDULER {
 		// Start scheduler
-		scheduler.StartAsync()
+		s.StartAsync()
 	}
 
-	if config.ENABLE_API {
+	if cfg.ENABLE_API {
 		// Start API
-		logging.Fatal(api.StartListening())
+		logging.Fatal(server.StartListening())
 	}
 
-	if !config.ENABLE_API && config.ENABLE_SUBSTITUTIONS_SCHEDULER {
+	if !cfg.ENABLE_API && cfg.ENABLE_SUBSTITUTIONS_SCHEDULER {
 		// Start scheduler
-		scheduler.StartBlocking()
+		s.StartBlocking()
 	}
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/database"
+)
+
+const migrateUsage = "usage: migrate up | down [steps] | status"
+
+// Runs the migrate subcommand: applies, reverts or lists the versioned database migrations
+func runMigrateCommand(cfg *config.Config, args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	db, err := database.Connect(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %s", err)
+	}
+	defer db.CloseDB()
+
+	switch args[0] {
+	case "up":
+		count, err := db.MigrateUp()
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %s", err)
+		}
+		fmt.Printf("Applied %d migrations\n", count)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal(migrateUsage)
+			}
+		}
+
+		count, err := db.MigrateDown(steps)
+		if err != nil {
+			log.Fatalf("Failed to revert migrations: %s", err)
+		}
+		fmt.Printf("Reverted %d migrations\n", count)
+	case "status":
+		statuses, err := db.GetMigrationStatus()
+		if err != nil {
+			log.Fatalf("Failed to get migration status: %s", err)
+		}
+
+		for _, s := range statuses {
+			applied := "pending"
+			if s.Applied {
+				applied = "applied at " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d %-40s %s\n", s.Version, s.Name, applied)
+		}
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,98 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// A single event of a calendar
+type Event struct {
+	UID         string // Has to be unique and stable, so that calendar apps can update the event
+	Summary     string
+	Description string
+	Start       time.Time
+	End         time.Time // Optional, for all day events the day after the last day
+	AllDay      bool
+	URL         string
+}
+
+// Escapes a text value as described in RFC 5545 3.3.11
+func escapeText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, ";", "\\;")
+	s = strings.ReplaceAll(s, ",", "\\,")
+	s = strings.ReplaceAll(s, "\r\n", "\\n")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return s
+}
+
+// Folds a content line after 75 octets as described in RFC 5545 3.1, without splitting utf-8 characters
+func foldLine(line string) string {
+	var sb strings.Builder
+	length := 0
+	for _, r := range line {
+		size := len(string(r))
+		if length+size > 75 {
+			sb.WriteString("\r\n ")
+			length = 1
+		}
+		sb.WriteRune(r)
+		length += size
+	}
+	sb.WriteString("\r\n")
+	return sb.String()
+}
+
+func writeLine(sb *strings.Builder, name, value string) {
+	sb.WriteString(foldLine(name + ":" + value))
+}
+
+// Returns the calendar with the given events in the iCalendar format
+func Encode(name string, events []Event, now time.Time) string {
+	var sb strings.Builder
+
+	writeLine(&sb, "BEGIN", "VCALENDAR")
+	writeLine(&sb, "VERSION", "2.0")
+	writeLine(&sb, "PRODID", "-//PurrmannPlus//PurrmannPlus//DE")
+	writeLine(&sb, "CALSCALE", "GREGORIAN")
+	writeLine(&sb, "METHOD", "PUBLISH")
+	writeLine(&sb, "X-WR-CALNAME", escapeText(name))
+
+	for _, e := range events {
+		writeLine(&sb, "BEGIN", "VEVENT")
+		writeLine(&sb, "UID", e.UID)
+		writeLine(&sb, "DTSTAMP", now.UTC().Format("20060102T150405Z"))
+
+		if e.AllDay {
+			end := e.End
+			if end.IsZero() {
+				end = e.Start.AddDate(0, 0, 1)
+			}
+			writeLine(&sb, "DTSTART;VALUE=DATE", e.Start.Format("20060102"))
+			writeLine(&sb, "DTEND;VALUE=DATE", end.Format("20060102"))
+		} else {
+			writeLine(&sb, "DTSTART", e.Start.UTC().Format("20060102T150405Z"))
+			if !e.End.IsZero() {
+				writeLine(&sb, "DTEND", e.End.UTC().Format("20060102T150405Z"))
+			}
+		}
+
+		writeLine(&sb, "SUMMARY", escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(&sb, "DESCRIPTION", escapeText(e.Description))
+		}
+		if e.URL != "" {
+			writeLine(&sb, "URL", e.URL)
+		}
+		writeLine(&sb, "END", "VEVENT")
+	}
+
+	writeLine(&sb, "END", "VCALENDAR")
+	return sb.String()
+}
+
+// Returns a uid for an event, which is unique for the account
+func UID(accountId, kind string, id interface{}) string {
+	return fmt.Sprintf("%s-%v-%s@purrmannplus", kind, id, accountId)
+}
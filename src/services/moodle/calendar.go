@@ -0,0 +1,59 @@
+package moodle
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+)
+
+// Events which are older than this are not fetched anymore
+const calendarEventsLookback = 30 * 24 * time.Hour
+
+// The maximum number of events moodle returns with one call
+const calendarEventsLimit = 50
+
+// The parts of the response of core_calendar_get_action_events_by_timesort which are needed
+type actionEventsResponse struct {
+	Events []struct {
+		ID         int    `json:"id"`
+		Name       string `json:"name"`
+		ModuleName string `json:"modulename"`
+		Instance   int    `json:"instance"`
+		TimeStart  int64  `json:"timestart"`
+		URL        string `json:"url"`
+		Course     *struct {
+			FullName string `json:"fullname"`
+		} `json:"course"`
+	} `json:"events"`
+}
+
+// Returns the action events (e.g. deadlines of assignments and quizzes) of the user
+func (c *Client) GetCalendarEvents(ctx context.Context, token string, now time.Time) ([]models.MoodleCalendarEvent, error) {
+	var r actionEventsResponse
+	if err := c.callWebservice(ctx, token, "core_calendar_get_action_events_by_timesort", url.Values{
+		"timesortfrom": {strconv.FormatInt(now.Add(-calendarEventsLookback).Unix(), 10)},
+		"limitnum":     {strconv.Itoa(calendarEventsLimit)},
+	}, &r); err != nil {
+		return nil, err
+	}
+
+	var events []models.MoodleCalendarEvent
+	for _, e := range r.Events {
+		event := models.MoodleCalendarEvent{
+			Id:         e.ID,
+			Name:       e.Name,
+			ModuleName: e.ModuleName,
+			Instance:   e.Instance,
+			Start:      time.Unix(e.TimeStart, 0),
+			Url:        e.URL,
+		}
+		if e.Course != nil {
+			event.CourseName = e.Course.FullName
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
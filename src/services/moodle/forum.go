@@ -0,0 +1,136 @@
+package moodle
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dattito/purrmannplus-backend/app/models"
+)
+
+// The type of the announcement forum every course has
+const announcementForumType = "news"
+
+// The parts of a forum of mod_forum_get_forums_by_courses which are needed
+type forum struct {
+	ID     int    `json:"id"`
+	Course int    `json:"course"`
+	Type   string `json:"type"`
+	Name   string `json:"name"`
+}
+
+// The parts of the response of mod_forum_get_forum_discussions which are needed
+type discussionsResponse struct {
+	Discussions []struct {
+		Discussion   int    `json:"discussion"`
+		Subject      string `json:"subject"`
+		Message      string `json:"message"`
+		UserFullName string `json:"userfullname"`
+		Created      int64  `json:"created"`
+	} `json:"discussions"`
+}
+
+// The parts of the response of core_course_get_courses_by_field which are needed
+type coursesResponse struct {
+	Courses []struct {
+		ID       int    `json:"id"`
+		FullName string `json:"fullname"`
+	} `json:"courses"`
+}
+
+// Returns the announcement forums of all courses the user is enrolled in
+func (c *Client) getAnnouncementForums(ctx context.Context, token string) ([]forum, error) {
+	var forums []forum
+	if err := c.callWebservice(ctx, token, "mod_forum_get_forums_by_courses", nil, &forums); err != nil {
+		return nil, err
+	}
+
+	var announcementForums []forum
+	for _, f := range forums {
+		if f.Type == announcementForumType {
+			announcementForums = append(announcementForums, f)
+		}
+	}
+	return announcementForums, nil
+}
+
+// Returns the full names of the given courses
+func (c *Client) getCourseNames(ctx context.Context, token string, courseIds []int) (map[int]string, error) {
+	courseNames := make(map[int]string)
+	if len(courseIds) == 0 {
+		return courseNames, nil
+	}
+
+	var ids []string
+	for _, id := range courseIds {
+		ids = append(ids, strconv.Itoa(id))
+	}
+
+	var r coursesResponse
+	if err := c.callWebservice(ctx, token, "core_course_get_courses_by_field",
+		url.Values{"field": {"ids"}, "value": {strings.Join(ids, ",")}}, &r); err != nil {
+		return nil, err
+	}
+
+	for _, c := range r.Courses {
+		courseNames[c.ID] = c.FullName
+	}
+	return courseNames, nil
+}
+
+// Returns the text of a html formatted message
+func htmlToText(html string) string {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return html
+	}
+	return strings.TrimSpace(doc.Text())
+}
+
+// Returns the announcements of all courses the user is enrolled in, sorted by their discussion id
+func (c *Client) GetAnnouncements(ctx context.Context, token string) ([]models.MoodleAnnouncement, error) {
+	forums, err := c.getAnnouncementForums(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var courseIds []int
+	for _, f := range forums {
+		courseIds = append(courseIds, f.Course)
+	}
+
+	courseNames, err := c.getCourseNames(ctx, token, courseIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var announcements []models.MoodleAnnouncement
+	for _, f := range forums {
+		var r discussionsResponse
+		if err := c.callWebservice(ctx, token, "mod_forum_get_forum_discussions",
+			url.Values{"forumid": {strconv.Itoa(f.ID)}}, &r); err != nil {
+			return nil, err
+		}
+
+		for _, d := range r.Discussions {
+			announcements = append(announcements, models.MoodleAnnouncement{
+				DiscussionId: d.Discussion,
+				CourseId:     f.Course,
+				CourseName:   courseNames[f.Course],
+				Subject:      d.Subject,
+				Message:      htmlToText(d.Message),
+				Author:       d.UserFullName,
+				CreatedAt:    time.Unix(d.Created, 0),
+			})
+		}
+	}
+
+	sort.Slice(announcements, func(i, j int) bool {
+		return announcements[i].DiscussionId < announcements[j].DiscussionId
+	})
+	return announcements, nil
+}
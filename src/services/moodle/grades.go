@@ -0,0 +1,98 @@
+package moodle
+
+import (
+	"context"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+)
+
+// The parts of the response of core_webservice_get_site_info which are needed
+type siteInfoResponse struct {
+	UserId int `json:"userid"`
+}
+
+// The parts of a course of core_enrol_get_users_courses which are needed
+type userCourse struct {
+	ID       int    `json:"id"`
+	FullName string `json:"fullname"`
+}
+
+// The parts of the response of gradereport_user_get_grade_items which are needed
+type gradeItemsResponse struct {
+	UserGrades []struct {
+		CourseId   int `json:"courseid"`
+		GradeItems []struct {
+			ID             int    `json:"id"`
+			ItemName       string `json:"itemname"`
+			ItemType       string `json:"itemtype"`
+			GradeFormatted string `json:"gradeformatted"`
+		} `json:"gradeitems"`
+	} `json:"usergrades"`
+}
+
+// Returns the id of the user the token belongs to
+func (c *Client) getUserId(ctx context.Context, token string) (int, error) {
+	var r siteInfoResponse
+	if err := c.callWebservice(ctx, token, "core_webservice_get_site_info", nil, &r); err != nil {
+		return 0, err
+	}
+	return r.UserId, nil
+}
+
+// Returns true if the grade of an item was released to the student
+func isReleasedGrade(gradeFormatted string) bool {
+	grade := strings.TrimSpace(gradeFormatted)
+	return grade != "" && grade != "-"
+}
+
+// Returns the released grades of all courses the user is enrolled in, sorted by course and item.
+// Course and category totals are left out, as they change with every single grade.
+func (c *Client) GetGrades(ctx context.Context, token string) ([]models.MoodleGrade, error) {
+	userId, err := c.getUserId(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var courses []userCourse
+	if err := c.callWebservice(ctx, token, "core_enrol_get_users_courses",
+		url.Values{"userid": {strconv.Itoa(userId)}}, &courses); err != nil {
+		return nil, err
+	}
+
+	var grades []models.MoodleGrade
+	for _, course := range courses {
+		var r gradeItemsResponse
+		if err := c.callWebservice(ctx, token, "gradereport_user_get_grade_items",
+			url.Values{"courseid": {strconv.Itoa(course.ID)}, "userid": {strconv.Itoa(userId)}}, &r); err != nil {
+			return nil, err
+		}
+
+		for _, userGrade := range r.UserGrades {
+			for _, item := range userGrade.GradeItems {
+				if item.ItemType == "course" || item.ItemType == "category" || !isReleasedGrade(item.GradeFormatted) {
+					continue
+				}
+
+				grades = append(grades, models.MoodleGrade{
+					CourseId:   course.ID,
+					CourseName: course.FullName,
+					ItemId:     item.ID,
+					ItemName:   htmlToText(item.ItemName),
+					Grade:      strings.TrimSpace(item.GradeFormatted),
+				})
+			}
+		}
+	}
+
+	sort.Slice(grades, func(i, j int) bool {
+		if grades[i].CourseId != grades[j].CourseId {
+			return grades[i].CourseId < grades[j].CourseId
+		}
+		return grades[i].ItemId < grades[j].ItemId
+	})
+	return grades, nil
+}
@@ -1,26 +1,39 @@
 package moodle
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"sort"
+	"time"
 
 	"github.com/dattito/purrmannplus-backend/app/models"
-	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/utils/httpclient"
 	"github.com/dattito/purrmannplus-backend/utils/logging"
 )
 
+// Talks to the moodle website at the given url
+type Client struct {
+	Url  string
+	HTTP *httpclient.Client
+}
+
+// Returns a Client for the moodle website at the given url, an empty url disables all requests
+func NewClient(url string, http *httpclient.Client) *Client {
+	return &Client{Url: url, HTTP: http}
+}
+
 type reponse struct {
 	Token        string `json:"token"`
 	ErrorMessage string `json:"error"`
 	ErrorCode    string `json:"errorcode"`
 }
 
-func GetToken(username, password string) (string, error) {
-	if config.MOODLE_URL == "" {
+func (c *Client) GetToken(ctx context.Context, username, password string) (string, error) {
+	if c.Url == "" {
 		return "", fmt.Errorf("moodle URL not set")
 	}
 
@@ -28,7 +41,7 @@ func GetToken(username, password string) (string, error) {
 		return "", nil
 	}
 
-	resp, err := http.PostForm(fmt.Sprintf("%s/login/token.php", config.MOODLE_URL),
+	resp, err := c.HTTP.PostForm(ctx, fmt.Sprintf("%s/login/token.php", c.Url),
 		url.Values{
 			"username": {username},
 			"password": {password},
@@ -69,8 +82,8 @@ func GetToken(username, password string) (string, error) {
 }
 
 // Checks if the credentials are correct, should be the same as substitutions.CheckCredentials()
-func CheckCredentials(username, password string) (bool, error) {
-	token, err := GetToken(username, password)
+func (c *Client) CheckCredentials(username, password string) (bool, error) {
+	token, err := c.GetToken(context.Background(), username, password)
 	if err != nil {
 		return false, err
 	}
@@ -78,71 +91,107 @@ func CheckCredentials(username, password string) (bool, error) {
 	return token != "", nil
 }
 
-func GetRawAssignments(token string) (models.MoodleCourse, error) {
-	if config.MOODLE_URL == "" {
-		return models.MoodleCourse{}, fmt.Errorf("moodle URL not set")
+// Returned by webservice calls if the token is expired or was revoked
+var ErrInvalidToken = errors.New("moodle token is invalid")
+
+// The error moodle returns instead of the result of a webservice function
+type webserviceError struct {
+	Exception string `json:"exception"`
+	ErrorCode string `json:"errorcode"`
+	Message   string `json:"message"`
+}
+
+// Calls a function of the moodle webservice and decodes the result into v
+func (c *Client) callWebservice(ctx context.Context, token, function string, params url.Values, v interface{}) error {
+	if c.Url == "" {
+		return fmt.Errorf("moodle URL not set")
 	}
 
 	if token == "" {
-		return models.MoodleCourse{}, fmt.Errorf("token is empty")
+		return fmt.Errorf("token is empty")
 	}
 
-	resp, err := http.Get(fmt.Sprintf("%s/webservice/rest/server.php?wstoken=%s&wsfunction=mod_assign_get_assignments&moodlewsrestformat=json", config.MOODLE_URL, token))
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("wstoken", token)
+	params.Set("wsfunction", function)
+	params.Set("moodlewsrestformat", "json")
+
+	resp, err := c.HTTP.Get(ctx, fmt.Sprintf("%s/webservice/rest/server.php?%s", c.Url, params.Encode()))
 	if err != nil {
-		logging.Errorf("Error while getting moodle assignments: %s", err)
-		return models.MoodleCourse{}, err
+		return err
 	}
 
 	defer resp.Body.Close()
 
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var wsErr webserviceError
+	if err := json.Unmarshal(body, &wsErr); err == nil && wsErr.Exception != "" {
+		if wsErr.ErrorCode == "invalidtoken" {
+			return ErrInvalidToken
+		}
+		return fmt.Errorf("moodle webservice %s failed: %s (%s)", function, wsErr.Message, wsErr.ErrorCode)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+func (c *Client) GetRawAssignments(ctx context.Context, token string) (models.MoodleCourse, error) {
 	var r models.MoodleCourse
-	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+	if err := c.callWebservice(ctx, token, "mod_assign_get_assignments", nil, &r); err != nil {
+		logging.Errorf("Error while getting moodle assignments: %s", err)
 		return models.MoodleCourse{}, err
 	}
 
 	return r, nil
 }
 
-func GetRawAssignmentsByCredentials(username, password string) (models.MoodleCourse, error) {
-	token, err := GetToken(username, password)
+func (c *Client) GetRawAssignmentsByCredentials(ctx context.Context, username, password string) (models.MoodleCourse, error) {
+	token, err := c.GetToken(ctx, username, password)
 	if err != nil {
 		return models.MoodleCourse{}, err
 	}
 
-	return GetRawAssignments(token)
+	return c.GetRawAssignments(ctx, token)
 }
 
-func GetAssignmentIDs(assingments models.MoodleCourse) []int {
-	var ids []int
-	for _, course := range assingments.Courses {
+// Returns the assignments of all courses, sorted by their id
+func GetAssignments(rawAssignments models.MoodleCourse) []models.MoodleAssignment {
+	var assignments []models.MoodleAssignment
+	for _, course := range rawAssignments.Courses {
 		for _, assignment := range course.Assignments {
-			ids = append(ids, assignment.ID)
+			courseId := assignment.Course
+			if courseId == 0 {
+				courseId = course.ID
+			}
+
+			assignments = append(assignments, models.MoodleAssignment{
+				Id:         assignment.ID,
+				CourseId:   courseId,
+				CourseName: course.FullName,
+				Name:       assignment.Name,
+				Intro:      assignment.Intro,
+				DueDate:    unixToTime(assignment.DueDate),
+				CutoffDate: unixToTime(assignment.CutoffDate),
+			})
 		}
 	}
-	sort.Ints(ids)
-	return ids
-}
 
-func GetAssignmentIDsByCredentials(username, password string) ([]int, error) {
-	token, err := GetToken(username, password)
-	if err != nil {
-		return []int{}, err
-	}
-
-	assingments, err := GetRawAssignments(token)
-	if err != nil {
-		return []int{}, err
-	}
-
-	return GetAssignmentIDs(assingments), nil
+	sort.Slice(assignments, func(i, j int) bool {
+		return assignments[i].Id < assignments[j].Id
+	})
+	return assignments
 }
 
-func GetAssignmentIdToCourseNameMap(assingments models.MoodleCourse) map[int]string {
-	var idsToCourses map[int]string = make(map[int]string)
-	for _, course := range assingments.Courses {
-		for _, assignment := range course.Assignments {
-			idsToCourses[assignment.ID] = course.FullName
-		}
+// Moodle uses 0 for dates which aren't set
+func unixToTime(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
 	}
-	return idsToCourses
+	return time.Unix(timestamp, 0)
 }
@@ -0,0 +1,159 @@
+package moodletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+type user struct {
+	password string
+	token    string
+}
+
+// A fake of the moodle website for tests, which answers login/token.php and the webservice functions
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	users     map[string]*user
+	responses map[string]map[string]interface{} // token -> webservice function -> response
+	calls     map[string]int
+}
+
+// Starts a moodle without any users, the caller has to call Close when finished
+func NewServer() *Server {
+	s := &Server{
+		users:     map[string]*user{},
+		responses: map[string]map[string]interface{}{},
+		calls:     map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Adds a user who logs in with the given credentials, returns the token of the user
+func (s *Server) AddUser(username, password string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token := fmt.Sprintf("token-%s-%d", username, len(s.users))
+	s.users[username] = &user{password: password, token: token}
+	s.responses[token] = map[string]interface{}{
+		"core_webservice_get_site_info": map[string]interface{}{"userid": len(s.users), "username": username},
+		"mod_assign_get_assignments":    map[string]interface{}{"courses": []interface{}{}},
+	}
+	return token
+}
+
+// Sets the response of a webservice function for the user, it's encoded as json
+func (s *Server) SetResponse(username, function string, response interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.responses[s.user(username).token][function] = response
+}
+
+// Invalidates the token of the user, a new one is issued on the next login
+func (s *Server) RevokeToken(username string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.user(username)
+	delete(s.responses, u.token)
+
+	u.token += "-renewed"
+	s.responses[u.token] = map[string]interface{}{}
+}
+
+// Returns how often the webservice function was called
+func (s *Server) Calls(function string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.calls[function]
+}
+
+func (s *Server) user(username string) *user {
+	u, ok := s.users[username]
+	if !ok {
+		panic(fmt.Sprintf("moodletest: unknown user %s", username))
+	}
+	return u
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/login/token.php":
+		s.handleToken(w, r)
+	case "/webservice/rest/server.php":
+		s.handleWebservice(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	u, ok := s.users[r.Form.Get("username")]
+	if !ok || u.password != r.Form.Get("password") {
+		writeJSON(w, map[string]string{
+			"error":     "Ungültige Anmeldedaten, bitte versuchen Sie es erneut!",
+			"errorcode": "invalidlogin",
+		})
+		return
+	}
+
+	if r.Form.Get("service") != "moodle_mobile_app" {
+		writeJSON(w, map[string]string{
+			"error":     "Web service is not available (it doesn't exist or might be disabled)",
+			"errorcode": "servicenotavailable",
+		})
+		return
+	}
+
+	writeJSON(w, map[string]string{"token": u.token})
+}
+
+func (s *Server) handleWebservice(w http.ResponseWriter, r *http.Request) {
+	function := r.Form.Get("wsfunction")
+	s.calls[function]++
+
+	responses, ok := s.responses[r.Form.Get("wstoken")]
+	if !ok {
+		writeJSON(w, map[string]string{
+			"exception": "moodle_exception",
+			"errorcode": "invalidtoken",
+			"message":   "Invalid token - token not found",
+		})
+		return
+	}
+
+	response, ok := responses[function]
+	if !ok {
+		writeJSON(w, map[string]string{
+			"exception": "webservice_access_exception",
+			"errorcode": "accessexception",
+			"message":   fmt.Sprintf("Access control exception (%s is not set up)", function),
+		})
+		return
+	}
+
+	writeJSON(w, response)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
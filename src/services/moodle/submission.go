@@ -0,0 +1,92 @@
+package moodle
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+type submission struct {
+	Status string `json:"status"`
+}
+
+// The parts of the response of mod_assign_get_submission_status which are needed
+type submissionStatusResponse struct {
+	LastAttempt *struct {
+		Submission     *submission `json:"submission"`
+		TeamSubmission *submission `json:"teamsubmission"`
+		GradingStatus  string      `json:"gradingstatus"`
+	} `json:"lastattempt"`
+	Feedback *struct {
+		GradedDate int64 `json:"gradeddate"`
+	} `json:"feedback"`
+}
+
+// Returns the submission status of the user the token belongs to for an assignment
+func (c *Client) GetSubmissionStatus(ctx context.Context, token string, assignmentId int) (string, error) {
+	var r submissionStatusResponse
+	if err := c.callWebservice(ctx, token, "mod_assign_get_submission_status",
+		url.Values{"assignid": {strconv.Itoa(assignmentId)}}, &r); err != nil {
+		return "", err
+	}
+
+	if r.Feedback != nil || (r.LastAttempt != nil && r.LastAttempt.GradingStatus == "graded") {
+		return models.MoodleSubmissionStatusGraded, nil
+	}
+
+	if r.LastAttempt == nil {
+		return models.MoodleSubmissionStatusNew, nil
+	}
+
+	s := r.LastAttempt.Submission
+	if s == nil || s.Status == "" {
+		s = r.LastAttempt.TeamSubmission
+	}
+	if s == nil || s.Status == "" {
+		return models.MoodleSubmissionStatusNew, nil
+	}
+
+	switch s.Status {
+	case models.MoodleSubmissionStatusSubmitted, models.MoodleSubmissionStatusDraft:
+		return s.Status, nil
+	default:
+		return models.MoodleSubmissionStatusNew, nil
+	}
+}
+
+// Sets the submission status of the assignments. Only open assignments which aren't known as submitted
+// or graded are requested from moodle, all others keep their known status. If the status of a single assignment
+// can't be fetched, the known status is kept. Returns an error if the context is done before all statuses were fetched.
+func (c *Client) AddSubmissionStatuses(ctx context.Context, token string, assignments, knownAssignments []models.MoodleAssignment, now time.Time) error {
+	known := make(map[int]models.MoodleAssignment)
+	for _, a := range knownAssignments {
+		known[a.Id] = a
+	}
+
+	for i := range assignments {
+		knownAssignment := known[assignments[i].Id]
+		assignments[i].SubmissionStatus = knownAssignment.SubmissionStatus
+
+		if knownAssignment.IsDone() || assignments[i].IsClosed(now) {
+			continue
+		}
+
+		status, err := c.GetSubmissionStatus(ctx, token, assignments[i].Id)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+
+			logging.Warningf("Error while getting submission status of moodle assignment %d: %s", assignments[i].Id, err)
+			continue
+		}
+
+		assignments[i].SubmissionStatus = status
+	}
+
+	return nil
+}
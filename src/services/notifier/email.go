@@ -0,0 +1,54 @@
+package notifier
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+type emailNotifier struct {
+	host     string
+	port     int
+	username string
+	password string
+	from     string
+}
+
+func newEmailNotifier(host string, port int, username, password, from string) *emailNotifier {
+	return &emailNotifier{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+// Sends the message as plain text email to the given email address
+func (e *emailNotifier) Send(message, recipient string) error {
+	var auth smtp.Auth
+	if e.username != "" {
+		auth = smtp.PlainAuth("", e.username, e.password, e.host)
+	}
+
+	body := strings.Join([]string{
+		fmt.Sprintf("From: %s", e.from),
+		fmt.Sprintf("To: %s", recipient),
+		"Subject: PurrmannPlus",
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"",
+		message,
+	}, "\r\n")
+
+	err := smtp.SendMail(fmt.Sprintf("%s:%d", e.host, e.port), auth, e.from, []string{recipient}, []byte(body))
+	if err != nil {
+		logging.Errorf("Error sending email. Error: %s", err.Error())
+	} else {
+		logging.Debugf("Email sent successfully. Recipient: %s", recipient)
+	}
+
+	return err
+}
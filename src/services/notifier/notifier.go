@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/config"
+	"github.com/dattito/purrmannplus-backend/utils/httpclient"
+)
+
+// A channel messages can be sent through, the recipient depends on the channel
+// (phone number, email address, chat id, url)
+type Notifier interface {
+	Send(message, recipient string) error
+}
+
+const (
+	ChannelSignal   = "signal"
+	ChannelEmail    = "email"
+	ChannelTelegram = "telegram"
+	ChannelWebhook  = "webhook"
+)
+
+// All notifiers which are configured, by channel
+type Notifiers map[string]Notifier
+
+// Returns all notifiers which are configured, signal messages are sent with the given notifier
+// and requests to the Telegram Bot API with the given Doer
+func New(cfg *config.Config, signal Notifier, doer httpclient.Doer) Notifiers {
+	n := Notifiers{
+		ChannelSignal:  signal,
+		ChannelWebhook: newWebhookNotifier(),
+	}
+
+	if cfg.SMTP_HOST != "" {
+		n[ChannelEmail] = newEmailNotifier(cfg.SMTP_HOST, cfg.SMTP_PORT, cfg.SMTP_USERNAME, cfg.SMTP_PASSWORD, cfg.SMTP_FROM)
+	}
+
+	if cfg.TELEGRAM_BOT_TOKEN != "" {
+		n[ChannelTelegram] = newTelegramNotifier(cfg.TELEGRAM_BOT_TOKEN, doer)
+	}
+
+	return n
+}
+
+// Returns the notifier of the given channel, returns an error if it's unknown or not configured
+func (n Notifiers) Get(channel string) (Notifier, error) {
+	notifier, ok := n[channel]
+	if !ok {
+		return nil, fmt.Errorf("notification channel '%s' is not available", channel)
+	}
+
+	return notifier, nil
+}
+
+// Checks if the recipient has the right format for the given channel
+func (n Notifiers) ValidateRecipient(channel, recipient string) error {
+	if _, err := n.Get(channel); err != nil {
+		return err
+	}
+
+	if recipient == "" {
+		return errors.New("recipient is empty")
+	}
+
+	switch channel {
+	case ChannelEmail:
+		if _, err := mail.ParseAddress(recipient); err != nil {
+			return errors.New("invalid email address")
+		}
+	case ChannelWebhook:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return validateWebhookURL(ctx, recipient)
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/utils/httpclient"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+// The maximum time a message may take to be sent to the Telegram Bot API
+const telegramTimeout = 10 * time.Second
+
+type telegramNotifier struct {
+	botToken string
+	apiUrl   string
+	doer     httpclient.Doer
+}
+
+type telegramResponse struct {
+	Ok          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+func newTelegramNotifier(botToken string, doer httpclient.Doer) *telegramNotifier {
+	return &telegramNotifier{
+		botToken: botToken,
+		apiUrl:   "https://api.telegram.org",
+		doer:     doer,
+	}
+}
+
+// Sends the message to the given chat id using the Telegram Bot API
+func (t *telegramNotifier) Send(message, recipient string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), telegramTimeout)
+	defer cancel()
+
+	form := url.Values{
+		"chat_id": {recipient},
+		"text":    {message},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/bot%s/sendMessage", t.apiUrl, t.botToken), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.doer.Do(req)
+	if err != nil {
+		// The url of the error contains the bot token, which must not end up in the logs or the stored send failures
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = fmt.Errorf("error sending telegram message: %w", urlErr.Err)
+		}
+
+		logging.Errorf("Error sending telegram message. Error: %s", err.Error())
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	var r telegramResponse
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return err
+	}
+
+	if !r.Ok {
+		logging.Errorf("Error sending telegram message. Error: %s", r.Description)
+		return fmt.Errorf("error sending telegram message: %s", r.Description)
+	}
+
+	logging.Debugf("Telegram message sent successfully. Recipient: %s", recipient)
+
+	return nil
+}
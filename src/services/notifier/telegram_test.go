@@ -0,0 +1,66 @@
+package notifier
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestTelegramSendsWithTheGivenDoer(t *testing.T) {
+	var chatId, text string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botTOKEN/sendMessage" {
+			t.Errorf("path = %q, want /botTOKEN/sendMessage", r.URL.Path)
+		}
+
+		chatId, text = r.FormValue("chat_id"), r.FormValue("text")
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer srv.Close()
+
+	n := newTelegramNotifier("TOKEN", srv.Client())
+	n.apiUrl = srv.URL
+
+	if err := n.Send("Hallo", "42"); err != nil {
+		t.Fatalf("Send() = %v, want nil", err)
+	}
+
+	if chatId != "42" || text != "Hallo" {
+		t.Errorf("sent chat_id=%q text=%q, want chat_id=42 text=Hallo", chatId, text)
+	}
+}
+
+func TestTelegramReturnsTheErrorOfTheApi(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"ok": false, "description": "chat not found"}`))
+	}))
+	defer srv.Close()
+
+	n := newTelegramNotifier("TOKEN", srv.Client())
+	n.apiUrl = srv.URL
+
+	if err := n.Send("Hallo", "42"); err == nil {
+		t.Error("Send() = nil, want an error")
+	}
+}
+
+// A Doer which fails like http.Client does when the connection can't be established
+type failingDoer struct{}
+
+func (failingDoer) Do(req *http.Request) (*http.Response, error) {
+	return nil, &url.Error{Op: "Post", URL: req.URL.String(), Err: errors.New("connection refused")}
+}
+
+func TestTelegramErrorsDontContainTheBotToken(t *testing.T) {
+	err := newTelegramNotifier("SECRET-TOKEN", failingDoer{}).Send("Hallo", "42")
+	if err == nil {
+		t.Fatal("Send() = nil, want an error")
+	}
+
+	if strings.Contains(err.Error(), "SECRET-TOKEN") {
+		t.Errorf("Send() error = %q, contains the bot token", err)
+	}
+}
@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+var errWebhookAddressNotAllowed = errors.New("webhook url has to point to a public address")
+
+type webhookNotifier struct {
+	client *http.Client
+}
+
+type webhookPayload struct {
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Returns a notifier which only connects to public addresses and doesn't follow redirects,
+// so that users can't make the server call internal services
+func newWebhookNotifier() *webhookNotifier {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// Checked on every connection, so that a dns entry can't change to an internal address after the validation
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return errWebhookAddressNotAllowed
+			}
+			return nil
+		},
+	}
+
+	return &webhookNotifier{
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Returns true if the address can be reached from the internet, i.e. it isn't a loopback, private or link-local address
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// Checks that the webhook url uses https and that its host resolves to public addresses only
+func validateWebhookURL(ctx context.Context, recipient string) error {
+	u, err := url.Parse(recipient)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("invalid webhook url, it has to start with https://")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, u.Hostname())
+	if err != nil {
+		return fmt.Errorf("can't resolve the host of the webhook url: %w", err)
+	}
+
+	for _, addr := range addrs {
+		if !isPublicIP(addr.IP) {
+			return errWebhookAddressNotAllowed
+		}
+	}
+
+	return nil
+}
+
+// Posts the message as json to the given url
+func (w *webhookNotifier) Send(message, recipient string) error {
+	if u, err := url.Parse(recipient); err != nil || u.Scheme != "https" {
+		return errors.New("invalid webhook url, it has to start with https://")
+	}
+
+	body, err := json.Marshal(webhookPayload{
+		Message: message,
+		SentAt:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(recipient, "application/json", bytes.NewReader(body))
+	if err != nil {
+		logging.Errorf("Error calling webhook. Error: %s", err.Error())
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		logging.Errorf("Error calling webhook. Status: %d", resp.StatusCode)
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	logging.Debugf("Webhook called successfully. Url: %s", recipient)
+
+	return nil
+}
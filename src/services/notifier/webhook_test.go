@@ -0,0 +1,45 @@
+package notifier
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateWebhookRecipient(t *testing.T) {
+	n := Notifiers{ChannelWebhook: newWebhookNotifier()}
+
+	for _, recipient := range []string{
+		"http://93.184.216.34/hook",
+		"https://127.0.0.1/hook",
+		"https://localhost/hook",
+		"https://10.0.0.1/hook",
+		"https://169.254.169.254/latest/meta-data",
+		"https://[::1]/hook",
+		"https:///hook",
+	} {
+		if err := n.ValidateRecipient(ChannelWebhook, recipient); err == nil {
+			t.Errorf("ValidateRecipient(%q) = nil, want an error", recipient)
+		}
+	}
+
+	if err := n.ValidateRecipient(ChannelWebhook, "https://93.184.216.34/hook"); err != nil {
+		t.Errorf("ValidateRecipient() of a public address = %v, want nil", err)
+	}
+}
+
+func TestWebhookDoesNotConnectToLocalAddresses(t *testing.T) {
+	called := false
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer srv.Close()
+
+	if err := newWebhookNotifier().Send("Hallo", srv.URL); err == nil {
+		t.Error("Send() to a local address = nil, want an error")
+	}
+
+	if called {
+		t.Error("the webhook on a local address was called")
+	}
+}
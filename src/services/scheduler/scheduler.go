@@ -7,26 +7,34 @@ import (
 	"github.com/go-co-op/gocron"
 )
 
-var S *gocron.Scheduler
+// Runs the jobs of the updaters at their cron times
+type Scheduler struct {
+	s *gocron.Scheduler
+}
 
 // Initizalize the scheduler object
-func Init() {
-	S = gocron.NewScheduler(time.Local)
+func New() *Scheduler {
+	return &Scheduler{s: gocron.NewScheduler(time.Local)}
 }
 
-// Add a job to the scheduler object
-func AddJob(cron string, exec func()) {
-	S.Cron(cron).Do(exec)
+// Add a job to the scheduler object, a new run doesn't start while the previous run is still active
+func (s *Scheduler) AddJob(name, cron string, exec func()) error {
+	_, err := s.s.Cron(cron).Tag(name).SingletonMode().Do(exec)
+	if err != nil {
+		logging.Errorf("Error while adding job %s: %s", name, err.Error())
+	}
+
+	return err
 }
 
 // Start the scheduler async
-func StartAsync() {
+func (s *Scheduler) StartAsync() {
 	logging.Info("Starting scheduler async")
-	S.StartAsync()
+	s.s.StartAsync()
 }
 
 // Start the scheduler sync
-func StartBlocking() {
+func (s *Scheduler) StartBlocking() {
 	logging.Info("Starting scheduler blocking")
-	S.StartBlocking()
+	s.s.StartBlocking()
 }
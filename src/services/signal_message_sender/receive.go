@@ -0,0 +1,79 @@
+package signal_message_sender
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender/proto"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+// A text message someone sent to the signal sender number
+type IncomingMessage struct {
+	Sender    string
+	Text      string
+	Timestamp time.Time
+}
+
+// A single message as returned by the signal cli json output
+type envelope struct {
+	Envelope struct {
+		Source       string `json:"source"`
+		SourceNumber string `json:"sourceNumber"`
+		Timestamp    int64  `json:"timestamp"`
+		DataMessage  *struct {
+			Message *string `json:"message"`
+		} `json:"dataMessage"`
+	} `json:"envelope"`
+}
+
+// Returns the text message of a raw message, receipts and typing indicators are ignored
+func parseIncomingMessage(raw string) (IncomingMessage, bool, error) {
+	var e envelope
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		return IncomingMessage{}, false, err
+	}
+
+	if e.Envelope.DataMessage == nil || e.Envelope.DataMessage.Message == nil {
+		return IncomingMessage{}, false, nil
+	}
+
+	sender := e.Envelope.SourceNumber
+	if sender == "" {
+		sender = e.Envelope.Source
+	}
+
+	return IncomingMessage{
+		Sender:    sender,
+		Text:      *e.Envelope.DataMessage.Message,
+		Timestamp: time.UnixMilli(e.Envelope.Timestamp),
+	}, true, nil
+}
+
+// Receives the messages sent to the signal sender number, waits up to timeout seconds for new messages
+func (sms *SignalMessageSender) Receive(ctx context.Context, timeout int) ([]IncomingMessage, error) {
+	res, err := sms.Client.Receive(ctx, &proto.ReceiveRequest{
+		Number:  sms.SenderNumber,
+		Timeout: strconv.Itoa(timeout),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []IncomingMessage
+	for _, raw := range res.Messages {
+		message, ok, err := parseIncomingMessage(raw)
+		if err != nil {
+			logging.Warningf("Skipping signal message which could not be parsed: %s", err.Error())
+			continue
+		}
+
+		if ok {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,112 @@
+package signaltest
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/services/signal_message_sender/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// A message sent through the fake signal cli
+type Message struct {
+	Number     string
+	Recipients []string
+	Text       string
+}
+
+// A fake of the signal cli grpc api for tests, which records the sent messages and
+// hands out the incoming messages added by the test
+type Server struct {
+	proto.UnimplementedSignalServiceServer
+
+	Addr string
+
+	grpcServer *grpc.Server
+
+	mu       sync.Mutex
+	sent     []Message
+	incoming []string
+}
+
+// Starts a signal cli api on a random local port, the caller has to call Close when finished
+func NewServer() (*Server, error) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Addr:       lis.Addr().String(),
+		grpcServer: grpc.NewServer(),
+	}
+	proto.RegisterSignalServiceServer(s.grpcServer, s)
+
+	go s.grpcServer.Serve(lis)
+
+	return s, nil
+}
+
+// Stops the server and closes all connections
+func (s *Server) Close() {
+	s.grpcServer.Stop()
+}
+
+func (s *Server) Send(ctx context.Context, req *proto.SendRequest) (*proto.SendResponse, error) {
+	return s.record(req.Number, req.Recipients, req.Message), nil
+}
+
+func (s *Server) SendV2(ctx context.Context, req *proto.SendV2Request) (*proto.SendResponse, error) {
+	return s.record(req.Number, req.Recipients, req.Message), nil
+}
+
+func (s *Server) record(number string, recipients []string, text string) *proto.SendResponse {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sent = append(s.sent, Message{Number: number, Recipients: recipients, Text: text})
+	return &proto.SendResponse{Timestamp: timestamppb.Now()}
+}
+
+// Returns the incoming messages added since the last call, like the signal cli does
+func (s *Server) Receive(ctx context.Context, req *proto.ReceiveRequest) (*proto.ReceiveResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	messages := s.incoming
+	s.incoming = nil
+	return &proto.ReceiveResponse{Messages: messages}, nil
+}
+
+// Queues a text message from the sender, which is returned by the next Receive call
+func (s *Server) AddIncomingMessage(sender, text string) error {
+	raw, err := json.Marshal(map[string]interface{}{
+		"envelope": map[string]interface{}{
+			"source":       sender,
+			"sourceNumber": sender,
+			"timestamp":    time.Now().UnixMilli(),
+			"dataMessage":  map[string]interface{}{"message": text},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.incoming = append(s.incoming, string(raw))
+	return nil
+}
+
+// Returns all messages sent so far
+func (s *Server) Sent() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Message(nil), s.sent...)
+}
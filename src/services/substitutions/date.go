@@ -0,0 +1,42 @@
+package substitutions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var dateRegex = regexp.MustCompile(`(\d{1,2})\.(\d{1,2})\.`)
+
+// Returns the date of a weekday header like "Mo 13.12.". The plan doesn't contain a year,
+// so the year is chosen which brings the date closest to now.
+func ParseDate(header string, now time.Time) (time.Time, error) {
+	match := dateRegex.FindStringSubmatch(header)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("no date found in %q", header)
+	}
+
+	day, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	if day < 1 || day > 31 || month < 1 || month > 12 {
+		return time.Time{}, fmt.Errorf("invalid date in %q", header)
+	}
+
+	var closest time.Time
+	for _, year := range []int{now.Year() - 1, now.Year(), now.Year() + 1} {
+		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+		if closest.IsZero() || absDuration(date.Sub(now)) < absDuration(closest.Sub(now)) {
+			closest = date
+		}
+	}
+
+	return closest, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
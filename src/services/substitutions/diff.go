@@ -0,0 +1,161 @@
+package substitutions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+)
+
+type ChangeType int
+
+const (
+	Added ChangeType = iota
+	Modified
+	Removed
+)
+
+// A change between two versions of the substitution plan, Old is empty for added and New is empty for removed entries
+type Change struct {
+	Type ChangeType
+	Old  models.Substitution
+	New  models.Substitution
+}
+
+// Returns the names of the fields which differ between Old and New
+func (c Change) ChangedFields() []string {
+	var fields []string
+	if c.Old.Class != c.New.Class {
+		fields = append(fields, "class")
+	}
+	if c.Old.Subject != c.New.Subject {
+		fields = append(fields, "subject")
+	}
+	if c.Old.OriginalTeacher != c.New.OriginalTeacher {
+		fields = append(fields, "original_teacher")
+	}
+	if c.Old.SubstituteTeacher != c.New.SubstituteTeacher {
+		fields = append(fields, "substitute_teacher")
+	}
+	if c.Old.Room != c.New.Room {
+		fields = append(fields, "room")
+	}
+	if c.Old.Note != c.New.Note {
+		fields = append(fields, "note")
+	}
+	return fields
+}
+
+// Returns the entry the change is about, the old one for removed entries and the new one otherwise
+func (c Change) Entry() models.Substitution {
+	if c.Type == Removed {
+		return c.Old
+	}
+	return c.New
+}
+
+// Sorts the changes by their day and period. Days which can't be parsed are sorted after the others.
+func SortChanges(changes []Change, now time.Time) {
+	type sortableChange struct {
+		change Change
+		date   time.Time // Zero if the day can't be parsed
+		period int
+	}
+
+	sortable := make([]sortableChange, len(changes))
+	for i, change := range changes {
+		date, _ := ParseDate(change.Entry().Date, now)
+		period, _ := PeriodNumber(change.Entry().Period)
+		sortable[i] = sortableChange{change: change, date: date, period: period}
+	}
+
+	sort.SliceStable(sortable, func(i, j int) bool {
+		a, b := sortable[i], sortable[j]
+		if !a.date.Equal(b.date) {
+			if a.date.IsZero() || b.date.IsZero() {
+				return b.date.IsZero()
+			}
+			return a.date.Before(b.date)
+		}
+		return a.period < b.period
+	})
+
+	for i := range sortable {
+		changes[i] = sortable[i].change
+	}
+}
+
+type substitutionKey struct {
+	Date   string
+	Period string
+}
+
+func keyOf(s models.Substitution) substitutionKey {
+	return substitutionKey{Date: s.Date, Period: s.Period}
+}
+
+// Compares two versions of the substitution plan. Entries are matched by day and period,
+// if there are multiple entries in the same period, equal entries are matched first and the rest in order.
+func Diff(oldSubstitutions, newSubstitutions []models.Substitution) []Change {
+	oldByKey := map[substitutionKey][]models.Substitution{}
+	for _, s := range oldSubstitutions {
+		oldByKey[keyOf(s)] = append(oldByKey[keyOf(s)], s)
+	}
+
+	newByKey := map[substitutionKey][]models.Substitution{}
+	var keys []substitutionKey
+	for _, s := range newSubstitutions {
+		if _, ok := newByKey[keyOf(s)]; !ok {
+			keys = append(keys, keyOf(s))
+		}
+		newByKey[keyOf(s)] = append(newByKey[keyOf(s)], s)
+	}
+
+	// Keys only found in the old plan are appended so removed entries keep their order
+	for _, s := range oldSubstitutions {
+		if _, ok := newByKey[keyOf(s)]; !ok {
+			newByKey[keyOf(s)] = nil
+			keys = append(keys, keyOf(s))
+		}
+	}
+
+	var changes []Change
+	for _, key := range keys {
+		olds, news := withoutEqualEntries(oldByKey[key], newByKey[key])
+
+		i := 0
+		for ; i < len(olds) && i < len(news); i++ {
+			changes = append(changes, Change{Type: Modified, Old: olds[i], New: news[i]})
+		}
+		for _, s := range news[i:] {
+			changes = append(changes, Change{Type: Added, New: s})
+		}
+		for _, s := range olds[i:] {
+			changes = append(changes, Change{Type: Removed, Old: s})
+		}
+	}
+
+	return changes
+}
+
+// Removes all entries which are found in both lists
+func withoutEqualEntries(olds, news []models.Substitution) ([]models.Substitution, []models.Substitution) {
+	remainingOlds := append([]models.Substitution{}, olds...)
+	var remainingNews []models.Substitution
+
+	for _, n := range news {
+		found := false
+		for i, o := range remainingOlds {
+			if n == o {
+				remainingOlds = append(remainingOlds[:i], remainingOlds[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			remainingNews = append(remainingNews, n)
+		}
+	}
+
+	return remainingOlds, remainingNews
+}
@@ -1,5 +1,7 @@
 package substitutions
 
+import "fmt"
+
 type wrongCredentialsError struct{}
 
 func (*wrongCredentialsError) Error() string {
@@ -7,3 +9,16 @@ func (*wrongCredentialsError) Error() string {
 }
 
 var WrongCredentialsError error = &wrongCredentialsError{}
+
+// Returned if the substitution page doesn't look as expected, e.g. because the layout of the pmwiki changed
+type ParseError struct {
+	Row    int // Number of the table row the error occurred in, counted from 1 over all tables; 0 if it isn't related to a row
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	if e.Row == 0 {
+		return fmt.Sprintf("HPG: can't parse substitution page: %s", e.Reason)
+	}
+	return fmt.Sprintf("HPG: can't parse row %d of substitution page: %s", e.Row, e.Reason)
+}
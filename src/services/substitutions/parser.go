@@ -0,0 +1,226 @@
+package substitutions
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/utils/logging"
+)
+
+// Column order of the substitution table on the pmwiki page
+const (
+	columnPeriod = iota
+	columnClass
+	columnSubject
+	columnOriginalTeacher
+	columnSubstituteTeacher
+	columnRoom
+	columnNote
+	columnCount
+)
+
+var weekdays = map[string]bool{
+	"mo": true, "di": true, "mi": true, "do": true, "fr": true, "sa": true, "so": true,
+	"montag": true, "dienstag": true, "mittwoch": true, "donnerstag": true, "freitag": true, "samstag": true, "sonntag": true,
+}
+
+// A single period like "3" or a range like "3 - 4"
+var periodCellRegex = regexp.MustCompile(`^\d{1,2}\.?(\s*[-–/]\s*\d{1,2}\.?)?$`)
+
+// Titles of the period column, rows starting with them repeat the column titles
+var periodColumnTitles = map[string]bool{"std": true, "std.": true, "stunde": true, "stunden": true}
+
+// Returns true if the given string begins with a weekday, e.g. "Mo 13.12." or "Freitag, 17.12."
+func beginsWithAWeekday(s string) bool {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return false
+	}
+
+	return weekdays[strings.ToLower(strings.TrimRight(fields[0], ".,:"))]
+}
+
+// Creates a substitution from the cells of a table row, missing cells are left empty
+func newSubstitution(date string, cells []string) models.Substitution {
+	cell := func(i int) string {
+		if i < len(cells) {
+			return cells[i]
+		}
+		return ""
+	}
+
+	return models.Substitution{
+		Date:              date,
+		Period:            cell(columnPeriod),
+		Class:             cell(columnClass),
+		Subject:           cell(columnSubject),
+		OriginalTeacher:   cell(columnOriginalTeacher),
+		SubstituteTeacher: cell(columnSubstituteTeacher),
+		Room:              cell(columnRoom),
+		Note:              cell(columnNote),
+	}
+}
+
+// A row of a table, cells spanning multiple columns are followed by empty cells
+type tableRow struct {
+	cells    []string
+	onlyHead bool // True if the row consists of th cells only
+}
+
+// Returns the text of the cells of a row with normalized whitespace
+func readTableRow(tr *goquery.Selection) tableRow {
+	row := tableRow{onlyHead: true}
+	tr.ChildrenFiltered("td, th").Each(func(_ int, cell *goquery.Selection) {
+		if !cell.Is("th") {
+			row.onlyHead = false
+		}
+
+		row.cells = append(row.cells, strings.Join(strings.Fields(cell.Text()), " "))
+
+		span, err := strconv.Atoi(cell.AttrOr("colspan", "1"))
+		for i := 1; err == nil && i < span && i < columnCount; i++ {
+			row.cells = append(row.cells, "")
+		}
+	})
+	return row
+}
+
+// Returns the cells which aren't empty
+func (r tableRow) nonEmptyCells() []string {
+	var cells []string
+	for _, cell := range r.cells {
+		if cell != "" {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
+
+func (r tableRow) isDayHeader() bool {
+	cells := r.nonEmptyCells()
+	return len(cells) > 0 && beginsWithAWeekday(cells[0])
+}
+
+// Returns the rows of the table itself, without the rows of nested tables
+func tableRows(table *goquery.Selection) []tableRow {
+	var rows []tableRow
+	table.Find("tr").Each(func(_ int, tr *goquery.Selection) {
+		if tr.Closest("table").IsSelection(table) {
+			rows = append(rows, readTableRow(tr))
+		}
+	})
+	return rows
+}
+
+// Returns the substitutions shown on the pmwiki page of a student. Returns WrongCredentialsError if the student
+// isn't logged in and a *ParseError if the page has no understandable plan or a row of the plan can't be parsed.
+func ParseSubstitutionPage(r io.Reader) ([]models.Substitution, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(doc.Text(), "abmelden") {
+		return nil, WrongCredentialsError
+	}
+
+	wikitext := doc.Find("#wikitext")
+	if wikitext.Length() == 0 {
+		return nil, &ParseError{Reason: "page has no wikitext"}
+	}
+
+	var tables [][]tableRow
+	wikitext.Find("table").Each(func(_ int, table *goquery.Selection) {
+		tables = append(tables, tableRows(table))
+	})
+
+	substitutions := []models.Substitution{}
+
+	// The first row of the plan which couldn't be parsed. A partial plan would look like cancelled
+	// substitutions, so the whole page is rejected
+	var firstErr *ParseError
+	skip := func(row int, reason string) {
+		logging.Warningf("Can't parse row %d of substitution page: %s", row, reason)
+		if firstErr == nil {
+			firstErr = &ParseError{Row: row, Reason: reason}
+		}
+	}
+
+	day := ""
+	seenDay := false
+	rowNumber := 0
+	for _, rows := range tables {
+		// Tables without days are other content of the page, e.g. a notice before, between or after the days
+		if !hasDayHeader(rows) {
+			rowNumber += len(rows)
+			continue
+		}
+
+		for _, row := range rows {
+			rowNumber++
+
+			cells := row.nonEmptyCells()
+			switch {
+			case len(cells) == 0:
+				continue
+			case row.isDayHeader():
+				seenDay = true
+				day = strings.Join(cells, " ")
+				if dateRegex.FindString(day) == "" {
+					skip(rowNumber, fmt.Sprintf("day %q has no date", day))
+					day = ""
+				}
+				continue
+			case row.onlyHead || periodColumnTitles[strings.ToLower(row.cells[columnPeriod])]:
+				continue
+			case len(cells) == 1:
+				// Notes like "Keine Vertretungen" which span the whole table
+				continue
+			case day == "":
+				skip(rowNumber, "substitution without a day")
+				continue
+			case row.cells[columnPeriod] != "" && !periodCellRegex.MatchString(row.cells[columnPeriod]):
+				skip(rowNumber, fmt.Sprintf("unexpected period %q", row.cells[columnPeriod]))
+				continue
+			}
+
+			substitutions = append(substitutions, newSubstitution(day, row.cells))
+		}
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if !seenDay && hasSubstitutionRow(tables) {
+		return nil, &ParseError{Reason: "substitutions without any day"}
+	}
+
+	return substitutions, nil
+}
+
+func hasDayHeader(rows []tableRow) bool {
+	for _, row := range rows {
+		if row.isDayHeader() {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns true if a row of the tables looks like a substitution
+func hasSubstitutionRow(tables [][]tableRow) bool {
+	for _, rows := range tables {
+		for _, row := range rows {
+			if len(row.nonEmptyCells()) > 1 && periodCellRegex.MatchString(row.cells[columnPeriod]) {
+				return true
+			}
+		}
+	}
+	return false
+}
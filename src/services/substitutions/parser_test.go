@@ -0,0 +1,182 @@
+package substitutions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/dattito/purrmannplus-backend/app/models"
+	"github.com/dattito/purrmannplus-backend/services/substitutions/substitutionstest"
+	"github.com/dattito/purrmannplus-backend/utils/httpclient"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	page, err := ioutil.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+func TestParseSubstitutionPage(t *testing.T) {
+	tests := []struct {
+		fixture string
+		want    []models.Substitution
+	}{
+		{"no_substitutions.html", []models.Substitution{}},
+		{"single_day.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Mo 13.12.", Period: "3 - 4", Class: "10a", Subject: "Englisch", OriginalTeacher: "BAU", Note: "entfällt"},
+		}},
+		{"multiple_days.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Di 14.12.", Period: "2", Class: "10a", Subject: "Deutsch", OriginalTeacher: "KRA", SubstituteTeacher: "LEH", Room: "210", Note: "Aufgaben im Moodle"},
+			{Date: "Di 14.12.", Period: "6", Class: "10a", Subject: "Sport", OriginalTeacher: "WEB", Note: "entfällt"},
+			{Date: "Mi 15.12.", Period: "5", Class: "10a", Subject: "Chemie", OriginalTeacher: "FIS", SubstituteTeacher: "FIS", Room: "NW2", Note: "Raumänderung"},
+		}},
+		{"multiple_tables.html", []models.Substitution{
+			{Date: "Do 16.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Fr 17.12.", Period: "4", Class: "10a", Subject: "Physik", OriginalTeacher: "HOF", SubstituteTeacher: "MÜL", Room: "NW1"},
+		}},
+		{"empty_rows.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Mo 13.12.", Period: "2", Class: "10a", Subject: "Bio", OriginalTeacher: "SCH", SubstituteTeacher: "MÜL", Room: "NW3"},
+		}},
+		{"saturday.html", []models.Substitution{
+			{Date: "Fr 17.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Sa 18.12.", Period: "1 - 4", Class: "10a", Subject: "Exkursion", OriginalTeacher: "BAU", SubstituteTeacher: "BAU", Note: "Treffpunkt Bahnhof"},
+		}},
+		{"header_rows.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Dienstag, 14.12.", Period: "2", Class: "10a", Subject: "Deutsch", OriginalTeacher: "KRA", SubstituteTeacher: "LEH", Room: "210"},
+		}},
+		{"colspan.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", Room: "104"},
+			{Date: "Mo 13.12.", Period: "5 - 6", Class: "10a", Subject: "Kunst", OriginalTeacher: "ROT", Note: "entfällt"},
+		}},
+		{"notice_table.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+		}},
+		{"trailing_table.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+		}},
+		{"notice_between_days.html", []models.Substitution{
+			{Date: "Mo 13.12.", Period: "1", Class: "10a", Subject: "Mathe", OriginalTeacher: "MÜL", SubstituteTeacher: "SCH", Room: "104"},
+			{Date: "Di 14.12.", Period: "2", Class: "10a", Subject: "Deutsch", OriginalTeacher: "KRA", SubstituteTeacher: "LEH", Room: "210"},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			got, err := ParseSubstitutionPage(bytes.NewReader(readFixture(t, test.fixture)))
+			if err != nil {
+				t.Fatalf("ParseSubstitutionPage() error = %v", err)
+			}
+
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseSubstitutionPage() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseSubstitutionPageErrors(t *testing.T) {
+	tests := []struct {
+		fixture string
+		row     int // Row of the expected *ParseError, -1 for WrongCredentialsError
+	}{
+		{"logged_out.html", -1},
+		{"no_wikitext.html", 0},
+		{"days_missing.html", 0},
+		{"day_without_date.html", 1},
+		{"unexpected_columns.html", 2},
+		{"odd_row.html", 3},
+		{"row_before_day.html", 1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			_, err := ParseSubstitutionPage(bytes.NewReader(readFixture(t, test.fixture)))
+
+			if test.row == -1 {
+				if err != WrongCredentialsError {
+					t.Errorf("ParseSubstitutionPage() error = %v, want WrongCredentialsError", err)
+				}
+				return
+			}
+
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("ParseSubstitutionPage() error = %v, want a *ParseError", err)
+			}
+
+			if parseErr.Row != test.row {
+				t.Errorf("ParseSubstitutionPage() error in row %d (%v), want row %d", parseErr.Row, err, test.row)
+			}
+		})
+	}
+}
+
+// Cut off pages, like they are received from a dropped connection, must not make the parser panic
+func TestParseSubstitutionPageTruncated(t *testing.T) {
+	fixtures, err := filepath.Glob(filepath.Join("testdata", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		page := readFixture(t, filepath.Base(fixture))
+		for i := 0; i < len(page); i += 7 {
+			ParseSubstitutionPage(bytes.NewReader(page[:i]))
+		}
+	}
+}
+
+func TestBeginsWithAWeekday(t *testing.T) {
+	tests := map[string]bool{
+		"":                 false,
+		"   ":              false,
+		"Mo 13.12.":        true,
+		"Sa 18.12.":        true,
+		"Freitag, 17.12.":  true,
+		"Do. 16.12.":       true,
+		"1 10a Mathe":      false,
+		"Doppelstunde 3/4": false,
+	}
+
+	for s, want := range tests {
+		if got := beginsWithAWeekday(s); got != want {
+			t.Errorf("beginsWithAWeekday(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestGetSubstituationOfStudent(t *testing.T) {
+	pmwiki := substitutionstest.NewServer()
+	defer pmwiki.Close()
+
+	pmwiki.AddStudent("max.mustermann", "geheim")
+	pmwiki.SetPage("max.mustermann", string(readFixture(t, "multiple_days.html")))
+
+	c := NewClient(pmwiki.URL, httpclient.New(http.DefaultClient))
+
+	got, err := c.GetSubstituationOfStudent(context.Background(), "max.mustermann", "geheim")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 4 || got[3].Date != "Mi 15.12." {
+		t.Errorf("GetSubstituationOfStudent() = %+v, want the substitutions of the page", got)
+	}
+
+	if _, err := c.GetSubstituationOfStudent(context.Background(), "max.mustermann", "falsch"); err != WrongCredentialsError {
+		t.Errorf("GetSubstituationOfStudent() with a wrong password error = %v, want WrongCredentialsError", err)
+	}
+}
@@ -0,0 +1,80 @@
+package substitutions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dattito/purrmannplus-backend/utils"
+)
+
+var periodRegex = regexp.MustCompile(`^\s*(\d{1,2})(?:\s*-\s*(\d{1,2}))?`)
+
+// The time of day a period starts and ends at
+type PeriodTime struct {
+	Start time.Duration // Since midnight
+	End   time.Duration // Since midnight
+}
+
+// Parses the times of the periods like "07:45-08:30,08:35-09:20", the first entry is the first period
+func ParsePeriodTimes(str string) ([]PeriodTime, error) {
+	var periodTimes []PeriodTime
+	for _, part := range strings.Split(str, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		clocks := strings.Split(part, "-")
+		if len(clocks) != 2 {
+			return nil, fmt.Errorf("invalid period time %q", part)
+		}
+
+		start, err := utils.ParseClock(clocks[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid period time %q", part)
+		}
+
+		end, err := utils.ParseClock(clocks[1])
+		if err != nil || end <= start {
+			return nil, fmt.Errorf("invalid period time %q", part)
+		}
+
+		periodTimes = append(periodTimes, PeriodTime{Start: start, End: end})
+	}
+	return periodTimes, nil
+}
+
+// Returns the first period of a period like "3" or "3 - 4"
+func PeriodNumber(period string) (int, bool) {
+	match := periodRegex.FindStringSubmatch(period)
+	if match == nil {
+		return 0, false
+	}
+
+	first, _ := strconv.Atoi(match[1])
+	return first, true
+}
+
+// Returns the start and the end of a period like "3" or "3 - 4" on the given date,
+// ok is false if the period is unknown
+func PeriodTimeRange(period string, date time.Time, periodTimes []PeriodTime) (start, end time.Time, ok bool) {
+	match := periodRegex.FindStringSubmatch(period)
+	if match == nil {
+		return time.Time{}, time.Time{}, false
+	}
+
+	first, _ := strconv.Atoi(match[1])
+	last := first
+	if match[2] != "" {
+		last, _ = strconv.Atoi(match[2])
+	}
+
+	if first < 1 || last < first || last > len(periodTimes) {
+		return time.Time{}, time.Time{}, false
+	}
+
+	midnight := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	return midnight.Add(periodTimes[first-1].Start), midnight.Add(periodTimes[last-1].End), true
+}
@@ -0,0 +1,151 @@
+package substitutionstest
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// The substitutions of a single day, every row has the columns of the plan
+// (period, class, subject, original teacher, substitute teacher, room, note)
+type Day struct {
+	Header string // e.g. "Mo 13.12."
+	Rows   [][]string
+}
+
+type student struct {
+	password string
+	days     []Day
+	page     string // Served instead of the rendered days if set
+}
+
+// A fake of the pmwiki of the school for tests, which serves the substitution pages of its students
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	students map[string]*student
+	requests int
+}
+
+// Starts a pmwiki without any students, the caller has to call Close when finished
+func NewServer() *Server {
+	s := &Server{students: map[string]*student{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// Adds a student without substitutions who logs in with the given credentials
+func (s *Server) AddStudent(authId, authPw string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.students[strings.ToLower(authId)] = &student{password: authPw}
+}
+
+// Replaces the substitutions shown to the student, no days produce a page without a substitution table
+func (s *Server) SetSubstitutions(authId string, days ...Day) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := s.student(authId)
+	st.days, st.page = days, ""
+}
+
+// Serves the given html to the logged in student instead of the substitutions, e.g. a saved page
+func (s *Server) SetPage(authId, page string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.student(authId).page = page
+}
+
+// Returns the number of requests the pmwiki answered
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+func (s *Server) student(authId string) *student {
+	st, ok := s.students[strings.ToLower(authId)]
+	if !ok {
+		panic(fmt.Sprintf("substitutionstest: unknown student %s", authId))
+	}
+	return st
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	if r.URL.Path != "/pmwiki/pmwiki.php" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	authId := r.PostForm.Get("authid")
+	st, ok := s.students[strings.ToLower(authId)]
+	if !ok || st.password != r.PostForm.Get("authpw") || !strings.EqualFold(r.URL.Query().Get("n"), "Main."+authId) {
+		fmt.Fprint(w, loginPage)
+		return
+	}
+
+	if st.page != "" {
+		fmt.Fprint(w, st.page)
+		return
+	}
+
+	fmt.Fprint(w, RenderPage(authId, st.days))
+}
+
+const loginPage = `<!DOCTYPE html>
+<html><body>
+<div id="wikitext">
+<form method="post"><input type="text" name="authid"><input type="password" name="authpw"><input type="submit" value="anmelden"></form>
+</div>
+</body></html>`
+
+// Returns the page of a logged in student like the pmwiki renders it.
+// The first table holds the logout link, the substitutions follow in a table with a header row per day.
+func RenderPage(authId string, days []Day) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "<!DOCTYPE html>\n<html><head><title>Main - %s</title></head><body>\n", html.EscapeString(authId))
+	fmt.Fprintf(&b, "<table id=\"wikihead\"><tr><td><a href=\"pmwiki.php?n=Main.%s&amp;action=logout\">abmelden</a></td></tr></table>\n",
+		html.EscapeString(authId))
+	b.WriteString("<div id=\"wikitext\">\n<div class=\"vertretungen\">\n")
+
+	if len(days) == 0 {
+		b.WriteString("<p>Zur Zeit gibt es keine Vertretungen.</p>\n")
+	} else {
+		b.WriteString("<table>\n")
+		for _, day := range days {
+			fmt.Fprintf(&b, "<tr><td colspan=\"7\"><strong>%s</strong></td></tr>\n", html.EscapeString(day.Header))
+			for _, row := range day.Rows {
+				b.WriteString("<tr>")
+				for _, cell := range row {
+					fmt.Fprintf(&b, "<td>%s</td>", html.EscapeString(cell))
+				}
+				b.WriteString("</tr>\n")
+			}
+		}
+		b.WriteString("</table>\n")
+	}
+
+	b.WriteString("</div>\n</div>\n</body></html>\n")
+	return b.String()
+}
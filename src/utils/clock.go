@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"strings"
+	"time"
+)
+
+// Parses a time of day like "07:45", returns the duration since midnight
+func ParseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Parses comma separated times of day like "06:30,18:00"
+func ParseClocks(str string) ([]time.Duration, error) {
+	var clocks []time.Duration
+	for _, part := range strings.Split(str, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		clock, err := ParseClock(part)
+		if err != nil {
+			return nil, err
+		}
+		clocks = append(clocks, clock)
+	}
+	return clocks, nil
+}
+
+// Returns the duration since midnight of the given time
+func SinceMidnight(t time.Time) time.Duration {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return t.Sub(midnight)
+}
@@ -0,0 +1,126 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Prefix of all values encrypted by Cipher.Encrypt(), used to tell them apart from old plaintext values
+const encryptedPrefix = "enc:v1:"
+
+// Encrypts and decrypts values with a key derived from a secret
+type Cipher struct {
+	keyEncryptionKey []byte // Encrypts the data keys
+}
+
+// Returns a Cipher which derives the key which encrypts the data keys from the given secret
+func NewCipher(secret string) *Cipher {
+	key := sha256.Sum256([]byte(secret))
+	return &Cipher{keyEncryptionKey: key[:]}
+}
+
+// Encrypts the plaintext using AES-GCM, the nonce is prepended to the ciphertext
+func seal(key, plaintext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Decrypts a ciphertext produced by seal()
+func open(key, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+
+	return gcm.Open(nil, ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():], nil)
+}
+
+// Returns true if the value was encrypted by Cipher.Encrypt()
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+// Encrypts the plaintext with a new random data key. The data key itself is encrypted
+// with the key of the Cipher and stored next to the ciphertext (envelope encryption).
+func (c *Cipher) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+
+	encryptedDataKey, err := seal(c.keyEncryptionKey, dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix +
+		base64.RawStdEncoding.EncodeToString(encryptedDataKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypts a value produced by Encrypt() with the same secret
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return "", errors.New("value is not encrypted")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if len(parts) != 2 {
+		return "", errors.New("malformed encrypted value")
+	}
+
+	encryptedDataKey, err := base64.RawStdEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", err
+	}
+
+	dataKey, err := open(c.keyEncryptionKey, encryptedDataKey)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dataKey, ciphertext)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
@@ -0,0 +1,32 @@
+package encryption
+
+import "testing"
+
+func TestCipherDecryptsItsOwnValues(t *testing.T) {
+	c := NewCipher("secret")
+
+	value, err := c.Encrypt("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsEncrypted(value) {
+		t.Errorf("IsEncrypted(%q) = false, want true", value)
+	}
+
+	plaintext, err := c.Decrypt(value)
+	if err != nil || plaintext != "password" {
+		t.Errorf("Decrypt() = %q, %v, want \"password\", nil", plaintext, err)
+	}
+}
+
+func TestCiphersWithDifferentSecretsDontShareTheKey(t *testing.T) {
+	value, err := NewCipher("secret").Encrypt("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewCipher("other secret").Decrypt(value); err == nil {
+		t.Error("Decrypt() with another secret = nil, want an error")
+	}
+}
@@ -0,0 +1,18 @@
+package encryption
+
+import "golang.org/x/crypto/bcrypt"
+
+// Returns a salted hash of the password
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Returns true if the password matches the hash produced by HashPassword()
+func CheckPasswordHash(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
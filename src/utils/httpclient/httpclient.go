@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// Sends http requests, implemented by *http.Client
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Sends http requests with a rate limit per host
+type Client struct {
+	doer     Doer
+	limiters map[string]*rate.Limiter
+	mu       sync.RWMutex
+}
+
+// Returns a Client without any rate limits, which sends the requests with the given Doer
+func New(doer Doer) *Client {
+	return &Client{
+		doer:     doer,
+		limiters: map[string]*rate.Limiter{},
+	}
+}
+
+// Limits the requests to the host of the given url to requestsPerSecond, 0 removes the limit
+func (c *Client) SetRateLimit(rawUrl string, requestsPerSecond int) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if requestsPerSecond <= 0 {
+		delete(c.limiters, u.Host)
+		return nil
+	}
+
+	c.limiters[u.Host] = rate.NewLimiter(rate.Limit(requestsPerSecond), requestsPerSecond)
+	return nil
+}
+
+// Sends the request after waiting for the rate limit of its host, returns early if the context of the request is done
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	c.mu.RLock()
+	limiter, ok := c.limiters[req.URL.Host]
+	c.mu.RUnlock()
+
+	if ok {
+		if err := limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.doer.Do(req)
+}
+
+// Same as http.Get(), but with a context and the rate limit of the host
+func (c *Client) Get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
+
+// Same as http.PostForm(), but with a context and the rate limit of the host
+func (c *Client) PostForm(ctx context.Context, url string, data url.Values) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return c.Do(req)
+}
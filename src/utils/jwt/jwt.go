@@ -4,21 +4,37 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/dattito/purrmannplus-backend/config"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-// Creates a new JWT token for the given user including the account_id
-func NewAccountIdToken(accountId string) (string, time.Time, error) {
-	expires := time.Now().Add(time.Duration(config.AUTHORIZATION_EXPIRATION_TIME) * time.Second)
+// Creates and parses the JWT tokens with the configured secrets
+type Issuer struct {
+	secret            []byte
+	shortLivingSecret []byte
+	expiration        time.Duration
+}
+
+// Returns an Issuer, tokens created by NewAccountIdToken() expire after the given duration
+func NewIssuer(secret, shortLivingSecret string, expiration time.Duration) *Issuer {
+	return &Issuer{
+		secret:            []byte(secret),
+		shortLivingSecret: []byte(shortLivingSecret),
+		expiration:        expiration,
+	}
+}
+
+// Creates a new JWT token for the given user including the account_id and the role
+func (i *Issuer) NewAccountIdToken(accountId, role string) (string, time.Time, error) {
+	expires := time.Now().Add(i.expiration)
 
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	claims := token.Claims.(jwt.MapClaims)
 	claims["account_id"] = accountId
+	claims["role"] = role
 	claims["exp"] = expires.Unix()
 
-	t, err := token.SignedString([]byte(config.JWT_SECRET))
+	t, err := token.SignedString(i.secret)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -27,7 +43,7 @@ func NewAccountIdToken(accountId string) (string, time.Time, error) {
 }
 
 // Creates a new short living JWT token for the user including the account_id and the phone_number
-func NewAccountIdPhoneNumberToken(accountId, phone_number string) (string, error) {
+func (i *Issuer) NewAccountIdPhoneNumberToken(accountId, phone_number string) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	claims := token.Claims.(jwt.MapClaims)
@@ -35,7 +51,7 @@ func NewAccountIdPhoneNumberToken(accountId, phone_number string) (string, error
 	claims["phone_number"] = phone_number
 	claims["exp"] = time.Now().Add(time.Minute * 10).Unix()
 
-	t, err := token.SignedString([]byte(config.JWT_SHORTLIVING_SECRET))
+	t, err := token.SignedString(i.shortLivingSecret)
 	if err != nil {
 		return "", err
 	}
@@ -44,13 +60,13 @@ func NewAccountIdPhoneNumberToken(accountId, phone_number string) (string, error
 }
 
 // Returns the account_id from the given token
-func ParseAccountIdToken(tokenString string) (string, error) {
+func (i *Issuer) ParseAccountIdToken(tokenString string) (string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(config.JWT_SECRET), nil
+		return i.secret, nil
 	})
 	if err != nil {
 		return "", err
@@ -61,13 +77,13 @@ func ParseAccountIdToken(tokenString string) (string, error) {
 }
 
 // Returns the account_id and phone_number from the given token
-func ParseAccountIdPhoneNumberToken(tokenString string) (string, string, error) {
+func (i *Issuer) ParseAccountIdPhoneNumberToken(tokenString string) (string, string, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return []byte(config.JWT_SHORTLIVING_SECRET), nil
+		return i.shortLivingSecret, nil
 	})
 	if err != nil {
 		return "", "", err
@@ -3,8 +3,6 @@ package logging
 import (
 	"log"
 	"os"
-
-	"github.com/dattito/purrmannplus-backend/config"
 )
 
 var (
@@ -26,14 +24,14 @@ const (
 	LEVEL_SILENT  = 0
 )
 
-// Initialize the logging objects
-func Init() error {
-	logLevel = config.LOG_LEVEL
+// Initialize the logging objects with the given log level, logs to stdout if file is empty
+func Init(level int, file string) error {
+	logLevel = level
 
 	var f *os.File
-	if config.LOGGING_FILE != "" {
+	if file != "" {
 		var err error
-		f, err = os.OpenFile(config.LOGGING_FILE, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+		f, err = os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
 		if err != nil {
 			return err
 		}
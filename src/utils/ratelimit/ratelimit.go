@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type window struct {
+	start    time.Time
+	attempts int
+}
+
+// Counts attempts per key, e.g. per username or ip, and refuses them after a maximum within a time window
+type KeyLimiter struct {
+	max    int
+	length time.Duration
+
+	mu      sync.Mutex
+	windows map[string]*window
+}
+
+// Returns a KeyLimiter which allows max attempts per key within the given duration
+func NewKeyLimiter(max int, length time.Duration) *KeyLimiter {
+	return &KeyLimiter{
+		max:     max,
+		length:  length,
+		windows: map[string]*window{},
+	}
+}
+
+// Counts an attempt for the key. Returns false without counting if the key has no attempts left.
+func (l *KeyLimiter) Allow(now time.Time, key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Expired windows are removed, so that the map doesn't grow with every ip
+	for k, w := range l.windows {
+		if now.Sub(w.start) >= l.length {
+			delete(l.windows, k)
+		}
+	}
+
+	w, ok := l.windows[key]
+	if !ok {
+		w = &window{start: now}
+		l.windows[key] = w
+	}
+
+	if w.attempts >= l.max {
+		return false
+	}
+
+	w.attempts++
+	return true
+}
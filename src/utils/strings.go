@@ -38,3 +38,20 @@ func GenerateString(n int) string {
 func NumberInString(str string) bool {
 	return strings.ContainsAny(str, "0123456789")
 }
+
+// Parses comma separated durations like "24h,2h"
+func ParseDurations(str string) ([]time.Duration, error) {
+	var durations []time.Duration
+	for _, part := range strings.Split(str, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		duration, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"crypto/rand"
+	"encoding/hex"
 	"math/big"
 )
 
@@ -14,3 +15,13 @@ func GenerateValidationCode(length int) string {
 
 	return code
 }
+
+// Generates a random hex token from n bytes of a cryptographically secure source
+func GenerateToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
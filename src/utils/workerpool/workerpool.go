@@ -0,0 +1,108 @@
+package workerpool
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// The result of a single job
+type Result struct {
+	MessagesSent int
+	Err          error
+}
+
+// The aggregated results of all jobs of a run
+type Summary struct {
+	Started      time.Time
+	Finished     time.Time
+	Processed    int
+	Failed       int
+	MessagesSent int
+	Errors       []error
+	Aborted      bool // True if the run was stopped because there were more than MaxErrors errors
+}
+
+type Pool struct {
+	Concurrency int           // Number of jobs running at the same time
+	Timeout     time.Duration // Timeout of a single job, 0 means no timeout
+	MaxErrors   int           // If more jobs fail, the remaining jobs are not started anymore, a negative value disables it
+}
+
+// Used as MaxErrors to run all jobs regardless of failures. 0 stops after the first failure, like the MAX_ERROS_* settings do.
+const NoErrorLimit = -1
+
+// Runs job(ctx, i) for every i in [0, jobs) and waits until all jobs are done
+func (p Pool) Run(jobs int, job func(ctx context.Context, i int) Result) Summary {
+	summary := Summary{Started: time.Now()}
+
+	concurrency := p.Concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Stops starting new jobs, jobs which are already running are finished
+	dispatchCtx, stopDispatching := context.WithCancel(context.Background())
+	defer stopDispatching()
+
+	indices := make(chan int)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				results <- p.runJob(i, job)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(indices)
+		for i := 0; i < jobs; i++ {
+			select {
+			case indices <- i:
+			case <-dispatchCtx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	for r := range results {
+		summary.Processed++
+		summary.MessagesSent += r.MessagesSent
+
+		if r.Err != nil {
+			summary.Failed++
+			summary.Errors = append(summary.Errors, r.Err)
+
+			if p.MaxErrors >= 0 && summary.Failed > p.MaxErrors && !summary.Aborted {
+				summary.Aborted = true
+				stopDispatching()
+			}
+		}
+	}
+
+	summary.Finished = time.Now()
+
+	return summary
+}
+
+// Runs a single job with the timeout of the pool
+func (p Pool) runJob(i int, job func(ctx context.Context, i int) Result) Result {
+	ctx := context.Background()
+	if p.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.Timeout)
+		defer cancel()
+	}
+
+	return job(ctx, i)
+}